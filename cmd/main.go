@@ -12,15 +12,13 @@ import (
 	justcli "just-icon/internal/cli"
 	"just-icon/internal/i18n"
 	"just-icon/internal/interactive"
+	"just-icon/pkg/utils"
 )
 
 func main() {
 	// Initialize default language (English)
 	i18n.InitLocalizer(i18n.English)
 
-	// Show ASCII art banner
-	banner.ShowBanner()
-
 	cmd := &cli.Command{
 		Name:        i18n.T("app_name"),
 		Usage:       i18n.T("app_usage"),
@@ -29,6 +27,59 @@ func main() {
 		Commands: []*cli.Command{
 			justcli.NewConfigCommand(),
 			justcli.NewResetCommand(),
+			justcli.NewDoctorCommand(),
+			justcli.NewTemplateCommand(),
+			justcli.NewPresetCommand(),
+			justcli.NewBrandCommand(),
+			justcli.NewHistoryCommand(),
+			justcli.NewInspectCommand(),
+			justcli.NewGalleryCommand(),
+			justcli.NewExportCommand(),
+			justcli.NewServeCommand(),
+			justcli.NewMCPCommand(),
+			justcli.NewJobsCommand(),
+		},
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "preset",
+				Usage:   i18n.T("flag_preset"),
+				Aliases: []string{"p"},
+			},
+			&cli.StringFlag{
+				Name:    "template",
+				Usage:   i18n.T("flag_template"),
+				Aliases: []string{"t"},
+			},
+			&cli.BoolFlag{
+				Name:    "enhance",
+				Usage:   i18n.T("flag_enhance"),
+				Aliases: []string{"e"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Usage:   i18n.T("flag_quiet"),
+				Aliases: []string{"q"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-banner",
+				Usage: i18n.T("flag_no_banner"),
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: i18n.T("flag_no_color"),
+			},
+			&cli.BoolFlag{
+				Name:  "verbose",
+				Usage: i18n.T("flag_verbose"),
+			},
+			&cli.BoolFlag{
+				Name:  "debug",
+				Usage: i18n.T("flag_debug"),
+			},
+			&cli.BoolFlag{
+				Name:  "no-log",
+				Usage: i18n.T("flag_no_log"),
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			// Check for language argument
@@ -38,8 +89,18 @@ func main() {
 				i18n.SwitchLanguage(lang)
 			}
 
+			// The workspace needs a terminal to draw in and read keys from
+			if !utils.CanPrompt() {
+				utils.PrintError(i18n.T("interactive_requires_terminal"))
+				return cli.Exit("", 1)
+			}
+
 			// Run interactive mode
-			err := interactive.RunInteractiveMode()
+			err := interactive.RunInteractiveMode(interactive.Options{
+				Preset:   cmd.String("preset"),
+				Template: cmd.String("template"),
+				Enhance:  cmd.Bool("enhance"),
+			})
 			if err != nil {
 				// Check if it's a user quit error, exit silently
 				if errors.Is(err, interactive.ErrUserQuit) {
@@ -55,6 +116,14 @@ func main() {
 				firstArg := args.Get(0)
 				i18n.SwitchLanguage(firstArg)
 			}
+
+			// Apply the output flags, then show the ASCII art banner on terminals
+			utils.ConfigureOutput(cmd.Bool("quiet"), cmd.Bool("no-color"))
+			if utils.Decorated() && !utils.Quiet() && !cmd.Bool("no-banner") {
+				banner.ShowBanner()
+			}
+			justcli.SetupLogging(cmd.Bool("verbose"), cmd.Bool("debug"), cmd.Bool("no-log"))
+			justcli.SetupHTTP()
 			return ctx, nil
 		},
 	}
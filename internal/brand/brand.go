@@ -0,0 +1,185 @@
+package brand
+
+import (
+	"fmt"
+	"strings"
+
+	"just-icon/internal/i18n"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// DefaultTolerance is the color difference from the palette accepted by
+	// the palette check when the kit does not set one
+	DefaultTolerance = 30.0
+
+	// forbiddenDistance is how close a color must be to a forbidden color to
+	// be reported
+	forbiddenDistance = 15.0
+
+	// minShare ignores colors covering less than this fraction of the image
+	minShare = 0.05
+
+	// neutralChroma treats greys, black and white as always allowed
+	neutralChroma = 12.0
+
+	// dominantColors is the number of colors extracted from an image
+	dominantColors = 6
+)
+
+// Issue is a dominant image color that does not fit the brand kit
+type Issue struct {
+	Color utils.ColorShare
+	// Nearest is the closest palette color, nil when the kit has no palette
+	Nearest  *types.BrandColor
+	Distance float64
+	// Forbidden is the forbidden color the image color matches, if any
+	Forbidden string
+}
+
+// Report is the result of a palette check
+type Report struct {
+	Dominant []utils.ColorShare
+	Issues   []Issue
+}
+
+// IsEmpty reports whether the kit sets no constraints
+func IsEmpty(kit *types.BrandKit) bool {
+	return kit == nil || (len(kit.Colors) == 0 && len(kit.ForbiddenColors) == 0 &&
+		len(kit.Mood) == 0 && kit.Typography == "" && kit.ReferenceLogo == "")
+}
+
+// Validate checks the kit's colors and reference logo
+func Validate(kit *types.BrandKit) error {
+	seen := make(map[string]bool)
+	for _, c := range kit.Colors {
+		if c.Name == "" {
+			return fmt.Errorf("brand color %s needs a name", c.Hex)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate brand color name: %s", c.Name)
+		}
+		seen[c.Name] = true
+		if _, err := utils.ParseHexColor(c.Hex); err != nil {
+			return err
+		}
+	}
+	for _, hex := range kit.ForbiddenColors {
+		if _, err := utils.ParseHexColor(hex); err != nil {
+			return err
+		}
+	}
+	if kit.Tolerance < 0 {
+		return fmt.Errorf("tolerance must not be negative")
+	}
+	if kit.ReferenceLogo != "" && !utils.FileExists(LogoPath(kit)) {
+		return fmt.Errorf("reference logo not found: %s", kit.ReferenceLogo)
+	}
+	return nil
+}
+
+// LogoPath returns the reference logo path with ~ expanded, or "" when unset
+func LogoPath(kit *types.BrandKit) string {
+	if kit == nil || kit.ReferenceLogo == "" {
+		return ""
+	}
+	return utils.ExpandHome(kit.ReferenceLogo)
+}
+
+// ApplyVars fills the brand variables of a prompt template
+func ApplyVars(kit *types.BrandKit, vars *templates.Vars) {
+	if kit == nil {
+		return
+	}
+
+	var palette []string
+	for _, c := range kit.Colors {
+		palette = append(palette, strings.TrimSpace(c.Name+" "+c.Hex))
+	}
+	vars.Palette = strings.Join(palette, ", ")
+	vars.Avoid = strings.Join(kit.ForbiddenColors, ", ")
+	vars.Mood = strings.Join(kit.Mood, ", ")
+	vars.Typography = kit.Typography
+	vars.Reference = kit.ReferenceLogo != ""
+}
+
+// Check extracts the dominant colors of the image at path and reports the
+// ones that are far from the palette or close to a forbidden color. Neutral
+// colors and colors covering only a small part of the image are ignored.
+func Check(kit *types.BrandKit, path string) (*Report, error) {
+	img, err := utils.LoadImage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Dominant: utils.DominantColors(img, dominantColors)}
+	if kit == nil {
+		return report, nil
+	}
+
+	tolerance := kit.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+
+	for _, dominant := range report.Dominant {
+		if dominant.Share < minShare || utils.ColorChroma(dominant.Color) < neutralChroma {
+			continue
+		}
+
+		if forbidden := matchForbidden(kit, dominant); forbidden != "" {
+			report.Issues = append(report.Issues, Issue{Color: dominant, Forbidden: forbidden})
+			continue
+		}
+
+		nearest, distance := nearestColor(kit, dominant)
+		if nearest != nil && distance > tolerance {
+			report.Issues = append(report.Issues, Issue{Color: dominant, Nearest: nearest, Distance: distance})
+		}
+	}
+	return report, nil
+}
+
+// matchForbidden returns the forbidden color close to dominant, if any
+func matchForbidden(kit *types.BrandKit, dominant utils.ColorShare) string {
+	for _, hex := range kit.ForbiddenColors {
+		c, err := utils.ParseHexColor(hex)
+		if err != nil {
+			continue
+		}
+		if utils.ColorDistance(c, dominant.Color) <= forbiddenDistance {
+			return hex
+		}
+	}
+	return ""
+}
+
+// nearestColor returns the palette color closest to dominant
+func nearestColor(kit *types.BrandKit, dominant utils.ColorShare) (*types.BrandColor, float64) {
+	var nearest *types.BrandColor
+	best := 0.0
+	for i := range kit.Colors {
+		c, err := utils.ParseHexColor(kit.Colors[i].Hex)
+		if err != nil {
+			continue
+		}
+		distance := utils.ColorDistance(c, dominant.Color)
+		if nearest == nil || distance < best {
+			nearest = &kit.Colors[i]
+			best = distance
+		}
+	}
+	return nearest, best
+}
+
+// Message describes the issue for the user
+func (i Issue) Message() string {
+	hex := utils.HexColor(i.Color.Color)
+	share := i.Color.Share * 100
+	if i.Forbidden != "" {
+		return i18n.Tf("brand_issue_forbidden", hex, share, i.Forbidden)
+	}
+	return i18n.Tf("brand_issue_drift", hex, share, i.Nearest.Name, i.Nearest.Hex, i.Distance)
+}
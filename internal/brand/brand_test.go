@@ -0,0 +1,104 @@
+package brand
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+)
+
+// writeImage saves a PNG split vertically between left and right at split percent
+func writeImage(t *testing.T, left, right color.RGBA, split int) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if x < split {
+				img.Set(x, y, left)
+			} else {
+				img.Set(x, y, right)
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "icon.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheck(t *testing.T) {
+	kit := &types.BrandKit{
+		Colors:          []types.BrandColor{{Name: "primary", Hex: "#1E88E5"}},
+		ForbiddenColors: []string{"#E53935"},
+	}
+	blue := color.RGBA{0x20, 0x8A, 0xE0, 255}
+	white := color.RGBA{250, 250, 250, 255}
+	red := color.RGBA{0xE5, 0x39, 0x35, 255}
+	green := color.RGBA{0x43, 0xA0, 0x47, 255}
+
+	tests := []struct {
+		name      string
+		right     color.RGBA
+		issues    int
+		forbidden bool
+	}{
+		{"on palette with neutral background", white, 0, false},
+		{"forbidden color", red, 1, true},
+		{"drift", green, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Check(kit, writeImage(t, blue, tt.right, 60))
+			if err != nil {
+				t.Fatalf("Check() failed: %v", err)
+			}
+			if len(report.Issues) != tt.issues {
+				t.Fatalf("expected %d issues, got %+v", tt.issues, report.Issues)
+			}
+			if tt.issues > 0 && (report.Issues[0].Forbidden != "") != tt.forbidden {
+				t.Errorf("unexpected issue %+v", report.Issues[0])
+			}
+		})
+	}
+}
+
+func TestApplyVars(t *testing.T) {
+	vars := templates.Vars{Prompt: "rocket"}
+	ApplyVars(&types.BrandKit{
+		Colors:          []types.BrandColor{{Name: "primary", Hex: "#1E88E5"}, {Name: "accent", Hex: "#FFC107"}},
+		ForbiddenColors: []string{"#E53935"},
+		Mood:            []string{"friendly", "calm"},
+		Typography:      "rounded sans-serif",
+		ReferenceLogo:   "logo.png",
+	}, &vars)
+
+	if vars.Palette != "primary #1E88E5, accent #FFC107" || vars.Avoid != "#E53935" ||
+		vars.Mood != "friendly, calm" || vars.Typography != "rounded sans-serif" || !vars.Reference {
+		t.Errorf("unexpected vars %+v", vars)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(&types.BrandKit{Colors: []types.BrandColor{{Name: "primary", Hex: "blue"}}}); err == nil {
+		t.Error("expected invalid hex to fail")
+	}
+	if err := Validate(&types.BrandKit{Colors: []types.BrandColor{{Name: "a", Hex: "#000"}, {Name: "a", Hex: "#fff"}}}); err == nil {
+		t.Error("expected duplicate names to fail")
+	}
+	if err := Validate(&types.BrandKit{ReferenceLogo: filepath.Join(t.TempDir(), "missing.png")}); err == nil {
+		t.Error("expected missing logo to fail")
+	}
+}
@@ -0,0 +1,392 @@
+// Package cassette records HTTP interactions with the image API and replays
+// them, so tests can exercise the client and everything built on it without
+// network access or an API key.
+//
+// A cassette is a directory holding cassette.json and one file per image.
+// Recording never stores headers, strips URL queries (image URLs are often
+// pre-signed) and replaces the given secrets, so cassettes can be committed.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"just-icon/internal/types"
+)
+
+// FileName is the index of a cassette directory
+const FileName = "cassette.json"
+
+// redacted replaces secrets in recorded bodies
+const redacted = "[redacted]"
+
+// filePrefix marks a base64 value that was moved to a file
+const filePrefix = "file:"
+
+// urlQuery matches the query and fragment of URLs
+var urlQuery = regexp.MustCompile(`(https?://[^\s?#"']+)[?#][^\s"'\\]*`)
+
+// Cassette is a recorded series of HTTP interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. Only the method and path are matched on
+// replay; the body is kept so tests can check what was sent.
+type Request struct {
+	Method string `json:"method"`
+	// Path is the URL path, without host and query
+	Path string `json:"path"`
+	// JSON is the body of JSON requests; other bodies, such as the
+	// multipart uploads of edits, are not kept
+	JSON json.RawMessage `json:"json,omitempty"`
+}
+
+// Response is a recorded response. Its body is stored as JSON, text or,
+// for images, a file next to cassette.json. Base64 images inside JSON are
+// moved to files too and referenced as "file:<name>".
+type Response struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	JSON        json.RawMessage `json:"json,omitempty"`
+	Text        string          `json:"text,omitempty"`
+	File        string          `json:"file,omitempty"`
+}
+
+// Load reads the cassette in dir
+func Load(dir string) (*Cassette, error) {
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", dir, err)
+	}
+	return &c, nil
+}
+
+// Recorder is a transport that passes requests to another transport and
+// records them. Save writes what was recorded.
+type Recorder struct {
+	base    http.RoundTripper
+	dir     string
+	secrets []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	files    map[string][]byte
+}
+
+// NewRecorder records the requests sent through base, or through the
+// default transport when base is nil, into the cassette dir. The secrets,
+// e.g. from logging.Secrets, are replaced wherever they appear.
+func NewRecorder(base http.RoundTripper, dir string, secrets []string) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base, dir: dir, secrets: secrets, files: make(map[string][]byte)}
+}
+
+// RoundTrip sends the request and records it with its response. Requests
+// that fail without a response are not recorded.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.cassette.Interactions) + 1
+	interaction := Interaction{
+		Request: Request{Method: req.Method, Path: r.scrub(req.URL.Path)},
+		Response: Response{
+			Status:      resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+		},
+	}
+	if isJSON(req.Header.Get("Content-Type")) && json.Valid(body) {
+		var decoded any
+		json.Unmarshal(body, &decoded)
+		if interaction.Request.JSON, err = r.marshal(decoded); err != nil {
+			return nil, err
+		}
+	}
+	switch mediaType := mediaType(interaction.Response.ContentType); {
+	case isJSON(mediaType) && json.Valid(data):
+		interaction.Response.JSON, err = r.extractImages(data, n)
+		if err != nil {
+			return nil, err
+		}
+	case strings.HasPrefix(mediaType, "image/") || mediaType == "application/octet-stream":
+		name := fmt.Sprintf("%02d-response%s", n, extension(mediaType))
+		r.files[name] = data
+		interaction.Response.File = name
+	default:
+		interaction.Response.Text = r.scrub(string(data))
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return resp, nil
+}
+
+// Save writes the recorded interactions and images into the cassette
+// directory, replacing an earlier recording
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.dir, types.ConfigDirPerm); err != nil {
+		return err
+	}
+	for name, data := range r.files {
+		if err := os.WriteFile(filepath.Join(r.dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.cassette); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.dir, FileName), buf.Bytes(), 0644)
+}
+
+// extractImages moves the base64 images of a JSON response into files and
+// scrubs the rest
+func (r *Recorder) extractImages(data []byte, n int) (json.RawMessage, error) {
+	var body any
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	images := 0
+	body = walkImages(body, func(value string) string {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return value
+		}
+		images++
+		name := fmt.Sprintf("%02d-image-%d%s", n, images, extension(http.DetectContentType(decoded)))
+		r.files[name] = decoded
+		return filePrefix + name
+	})
+	return r.marshal(body)
+}
+
+// marshal encodes a decoded JSON body for the cassette and scrubs it. HTML
+// characters are left unescaped so URL queries are recognized.
+func (r *Recorder) marshal(body any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(r.scrub(strings.TrimSpace(buf.String()))), nil
+}
+
+// scrub strips URL queries and replaces the secrets in a text
+func (r *Recorder) scrub(text string) string {
+	text = urlQuery.ReplaceAllString(text, "$1")
+	for _, secret := range r.secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+	}
+	return text
+}
+
+// Replayer is a transport that answers requests from a cassette. Each
+// request gets the first unused interaction with the same method and path,
+// whatever its host, so image URLs in recorded responses are served too.
+type Replayer struct {
+	dir      string
+	cassette *Cassette
+
+	mu   sync.Mutex
+	used []bool
+}
+
+// NewReplayer loads the cassette in dir for replay
+func NewReplayer(dir string) (*Replayer, error) {
+	c, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{dir: dir, cassette: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// Client returns an HTTP client that replays the cassette
+func (r *Replayer) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip answers the request with its recorded response. A request
+// without one fails, which makes tests notice unexpected calls.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+
+	r.mu.Lock()
+	index := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.used[i] && interaction.Request.Method == req.Method && interaction.Request.Path == req.URL.Path {
+			r.used[i] = true
+			index = i
+			break
+		}
+	}
+	r.mu.Unlock()
+	if index < 0 {
+		return nil, fmt.Errorf("cassette %s has no response for %s %s", r.dir, req.Method, req.URL.Path)
+	}
+
+	recorded := r.cassette.Interactions[index].Response
+	body, err := r.body(recorded)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	if recorded.ContentType != "" {
+		header.Set("Content-Type", recorded.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Unused returns the number of interactions no request has asked for
+func (r *Replayer) Unused() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// body rebuilds a recorded response body, putting base64 images back
+func (r *Replayer) body(recorded Response) ([]byte, error) {
+	switch {
+	case recorded.File != "":
+		return os.ReadFile(filepath.Join(r.dir, recorded.File))
+	case recorded.JSON != nil:
+		var body any
+		if err := json.Unmarshal(recorded.JSON, &body); err != nil {
+			return nil, err
+		}
+		var readErr error
+		body = walkImages(body, func(value string) string {
+			name, ok := strings.CutPrefix(value, filePrefix)
+			if !ok {
+				return value
+			}
+			data, err := os.ReadFile(filepath.Join(r.dir, name))
+			if err != nil {
+				readErr = err
+				return value
+			}
+			return base64.StdEncoding.EncodeToString(data)
+		})
+		if readErr != nil {
+			return nil, readErr
+		}
+		return json.Marshal(body)
+	default:
+		return []byte(recorded.Text), nil
+	}
+}
+
+// walkImages replaces the b64_json values of a decoded JSON body
+func walkImages(value any, replace func(string) string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if s, ok := item.(string); ok && key == "b64_json" && s != "" {
+				v[key] = replace(s)
+				continue
+			}
+			v[key] = walkImages(item, replace)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = walkImages(item, replace)
+		}
+	}
+	return value
+}
+
+// mediaType returns the media type of a Content-Type header
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(contentType)
+	}
+	return mediaType
+}
+
+// isJSON reports whether a content type is JSON
+func isJSON(contentType string) bool {
+	mediaType := mediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// extension returns the file extension for an image content type
+func extension(contentType string) string {
+	switch mediaType(contentType) {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpeg"
+	case "image/webp":
+		return ".webp"
+	}
+	return ".bin"
+}
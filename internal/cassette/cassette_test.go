@@ -0,0 +1,98 @@
+package cassette
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	const secret = "sk-cassette-secret-123"
+	image := []byte("\x89PNG\r\n\x1a\nicon")
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"created":1,"data":[{"b64_json":"`+base64.StdEncoding.EncodeToString(image)+`"},`+
+				`{"url":"`+server.URL+`/files/icon.png?sig=abc&se=2026"}],"note":"key `+secret+`"}`)
+		case "/files/icon.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(image)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "missing "+secret)
+		}
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "cassette")
+	recorder := NewRecorder(nil, dir, []string{secret})
+	client := &http.Client{Transport: recorder}
+	send := func(client *http.Client, method, path, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+secret)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	_, recorded := send(client, "POST", "/v1/images/generations", `{"prompt":"rocket","key":"`+secret+`"}`)
+	send(client, "GET", "/files/icon.png?sig=abc", "")
+	send(client, "GET", "/missing", "")
+	if !strings.Contains(recorded, secret) {
+		t.Fatal("recording changed the response the client sees")
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing secret is written and images are files
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, entry.Name()))
+		for _, leak := range []string{secret, "sig=abc", "Authorization", "Bearer"} {
+			if strings.Contains(string(data), leak) {
+				t.Errorf("%s contains %q", entry.Name(), leak)
+			}
+		}
+	}
+	if len(entries) != 3 {
+		t.Errorf("cassette has %d files, want cassette.json and two images", len(entries))
+	}
+
+	replay, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replay answers any host, as recorded image URLs point elsewhere
+	server.URL = "http://replay.invalid"
+	client = replay.Client()
+	status, body := send(client, "POST", "/v1/images/generations", `{}`)
+	if status != http.StatusOK || !strings.Contains(body, base64.StdEncoding.EncodeToString(image)) || !strings.Contains(body, "/files/icon.png\"") {
+		t.Errorf("replayed generation = %d %s", status, body)
+	}
+	if status, body := send(client, "GET", "/files/icon.png", ""); status != http.StatusOK || body != string(image) {
+		t.Errorf("replayed download = %d %q", status, body)
+	}
+	if status, body := send(client, "GET", "/missing", ""); status != http.StatusNotFound || body != "missing [redacted]" {
+		t.Errorf("replayed error = %d %q", status, body)
+	}
+	if replay.Unused() != 0 {
+		t.Errorf("Unused() = %d, want 0", replay.Unused())
+	}
+	if _, err := client.Get("http://replay.invalid/files/icon.png"); err == nil {
+		t.Error("a request without a recorded response succeeded")
+	}
+}
@@ -0,0 +1,282 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/brand"
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// NewBrandCommand creates the brand command
+func NewBrandCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "brand",
+		Usage:       i18n.T("brand_usage"),
+		Description: i18n.T("brand_description"),
+		Commands: []*cli.Command{
+			{
+				Name:   "show",
+				Usage:  i18n.T("brand_show_usage"),
+				Action: brandShowAction,
+			},
+			{
+				Name:  "set",
+				Usage: i18n.T("brand_set_usage"),
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:    "color",
+						Usage:   i18n.T("brand_flag_color"),
+						Aliases: []string{"c"},
+					},
+					&cli.StringSliceFlag{
+						Name:  "forbid",
+						Usage: i18n.T("brand_flag_forbid"),
+					},
+					&cli.StringSliceFlag{
+						Name:  "mood",
+						Usage: i18n.T("brand_flag_mood"),
+					},
+					&cli.StringFlag{
+						Name:  "typography",
+						Usage: i18n.T("brand_flag_typography"),
+					},
+					&cli.StringFlag{
+						Name:  "logo",
+						Usage: i18n.T("brand_flag_logo"),
+					},
+					&cli.FloatFlag{
+						Name:  "tolerance",
+						Usage: i18n.T("brand_flag_tolerance"),
+					},
+				},
+				Action: brandSetAction,
+			},
+			{
+				Name:   "clear",
+				Usage:  i18n.T("brand_clear_usage"),
+				Action: brandClearAction,
+			},
+			{
+				Name:      "check",
+				Usage:     i18n.T("brand_check_usage"),
+				ArgsUsage: "<image>...",
+				Action:    brandCheckAction,
+			},
+		},
+		Action: brandShowAction,
+	}
+}
+
+func brandShowAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	if brand.IsEmpty(cfg.Brand) {
+		utils.PrintInfo(i18n.T("brand_not_configured"))
+		utils.PrintDim(i18n.T("brand_set_hint"))
+		return nil
+	}
+	kit := cfg.Brand
+
+	utils.PrintSubHeader(i18n.T("brand_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	if len(kit.Colors) > 0 {
+		utils.PrintKeyValue(i18n.T("brand_colors"), "")
+		for _, c := range kit.Colors {
+			fmt.Fprintf(utils.Report(), "   %s %s %s\n", colorSwatch(c.Hex), utils.Cyan(c.Hex), c.Name)
+		}
+	}
+	if len(kit.ForbiddenColors) > 0 {
+		utils.PrintKeyValue(i18n.T("brand_forbidden"), "")
+		for _, hex := range kit.ForbiddenColors {
+			fmt.Fprintf(utils.Report(), "   %s %s\n", colorSwatch(hex), utils.Red(hex))
+		}
+	}
+	if len(kit.Mood) > 0 {
+		utils.PrintKeyValue(i18n.T("brand_mood"), utils.Cyan(strings.Join(kit.Mood, ", ")))
+	}
+	if kit.Typography != "" {
+		utils.PrintKeyValue(i18n.T("brand_typography"), utils.Cyan(kit.Typography))
+	}
+	if kit.ReferenceLogo != "" {
+		logo := utils.Blue(kit.ReferenceLogo)
+		if !utils.FileExists(brand.LogoPath(kit)) {
+			logo = utils.Red(kit.ReferenceLogo + " (" + i18n.T("brand_logo_missing") + ")")
+		}
+		utils.PrintKeyValue(i18n.T("brand_logo"), logo)
+	}
+
+	tolerance := kit.Tolerance
+	if tolerance == 0 {
+		tolerance = brand.DefaultTolerance
+	}
+	utils.PrintKeyValue(i18n.T("brand_tolerance"), utils.Cyan(fmt.Sprintf("%g", tolerance)))
+	return nil
+}
+
+// brandSetAction updates the brand kit from the flags. "NAME=#HEX" adds or
+// replaces a palette color and "NAME=" removes it; the other list flags
+// replace the whole list, and an empty value clears a setting.
+func brandSetAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	err := configService.UpdateBrandKit(func(kit *types.BrandKit) error {
+		for _, value := range cmd.StringSlice("color") {
+			name, hex, found := strings.Cut(value, "=")
+			name = strings.TrimSpace(name)
+			hex = strings.TrimSpace(hex)
+			if !found || name == "" {
+				return fmt.Errorf("invalid color %q, expected NAME=#RRGGBB", value)
+			}
+			kit.Colors = setBrandColor(kit.Colors, name, hex)
+		}
+		if cmd.IsSet("forbid") {
+			kit.ForbiddenColors = nonEmpty(cmd.StringSlice("forbid"))
+		}
+		if cmd.IsSet("mood") {
+			kit.Mood = nonEmpty(cmd.StringSlice("mood"))
+		}
+		if cmd.IsSet("typography") {
+			kit.Typography = strings.TrimSpace(cmd.String("typography"))
+		}
+		if cmd.IsSet("logo") {
+			logo := cmd.String("logo")
+			if logo != "" && !strings.HasPrefix(logo, "~") {
+				absolute, err := filepath.Abs(logo)
+				if err != nil {
+					return err
+				}
+				logo = absolute
+			}
+			kit.ReferenceLogo = logo
+		}
+		if cmd.IsSet("tolerance") {
+			kit.Tolerance = cmd.Float("tolerance")
+		}
+		return brand.Validate(kit)
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("brand_invalid", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("brand_saved"))
+	return nil
+}
+
+func brandClearAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	err := configService.UpdateBrandKit(func(kit *types.BrandKit) error {
+		*kit = types.BrandKit{}
+		return nil
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.T("brand_cleared"))
+	return nil
+}
+
+func brandCheckAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("brand_check_missing_image"))
+		return nil
+	}
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	drift := false
+	for _, path := range cmd.Args().Slice() {
+		report, err := brand.Check(cfg.Brand, path)
+		if err != nil {
+			utils.PrintError(fmt.Sprintf("%s: %v", path, err))
+			drift = true
+			continue
+		}
+
+		utils.PrintSubHeader(path)
+		for _, dominant := range report.Dominant {
+			hex := utils.HexColor(dominant.Color)
+			fmt.Fprintf(utils.Report(), "   %s %s %s\n", colorSwatch(hex), utils.Cyan(hex), utils.Gray(fmt.Sprintf("%.0f%%", dominant.Share*100)))
+		}
+		if len(report.Issues) == 0 {
+			utils.PrintSuccess(i18n.T("brand_check_ok"))
+			continue
+		}
+		drift = true
+		for _, issue := range report.Issues {
+			utils.PrintWarning(issue.Message())
+		}
+	}
+
+	if drift {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// setBrandColor adds, replaces or, for an empty hex, removes a palette color
+// while keeping the palette order
+func setBrandColor(colors []types.BrandColor, name, hex string) []types.BrandColor {
+	for i, c := range colors {
+		if c.Name != name {
+			continue
+		}
+		if hex == "" {
+			return append(colors[:i], colors[i+1:]...)
+		}
+		colors[i].Hex = hex
+		return colors
+	}
+	if hex == "" {
+		return colors
+	}
+	return append(colors, types.BrandColor{Name: name, Hex: hex})
+}
+
+// nonEmpty trims values and drops empty ones
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// colorSwatch renders a small block in the given color
+func colorSwatch(hex string) string {
+	c, err := utils.ParseHexColor(hex)
+	if err != nil {
+		return "  "
+	}
+	return pterm.NewRGB(c.R, c.G, c.B, true).Sprint("  ")
+}
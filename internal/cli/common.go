@@ -0,0 +1,15 @@
+package cli
+
+import (
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+)
+
+// applyConfiguredLanguage switches the localizer to the language saved in config
+func applyConfiguredLanguage(configService *config.Service) {
+	language, err := configService.GetLanguage()
+	if err != nil || language == "" {
+		return
+	}
+	i18n.SwitchLanguage(language)
+}
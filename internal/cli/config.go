@@ -18,7 +18,7 @@ func NewConfigCommand() *cli.Command {
 		Name:        i18n.T("config_name"),
 		Usage:       i18n.T("config_usage"),
 		Description: i18n.T("config_description"),
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "api-key",
 				Usage:   i18n.T("config_flag_api_key"),
@@ -44,6 +44,10 @@ func NewConfigCommand() *cli.Command {
 				Usage:   i18n.T("config_flag_show"),
 				Aliases: []string{"s"},
 			},
+		}, append(append(append(append(azureConfigFlags(), httpConfigFlags()...), enhanceConfigFlags()...), outputConfigFlags()...), logConfigFlags()...)...),
+		Commands: []*cli.Command{
+			newConfigExportCommand(),
+			newConfigImportCommand(),
 		},
 		Action: configAction,
 	}
@@ -80,13 +84,55 @@ func configAction(ctx context.Context, cmd *cli.Command) error {
 		}
 	}
 
+	// Handle provider setting
+	if provider := cmd.String("provider"); provider != "" {
+		if err := setProvider(configService, provider); err != nil {
+			return err
+		}
+	}
+
+	// Handle Azure OpenAI settings
+	if azureFlagsSet(cmd) {
+		if err := setAzureSettings(configService, cmd); err != nil {
+			return err
+		}
+	}
+
+	// Handle HTTP client settings
+	if httpFlagsSet(cmd) {
+		if err := setHTTPSettings(configService, cmd); err != nil {
+			return err
+		}
+	}
+
+	// Handle prompt enhancement settings
+	if enhanceFlagsSet(cmd) {
+		if err := setEnhanceSettings(configService, cmd); err != nil {
+			return err
+		}
+	}
+
+	// Handle output naming settings
+	if outputFlagsSet(cmd) {
+		if err := setOutputSettings(configService, cmd); err != nil {
+			return err
+		}
+	}
+
+	// Handle request log settings
+	if logFlagsSet(cmd) {
+		if err := setLogSettings(configService, cmd); err != nil {
+			return err
+		}
+	}
+
 	// Handle show configuration
 	if cmd.Bool("show") {
 		return showConfig(configService)
 	}
 
 	// If no flags provided, show configuration by default
-	if !cmd.Bool("show") && cmd.String("api-key") == "" && cmd.String("output-path") == "" && cmd.String("language") == "" {
+	if !cmd.Bool("show") && cmd.String("api-key") == "" && cmd.String("base-url") == "" && cmd.String("output-path") == "" && cmd.String("language") == "" && cmd.String("provider") == "" && !azureFlagsSet(cmd) && !httpFlagsSet(cmd) && !enhanceFlagsSet(cmd) && !outputFlagsSet(cmd) && !logFlagsSet(cmd) {
 		return showConfig(configService)
 	}
 
@@ -129,7 +175,7 @@ func setAPIKey(configService *config.Service, apiKey string) error {
 		"config_api_key_success")
 	
 	if err == nil {
-		fmt.Println()
+		fmt.Fprintln(utils.Diagnostics())
 		utils.PrintDim(i18n.T("common_built_with"))
 	}
 	
@@ -138,7 +184,7 @@ func setAPIKey(configService *config.Service, apiKey string) error {
 
 func setBaseURL(configService *config.Service, baseURL string) error {
 	return setConfigValue(configService, "base_url", baseURL, 
-		nil, 
+		config.ValidateBaseURL, 
 		func(url string) error {
 			return configService.SetConfigField("base_url", url)
 		}, 
@@ -185,24 +231,35 @@ func showConfig(configService *config.Service) error {
 	}
 
 	utils.PrintSubHeader(i18n.T("config_current_title"))
-	fmt.Println()
+	fmt.Fprintln(utils.Diagnostics())
 
-	// Show API key status
-	if config.OpenAIAPIKey != "" {
-		maskedKey := utils.MaskAPIKey(config.OpenAIAPIKey)
-		utils.PrintKeyValue(i18n.T("config_api_key"), utils.Green(maskedKey))
-	} else {
-		utils.PrintKeyValue(i18n.T("config_api_key"), utils.Red(i18n.T("config_not_configured")))
-		fmt.Printf("   %s\n", utils.Gray(i18n.T("config_get_api_key")))
-		fmt.Printf("   %s\n", utils.Gray(i18n.T("config_set_api_key")))
+	// Show provider
+	provider := config.Provider
+	if provider == "" {
+		provider = types.ProviderOpenAI
 	}
+	utils.PrintKeyValue(i18n.T("config_provider"), utils.Cyan(provider))
 
-	// Show base URL
-	baseURL := config.BaseURL
-	if baseURL == "" {
-		baseURL = types.DefaultValues.BaseURL
+	if provider == types.ProviderAzure {
+		showAzureConfig(config.Azure)
+	} else {
+		// Show API key status
+		if config.OpenAIAPIKey != "" {
+			maskedKey := utils.MaskAPIKey(config.OpenAIAPIKey)
+			utils.PrintKeyValue(i18n.T("config_api_key"), utils.Green(maskedKey))
+		} else {
+			utils.PrintKeyValue(i18n.T("config_api_key"), utils.Red(i18n.T("config_not_configured")))
+			fmt.Fprintf(utils.Report(), "   %s\n", utils.Gray(i18n.T("config_get_api_key")))
+			fmt.Fprintf(utils.Report(), "   %s\n", utils.Gray(i18n.T("config_set_api_key")))
+		}
+
+		// Show base URL
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = types.DefaultValues.BaseURL
+		}
+		utils.PrintKeyValue(i18n.T("config_base_url"), utils.Blue(baseURL))
 	}
-	utils.PrintKeyValue(i18n.T("config_base_url"), utils.Blue(baseURL))
 
 	// Show default output path
 	outputPath, err := configService.GetDefaultOutputPath()
@@ -212,6 +269,9 @@ func showConfig(configService *config.Service) error {
 		utils.PrintKeyValue(i18n.T("config_default_output"), utils.Blue(outputPath))
 	}
 
+	// Show output naming settings
+	showOutputConfig(config.Output)
+
 	// Show language
 	language, err := configService.GetLanguage()
 	if err != nil {
@@ -220,10 +280,19 @@ func showConfig(configService *config.Service) error {
 		utils.PrintKeyValue(i18n.T("config_language"), utils.Cyan(language))
 	}
 
+	// Show prompt enhancement settings
+	showEnhanceConfig(config.Enhance)
+
+	// Show HTTP client settings
+	showHTTPConfig(config.HTTP)
+
+	// Show request log settings
+	showLogConfig(config.Log, LogPath())
+
 	// Show config file location
 	utils.PrintKeyValue(i18n.T("config_file"), utils.Gray(configService.GetConfigPath()))
 
-	fmt.Println()
+	fmt.Fprintln(utils.Diagnostics())
 	utils.PrintDim(i18n.T("common_built_with"))
 
 	return nil
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// azureFlagNames lists the config flags that change Azure OpenAI settings
+var azureFlagNames = []string{"azure-endpoint", "azure-api-version", "azure-auth", "azure-api-key", "azure-token-command", "azure-deployment"}
+
+// azureConfigFlags returns the config flags for the provider and Azure settings
+func azureConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "provider",
+			Usage: i18n.T("config_flag_provider"),
+		},
+		&cli.StringFlag{
+			Name:  "azure-endpoint",
+			Usage: i18n.T("config_flag_azure_endpoint"),
+		},
+		&cli.StringFlag{
+			Name:  "azure-api-version",
+			Usage: i18n.T("config_flag_azure_api_version"),
+		},
+		&cli.StringFlag{
+			Name:  "azure-auth",
+			Usage: i18n.T("config_flag_azure_auth"),
+		},
+		&cli.StringFlag{
+			Name:  "azure-api-key",
+			Usage: i18n.T("config_flag_azure_api_key"),
+		},
+		&cli.StringFlag{
+			Name:  "azure-token-command",
+			Usage: i18n.T("config_flag_azure_token_command"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "azure-deployment",
+			Usage: i18n.T("config_flag_azure_deployment"),
+		},
+	}
+}
+
+// azureFlagsSet reports whether any Azure setting flag was given
+func azureFlagsSet(cmd *cli.Command) bool {
+	for _, name := range azureFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func setProvider(configService *config.Service, provider string) error {
+	return setConfigValue(configService, "provider", provider,
+		config.ValidateProvider,
+		func(p string) error {
+			return configService.SetConfigField("provider", p)
+		},
+		"config_provider_success")
+}
+
+// setAzureSettings saves the Azure flags given on the command line. Passing
+// an empty value clears a setting; "NAME=" removes a deployment.
+func setAzureSettings(configService *config.Service, cmd *cli.Command) error {
+	err := configService.UpdateAzureConfig(func(azure *types.AzureConfig) error {
+		if cmd.IsSet("azure-endpoint") {
+			endpoint := strings.TrimRight(cmd.String("azure-endpoint"), "/")
+			if endpoint != "" {
+				if err := config.ValidateBaseURL(endpoint); err != nil {
+					return err
+				}
+			}
+			azure.Endpoint = endpoint
+		}
+		if cmd.IsSet("azure-api-version") {
+			azure.APIVersion = cmd.String("azure-api-version")
+		}
+		if cmd.IsSet("azure-auth") {
+			auth := cmd.String("azure-auth")
+			if auth != "" && auth != types.AzureAuthKey && auth != types.AzureAuthAAD {
+				return fmt.Errorf("unsupported auth %q (use %s or %s)", auth, types.AzureAuthKey, types.AzureAuthAAD)
+			}
+			azure.Auth = auth
+		}
+		if cmd.IsSet("azure-api-key") {
+			azure.APIKey = cmd.String("azure-api-key")
+		}
+		if cmd.IsSet("azure-token-command") {
+			azure.TokenCommand = cmd.String("azure-token-command")
+		}
+
+		for _, deployment := range cmd.StringSlice("azure-deployment") {
+			name, model, found := strings.Cut(deployment, "=")
+			name = strings.TrimSpace(name)
+			model = strings.TrimSpace(model)
+			if !found || name == "" {
+				return fmt.Errorf("invalid deployment %q, expected NAME=MODEL", deployment)
+			}
+			if model == "" {
+				delete(azure.Deployments, name)
+				continue
+			}
+			if azure.Deployments == nil {
+				azure.Deployments = make(map[string]string)
+			}
+			azure.Deployments[name] = model
+		}
+		return nil
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_invalid_azure", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("config_azure_success"))
+	return nil
+}
+
+// showAzureConfig prints the Azure OpenAI settings
+func showAzureConfig(azure *types.AzureConfig) {
+	if azure == nil {
+		utils.PrintKeyValue(i18n.T("config_azure_endpoint"), utils.Red(i18n.T("config_not_configured")))
+		return
+	}
+
+	endpoint := utils.Blue(azure.Endpoint)
+	if azure.Endpoint == "" {
+		endpoint = utils.Red(i18n.T("config_not_configured"))
+	}
+	utils.PrintKeyValue(i18n.T("config_azure_endpoint"), endpoint)
+
+	apiVersion := azure.APIVersion
+	if apiVersion == "" {
+		apiVersion = types.DefaultAzureAPIVersion
+	}
+	utils.PrintKeyValue(i18n.T("config_azure_api_version"), utils.Cyan(apiVersion))
+
+	if azure.Auth == types.AzureAuthAAD {
+		utils.PrintKeyValue(i18n.T("config_azure_auth"), utils.Cyan(i18n.T("config_azure_auth_aad")))
+		if azure.TokenCommand != "" {
+			utils.PrintKeyValue(i18n.T("config_azure_token_command"), utils.Gray(azure.TokenCommand))
+		}
+	} else {
+		utils.PrintKeyValue(i18n.T("config_azure_auth"), utils.Cyan(i18n.T("config_azure_auth_key")))
+		if azure.APIKey != "" {
+			utils.PrintKeyValue(i18n.T("config_api_key"), utils.Green(utils.MaskAPIKey(azure.APIKey)))
+		} else {
+			utils.PrintKeyValue(i18n.T("config_api_key"), utils.Red(i18n.T("config_not_configured")))
+		}
+	}
+
+	if len(azure.Deployments) == 0 {
+		utils.PrintKeyValue(i18n.T("config_azure_deployments"), utils.Red(i18n.T("config_not_configured")))
+		return
+	}
+
+	names := make([]string, 0, len(azure.Deployments))
+	for name := range azure.Deployments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	utils.PrintKeyValue(i18n.T("config_azure_deployments"), "")
+	for _, name := range names {
+		fmt.Fprintf(utils.Report(), "   %s → %s\n", name, utils.Cyan(azure.Deployments[name]))
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// enhanceFlagNames lists the config flags that change prompt enhancement
+var enhanceFlagNames = []string{"enhance", "enhance-model", "enhance-system-prompt"}
+
+// enhanceConfigFlags returns the config flags for prompt enhancement
+func enhanceConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "enhance",
+			Usage: i18n.T("config_flag_enhance"),
+		},
+		&cli.StringFlag{
+			Name:  "enhance-model",
+			Usage: i18n.T("config_flag_enhance_model"),
+		},
+		&cli.StringFlag{
+			Name:  "enhance-system-prompt",
+			Usage: i18n.T("config_flag_enhance_system_prompt"),
+		},
+	}
+}
+
+// enhanceFlagsSet reports whether any prompt enhancement flag was given
+func enhanceFlagsSet(cmd *cli.Command) bool {
+	for _, name := range enhanceFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnhanceSettings saves the prompt enhancement flags. Passing an empty
+// model or system prompt restores the default.
+func setEnhanceSettings(configService *config.Service, cmd *cli.Command) error {
+	err := configService.UpdateEnhanceConfig(func(enhance *types.EnhanceConfig) error {
+		if cmd.IsSet("enhance") {
+			enhance.Enabled = cmd.Bool("enhance")
+		}
+		if cmd.IsSet("enhance-model") {
+			enhance.Model = cmd.String("enhance-model")
+		}
+		if cmd.IsSet("enhance-system-prompt") {
+			enhance.SystemPrompt = cmd.String("enhance-system-prompt")
+		}
+		return nil
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("config_enhance_success"))
+	return nil
+}
+
+// showEnhanceConfig prints the prompt enhancement settings
+func showEnhanceConfig(enhance *types.EnhanceConfig) {
+	if enhance == nil || !enhance.Enabled {
+		utils.PrintKeyValue(i18n.T("config_enhance"), utils.Gray(i18n.T("config_disabled")))
+		return
+	}
+
+	model := enhance.Model
+	if model == "" {
+		model = types.DefaultEnhanceModel
+	}
+	utils.PrintKeyValue(i18n.T("config_enhance"), utils.Green(i18n.Tf("config_enhance_enabled", model)))
+	if enhance.SystemPrompt != "" {
+		utils.PrintKeyValue(i18n.T("config_enhance_system_prompt"), utils.Gray(enhance.SystemPrompt))
+	}
+}
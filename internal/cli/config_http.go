@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/httpclient"
+	"just-icon/internal/i18n"
+	"just-icon/internal/openai"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// httpFlagNames lists the config flags that change HTTP client settings
+var httpFlagNames = []string{"proxy", "ca-file", "client-cert", "client-key", "header", "user-agent"}
+
+// httpConfigFlags returns the config flags for proxy, TLS and header settings
+func httpConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "proxy",
+			Usage: i18n.T("config_flag_proxy"),
+		},
+		&cli.StringFlag{
+			Name:  "ca-file",
+			Usage: i18n.T("config_flag_ca_file"),
+		},
+		&cli.StringFlag{
+			Name:  "client-cert",
+			Usage: i18n.T("config_flag_client_cert"),
+		},
+		&cli.StringFlag{
+			Name:  "client-key",
+			Usage: i18n.T("config_flag_client_key"),
+		},
+		&cli.StringSliceFlag{
+			Name:  "header",
+			Usage: i18n.T("config_flag_header"),
+		},
+		&cli.StringFlag{
+			Name:  "user-agent",
+			Usage: i18n.T("config_flag_user_agent"),
+		},
+	}
+}
+
+// httpFlagsSet reports whether any HTTP setting flag was given
+func httpFlagsSet(cmd *cli.Command) bool {
+	for _, name := range httpFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setHTTPSettings saves the HTTP flags given on the command line. Passing an
+// empty value clears a setting; a header without a value removes it.
+func setHTTPSettings(configService *config.Service, cmd *cli.Command) error {
+	err := configService.UpdateHTTPConfig(func(httpConfig *types.HTTPConfig) error {
+		if cmd.IsSet("proxy") {
+			httpConfig.ProxyURL = cmd.String("proxy")
+		}
+		if cmd.IsSet("ca-file") {
+			httpConfig.CAFile = cmd.String("ca-file")
+		}
+		if cmd.IsSet("client-cert") {
+			httpConfig.ClientCertFile = cmd.String("client-cert")
+		}
+		if cmd.IsSet("client-key") {
+			httpConfig.ClientKeyFile = cmd.String("client-key")
+		}
+		if cmd.IsSet("user-agent") {
+			httpConfig.UserAgent = cmd.String("user-agent")
+		}
+
+		for _, header := range cmd.StringSlice("header") {
+			name, value, err := httpclient.ParseHeader(header)
+			if err != nil {
+				return err
+			}
+			if value == "" {
+				delete(httpConfig.Headers, name)
+				continue
+			}
+			if httpConfig.Headers == nil {
+				httpConfig.Headers = make(map[string]string)
+			}
+			httpConfig.Headers[name] = value
+		}
+
+		// Reject settings the client could not be built with
+		_, err := httpclient.New(httpConfig, "")
+		return err
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_invalid_http", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("config_http_success"))
+	return nil
+}
+
+// showHTTPConfig prints the HTTP client settings, if any are configured
+func showHTTPConfig(httpConfig *types.HTTPConfig) {
+	if httpConfig == nil {
+		return
+	}
+
+	if httpConfig.ProxyURL != "" {
+		proxy := httpConfig.ProxyURL
+		if parsed, err := url.Parse(proxy); err == nil {
+			// Keep a proxy password off the screen
+			proxy = parsed.Redacted()
+		}
+		utils.PrintKeyValue(i18n.T("config_proxy"), utils.Blue(proxy))
+	}
+	if httpConfig.CAFile != "" {
+		utils.PrintKeyValue(i18n.T("config_ca_file"), utils.Blue(httpConfig.CAFile))
+	}
+	if httpConfig.ClientCertFile != "" {
+		utils.PrintKeyValue(i18n.T("config_client_cert"),
+			utils.Blue(fmt.Sprintf("%s, %s", httpConfig.ClientCertFile, httpConfig.ClientKeyFile)))
+	}
+	if httpConfig.UserAgent != "" {
+		utils.PrintKeyValue(i18n.T("config_user_agent"), utils.Cyan(httpConfig.UserAgent))
+	}
+	if len(httpConfig.Headers) > 0 {
+		names := make([]string, 0, len(httpConfig.Headers))
+		for name := range httpConfig.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		utils.PrintKeyValue(i18n.T("config_headers"), "")
+		for _, name := range names {
+			fmt.Fprintf(utils.Report(), "   %s: %s\n", name, utils.Cyan(httpConfig.Headers[name]))
+		}
+	}
+}
+
+// SetupHTTP routes file downloads through the configured proxy, CA bundle
+// and headers. Invalid settings keep the default client; the commands that
+// call the API report them.
+func SetupHTTP() {
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil {
+		return
+	}
+	client, err := httpclient.New(cfg.HTTP, openai.APIURL(cfg))
+	if err != nil {
+		return
+	}
+	utils.SetHTTPClient(client)
+}
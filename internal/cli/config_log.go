@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/logging"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// logFlagNames lists the config flags that change the request log
+var logFlagNames = []string{"log", "log-level", "log-max-size", "log-max-files", "log-hash-prompts", "log-retention-days"}
+
+// logConfigFlags returns the config flags for the request log
+func logConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "log",
+			Usage: i18n.T("config_flag_log"),
+		},
+		&cli.StringFlag{
+			Name:  "log-level",
+			Usage: i18n.Tf("config_flag_log_level", strings.Join(logging.Levels, ", ")),
+		},
+		&cli.IntFlag{
+			Name:  "log-max-size",
+			Usage: i18n.Tf("config_flag_log_max_size", types.DefaultLogMaxSizeMB),
+		},
+		&cli.IntFlag{
+			Name:  "log-max-files",
+			Usage: i18n.Tf("config_flag_log_max_files", types.DefaultLogMaxFiles),
+		},
+		&cli.BoolFlag{
+			Name:  "log-hash-prompts",
+			Usage: i18n.T("config_flag_log_hash_prompts"),
+		},
+		&cli.IntFlag{
+			Name:  "log-retention-days",
+			Usage: i18n.T("config_flag_log_retention_days"),
+		},
+	}
+}
+
+// logFlagsSet reports whether any request log flag was given
+func logFlagsSet(cmd *cli.Command) bool {
+	for _, name := range logFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setLogSettings saves the request log flags. Passing an empty level or a
+// zero size or count restores the default; zero retention days keeps records
+// until the log rotates.
+func setLogSettings(configService *config.Service, cmd *cli.Command) error {
+	level := strings.ToLower(strings.TrimSpace(cmd.String("log-level")))
+	if _, err := logging.ParseLevel(level); err != nil {
+		utils.PrintError(err.Error())
+		return nil // Don't return error to avoid showing usage
+	}
+	if cmd.Int("log-max-size") < 0 || cmd.Int("log-max-files") < 0 || cmd.Int("log-retention-days") < 0 {
+		utils.PrintError(i18n.T("config_log_negative"))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	err := configService.UpdateLogConfig(func(log *types.LogConfig) error {
+		if cmd.IsSet("log") {
+			log.Disabled = !cmd.Bool("log")
+		}
+		if cmd.IsSet("log-level") {
+			log.Level = level
+		}
+		if cmd.IsSet("log-max-size") {
+			log.MaxSizeMB = int(cmd.Int("log-max-size"))
+		}
+		if cmd.IsSet("log-max-files") {
+			log.MaxFiles = int(cmd.Int("log-max-files"))
+		}
+		if cmd.IsSet("log-hash-prompts") {
+			log.HashPrompts = cmd.Bool("log-hash-prompts")
+		}
+		if cmd.IsSet("log-retention-days") {
+			log.RetentionDays = int(cmd.Int("log-retention-days"))
+		}
+		return nil
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("config_log_success"))
+	return nil
+}
+
+// showLogConfig prints the request log settings
+func showLogConfig(log *types.LogConfig, path string) {
+	if log != nil && log.Disabled {
+		utils.PrintKeyValue(i18n.T("config_log"), utils.Gray(i18n.T("config_disabled")))
+		return
+	}
+
+	level, maxSize, maxFiles := "info", types.DefaultLogMaxSizeMB, types.DefaultLogMaxFiles
+	if log != nil {
+		if log.Level != "" {
+			level = log.Level
+		}
+		if log.MaxSizeMB > 0 {
+			maxSize = log.MaxSizeMB
+		}
+		if log.MaxFiles > 0 {
+			maxFiles = log.MaxFiles
+		}
+	}
+	utils.PrintKeyValue(i18n.T("config_log"), utils.Green(i18n.Tf("config_log_enabled", level, maxSize, maxFiles)))
+	prompts, retention := i18n.T("config_log_prompts_full"), i18n.T("config_log_retention_unlimited")
+	if log != nil && log.HashPrompts {
+		prompts = i18n.T("config_log_prompts_hashed")
+	}
+	if log != nil && log.RetentionDays > 0 {
+		retention = i18n.Tf("config_log_retention_days", log.RetentionDays)
+	}
+	fmt.Fprintf(utils.Report(), "   %s\n", utils.Gray(path))
+	utils.PrintKeyValue(i18n.T("config_log_privacy"), utils.Green(prompts+", "+retention))
+}
+
+// LogPath returns the request log in the default config service's state
+// directory
+func LogPath() string {
+	return filepath.Join(config.DefaultService.StateDir(), logging.FileName)
+}
+
+// SetupLogging starts the request log with the configured settings. Verbose
+// echoes info records to stderr and debug also debug records. NoLog keeps
+// this run out of the log file; old records are still pruned.
+func SetupLogging(verbose, debug, noLog bool) {
+	opts := logging.Options{Path: LogPath()}
+	if cfg, err := config.DefaultService.GetConfig(); err == nil {
+		opts.Config = cfg.Log
+		opts.Secrets = logging.Secrets(cfg)
+	}
+	if noLog {
+		log := types.LogConfig{}
+		if opts.Config != nil {
+			log = *opts.Config
+		}
+		log.Disabled = true
+		opts.Config = &log
+	}
+	switch {
+	case debug:
+		opts.Console, opts.ConsoleLevel = os.Stderr, slog.LevelDebug
+	case verbose:
+		opts.Console, opts.ConsoleLevel = os.Stderr, slog.LevelInfo
+	}
+	if err := logging.Setup(opts); err != nil {
+		utils.PrintWarning(i18n.Tf("log_setup_failed", err.Error()))
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/filenames"
+	"just-icon/internal/i18n"
+	"just-icon/internal/preview"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// outputFlagNames lists the config flags that change output naming
+var outputFlagNames = []string{"filename-template", "prompt-subdirs", "contact-sheet", "preview"}
+
+// outputConfigFlags returns the config flags for output naming
+func outputConfigFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "filename-template",
+			Usage: i18n.Tf("config_flag_filename_template", placeholderNames()),
+		},
+		&cli.BoolFlag{
+			Name:  "prompt-subdirs",
+			Usage: i18n.T("config_flag_prompt_subdirs"),
+		},
+		&cli.BoolFlag{
+			Name:  "contact-sheet",
+			Usage: i18n.T("config_flag_contact_sheet"),
+		},
+		&cli.StringFlag{
+			Name:  "preview",
+			Usage: i18n.Tf("config_flag_preview", previewNames()),
+		},
+	}
+}
+
+// outputFlagsSet reports whether any output naming flag was given
+func outputFlagsSet(cmd *cli.Command) bool {
+	for _, name := range outputFlagNames {
+		if cmd.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOutputSettings saves the output naming flags. Passing an empty filename
+// template restores the default.
+func setOutputSettings(configService *config.Service, cmd *cli.Command) error {
+	template := strings.TrimSpace(cmd.String("filename-template"))
+	if cmd.IsSet("filename-template") && template != "" {
+		if err := filenames.Validate(template); err != nil {
+			utils.PrintError(i18n.Tf("config_filename_template_invalid", err.Error()))
+			return nil // Don't return error to avoid showing usage
+		}
+	}
+
+	previewSetting := strings.ToLower(strings.TrimSpace(cmd.String("preview")))
+	if cmd.IsSet("preview") && previewSetting != "" && !preview.IsValid(previewSetting) {
+		utils.PrintError(i18n.Tf("config_preview_invalid", previewSetting, previewNames()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	err := configService.UpdateOutputConfig(func(output *types.OutputConfig) error {
+		if cmd.IsSet("filename-template") {
+			output.FilenameTemplate = template
+		}
+		if cmd.IsSet("prompt-subdirs") {
+			output.PromptSubdirs = cmd.Bool("prompt-subdirs")
+		}
+		if cmd.IsSet("contact-sheet") {
+			output.ContactSheet = cmd.Bool("contact-sheet")
+		}
+		if cmd.IsSet("preview") {
+			output.Preview = previewSetting
+		}
+		return nil
+	})
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	utils.PrintSuccess(i18n.T("config_output_success"))
+	return nil
+}
+
+// showOutputConfig prints the output naming settings
+func showOutputConfig(output *types.OutputConfig) {
+	template := filenames.DefaultTemplate
+	subdirs, contactSheet := false, false
+	previewSetting := string(preview.Auto)
+	if output != nil {
+		if output.FilenameTemplate != "" {
+			template = output.FilenameTemplate
+		}
+		subdirs = output.PromptSubdirs
+		contactSheet = output.ContactSheet
+		if output.Preview != "" {
+			previewSetting = output.Preview
+		}
+	}
+
+	utils.PrintKeyValue(i18n.T("config_filename_template"), utils.Cyan(template))
+	if subdirs {
+		utils.PrintKeyValue(i18n.T("config_prompt_subdirs"), utils.Green(i18n.T("config_enabled")))
+	}
+	if contactSheet {
+		utils.PrintKeyValue(i18n.T("config_contact_sheet"), utils.Green(i18n.T("config_enabled")))
+	}
+	utils.PrintKeyValue(i18n.T("config_preview"), utils.Cyan(previewSetting))
+}
+
+// previewNames lists the preview settings for help text
+func previewNames() string {
+	names := make([]string, len(preview.Protocols))
+	for i, protocol := range preview.Protocols {
+		names[i] = string(protocol)
+	}
+	return strings.Join(names, ", ")
+}
+
+// placeholderNames lists the filename template placeholders for help text
+func placeholderNames() string {
+	names := make([]string, len(filenames.Placeholders))
+	for i, name := range filenames.Placeholders {
+		names[i] = "{" + name + "}"
+	}
+	return strings.Join(names, " ")
+}
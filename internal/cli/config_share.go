@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/pkg/utils"
+)
+
+const defaultSharedConfigFile = "just-icon.shared.json"
+
+// newConfigExportCommand creates the config export subcommand
+func newConfigExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "export",
+		Usage:       i18n.T("config_export_usage"),
+		Description: i18n.T("config_export_description"),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   i18n.T("config_export_flag_output"),
+				Aliases: []string{"o"},
+				Value:   defaultSharedConfigFile,
+			},
+		},
+		Action: configExportAction,
+	}
+}
+
+// newConfigImportCommand creates the config import subcommand
+func newConfigImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "import",
+		Usage:       i18n.T("config_import_usage"),
+		Description: i18n.T("config_import_description"),
+		ArgsUsage:   "<file>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "overwrite",
+				Usage: i18n.T("config_import_flag_overwrite"),
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: i18n.T("config_import_flag_dry_run"),
+			},
+			&cli.BoolFlag{
+				Name:  "trust",
+				Usage: i18n.T("config_import_flag_trust"),
+			},
+		},
+		Action: configImportAction,
+	}
+}
+
+func configExportAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	shared, omitted, err := configService.ExportConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	data, err := json.MarshalIndent(shared, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal shared config: %w", err)
+	}
+
+	output := cmd.String("output")
+	if output == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(output, append(data, '\n'), 0644); err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.Tf("config_export_success", output))
+	for key, value := range omitted {
+		utils.PrintDim(i18n.Tf("config_export_secret_omitted", key, utils.MaskAPIKey(value)))
+	}
+
+	return nil
+}
+
+func configImportAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("config_import_missing_file"))
+		return nil
+	}
+
+	shared, err := config.ReadSharedConfig(cmd.Args().First())
+	if err != nil {
+		utils.PrintError(err.Error())
+		return err
+	}
+
+	dryRun := cmd.Bool("dry-run")
+	changes, err := configService.ImportConfig(shared, cmd.Bool("overwrite"), cmd.Bool("trust"), dryRun)
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	printImportChanges(changes)
+
+	fmt.Fprintln(utils.Diagnostics())
+	if dryRun {
+		utils.PrintInfo(i18n.T("config_import_dry_run"))
+	} else {
+		utils.PrintSuccess(i18n.T("config_import_success"))
+	}
+	return nil
+}
+
+// printImportChanges reports one line per imported setting and hints for
+// conflicts and untrusted settings
+func printImportChanges(changes []config.ImportChange) {
+	out := utils.Report()
+	conflicts, untrusted := 0, 0
+	for _, change := range changes {
+		switch change.Status {
+		case config.ImportAdded:
+			fmt.Fprintf(out, "  %s %s = %s\n", utils.Green("+"), change.Key, utils.Gray(change.Incoming))
+		case config.ImportOverwritten:
+			fmt.Fprintf(out, "  %s %s: %s → %s\n", utils.Yellow("~"), change.Key, utils.Gray(change.Local), change.Incoming)
+		case config.ImportConflict:
+			conflicts++
+			fmt.Fprintf(out, "  %s %s: %s\n", utils.Red("!"), change.Key,
+				i18n.Tf("config_import_conflict", change.Local, change.Incoming))
+		case config.ImportUntrusted:
+			untrusted++
+			message := i18n.Tf("config_import_untrusted", change.Key, change.Local, change.Incoming)
+			if change.Local == "" {
+				message = i18n.Tf("config_import_untrusted_new", change.Key, change.Incoming)
+			}
+			fmt.Fprintf(out, "  %s %s\n", utils.Yellow("?"), message)
+		case config.ImportSkipped:
+			fmt.Fprintf(out, "  %s %s\n", utils.Gray("-"), utils.Gray(i18n.Tf("config_import_skipped", change.Key)))
+		case config.ImportUnchanged:
+			fmt.Fprintf(out, "  %s %s\n", utils.Gray("="), utils.Gray(change.Key))
+		}
+	}
+
+	if conflicts > 0 {
+		fmt.Fprintln(out)
+		utils.PrintWarning(i18n.Tf("config_import_conflicts_hint", conflicts))
+	}
+	if untrusted > 0 {
+		fmt.Fprintln(out)
+		utils.PrintWarning(i18n.Tf("config_import_untrusted_hint", untrusted))
+	}
+}
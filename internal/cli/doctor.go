@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/doctor"
+	"just-icon/internal/i18n"
+	"just-icon/pkg/utils"
+)
+
+// NewDoctorCommand creates the doctor command
+func NewDoctorCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "doctor",
+		Usage:       i18n.T("doctor_usage"),
+		Description: i18n.T("doctor_description"),
+		Action:      doctorAction,
+	}
+}
+
+func doctorAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	utils.PrintSubHeader(i18n.T("doctor_title"))
+	fmt.Fprintln(utils.Diagnostics())
+
+	results := doctor.New(configService).Run(ctx)
+
+	var failed, warned int
+	for _, result := range results {
+		printDoctorResult(result)
+		switch result.Status {
+		case doctor.StatusFail:
+			failed++
+		case doctor.StatusWarn:
+			warned++
+		}
+	}
+
+	fmt.Fprintln(utils.Diagnostics())
+	switch {
+	case failed > 0:
+		utils.PrintError(i18n.Tf("doctor_summary_failed", failed, warned))
+		return cli.Exit("", 1)
+	case warned > 0:
+		utils.PrintWarning(i18n.Tf("doctor_summary_warnings", warned))
+	default:
+		utils.PrintSuccess(i18n.T("doctor_summary_ok"))
+	}
+
+	return nil
+}
+
+// printDoctorResult prints one check with its detail and remediation hint
+// to the report
+func printDoctorResult(result doctor.Result) {
+	out := utils.Report()
+	label := i18n.T("doctor_check_" + result.Name)
+
+	var icon string
+	switch result.Status {
+	case doctor.StatusPass:
+		icon = utils.Green("✔")
+	case doctor.StatusWarn:
+		icon = utils.Yellow("▲")
+	case doctor.StatusFail:
+		icon = utils.Red("✘")
+	default:
+		icon = utils.Gray("○")
+		label = utils.Gray(label + " " + i18n.T("doctor_skipped"))
+	}
+
+	if result.Detail != "" {
+		fmt.Fprintf(out, "%s %s %s\n", icon, label, utils.Gray("("+result.Detail+")"))
+	} else {
+		fmt.Fprintf(out, "%s %s\n", icon, label)
+	}
+
+	if result.Hint != "" {
+		if utils.Decorated() {
+			fmt.Fprintf(out, "   💡 %s\n", i18n.T(result.Hint))
+		} else {
+			fmt.Fprintf(out, "   %s\n", i18n.T(result.Hint))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/gallery"
+	"just-icon/internal/i18n"
+	"just-icon/internal/iconset"
+	"just-icon/pkg/utils"
+)
+
+// NewExportCommand creates the export command
+func NewExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "export",
+		Usage:       i18n.T("export_usage"),
+		Description: i18n.Tf("export_description", iconset.Names()),
+		ArgsUsage:   "<image|gallery-id>...",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:    "target",
+				Aliases: []string{"t"},
+				Usage:   i18n.Tf("export_flag_target", iconset.Names()),
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   i18n.T("export_flag_output"),
+			},
+		},
+		Action: exportAction,
+	}
+}
+
+func exportAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("export_missing_images"))
+		return nil
+	}
+	targets, err := iconset.ParseTargets(cmd.StringSlice("target"))
+	if err != nil {
+		utils.PrintError(err.Error())
+		return nil
+	}
+
+	// Arguments are image files or gallery references
+	var paths []string
+	for _, arg := range cmd.Args().Slice() {
+		if _, err := os.Stat(arg); err == nil {
+			paths = append(paths, arg)
+			continue
+		}
+		entries, ok := findGalleryEntries(gallery.DefaultStore(), []string{arg})
+		if !ok {
+			return cli.Exit("", 1)
+		}
+		paths = append(paths, entries[0].Path)
+	}
+
+	failed := false
+	for _, path := range paths {
+		dir := utils.ExpandHome(cmd.String("output"))
+		switch {
+		case dir == "":
+			dir = iconset.DefaultDir(path)
+		case len(paths) > 1:
+			dir = filepath.Join(dir, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		}
+
+		dirs, err := iconset.Export(path, dir, targets)
+		if err != nil {
+			failed = true
+			utils.PrintError(i18n.Tf("export_failed", path, err.Error()))
+			continue
+		}
+		utils.PrintSuccess(i18n.Tf("export_done", path, dir))
+		for _, targetDir := range dirs {
+			utils.PrintDim("   " + targetDir)
+		}
+	}
+
+	if failed {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
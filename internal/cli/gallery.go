@@ -0,0 +1,461 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/gallery"
+	"just-icon/internal/generator"
+	"just-icon/internal/i18n"
+	"just-icon/internal/preview"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// defaultGalleryLimit is the number of images gallery list shows
+	defaultGalleryLimit = 50
+	// galleryDateFormat is the layout of --since and --until
+	galleryDateFormat = "2006-01-02"
+)
+
+// NewGalleryCommand creates the gallery command
+func NewGalleryCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "gallery",
+		Usage:       i18n.T("gallery_usage"),
+		Description: i18n.T("gallery_description"),
+		Commands: []*cli.Command{
+			{
+				Name:      "list",
+				Aliases:   []string{"search"},
+				Usage:     i18n.T("gallery_list_usage"),
+				ArgsUsage: "[text...]",
+				Flags:     galleryListFlags(),
+				Action:    galleryListAction,
+			},
+			{
+				Name:      "favorite",
+				Aliases:   []string{"fav"},
+				Usage:     i18n.T("gallery_favorite_usage"),
+				ArgsUsage: "<id|path>...",
+				Action:    galleryFavoriteAction(true),
+			},
+			{
+				Name:      "unfavorite",
+				Aliases:   []string{"unfav"},
+				Usage:     i18n.T("gallery_unfavorite_usage"),
+				ArgsUsage: "<id|path>...",
+				Action:    galleryFavoriteAction(false),
+			},
+			{
+				Name:      "tag",
+				Usage:     i18n.T("gallery_tag_usage"),
+				ArgsUsage: "<id|path> <tag>...",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "remove",
+						Usage:   i18n.T("gallery_flag_remove_tags"),
+						Aliases: []string{"r"},
+					},
+				},
+				Action: galleryTagAction,
+			},
+			{
+				Name:      "delete",
+				Aliases:   []string{"rm"},
+				Usage:     i18n.T("gallery_delete_usage"),
+				ArgsUsage: "<id|path>...",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Usage:   i18n.T("gallery_flag_force"),
+						Aliases: []string{"f"},
+					},
+				},
+				Action: galleryDeleteAction,
+			},
+			{
+				Name:      "export",
+				Usage:     i18n.T("gallery_export_usage"),
+				ArgsUsage: "<dir> [id|path...]",
+				Flags:     galleryFilterFlags(),
+				Action:    galleryExportAction,
+			},
+			{
+				Name:      "scan",
+				Usage:     i18n.T("gallery_scan_usage"),
+				ArgsUsage: "[dir...]",
+				Action:    galleryScanAction,
+			},
+		},
+		Flags:  galleryListFlags(),
+		Action: galleryListAction,
+	}
+}
+
+// galleryFilterFlags returns the flags that narrow a gallery search
+func galleryFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: i18n.T("gallery_flag_since"),
+		},
+		&cli.StringFlag{
+			Name:  "until",
+			Usage: i18n.T("gallery_flag_until"),
+		},
+		&cli.StringFlag{
+			Name:    "preset",
+			Usage:   i18n.T("gallery_flag_preset"),
+			Aliases: []string{"p"},
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: i18n.T("gallery_flag_tag"),
+		},
+		&cli.BoolFlag{
+			Name:    "favorites",
+			Usage:   i18n.T("gallery_flag_favorites"),
+			Aliases: []string{"f"},
+		},
+	}
+}
+
+// galleryListFlags returns the filter flags plus the list output flags
+func galleryListFlags() []cli.Flag {
+	return append(galleryFilterFlags(),
+		&cli.IntFlag{
+			Name:    "limit",
+			Usage:   i18n.T("gallery_flag_limit"),
+			Aliases: []string{"n"},
+			Value:   defaultGalleryLimit,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: i18n.T("gallery_flag_json"),
+		},
+		&cli.BoolFlag{
+			Name:  "preview",
+			Usage: i18n.T("gallery_flag_preview"),
+		},
+	)
+}
+
+func galleryListAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	query, ok := galleryQuery(cmd, strings.Join(cmd.Args().Slice(), " "))
+	if !ok {
+		return nil
+	}
+
+	store := gallery.DefaultStore()
+	entries, err := store.Search(query, int(cmd.Int("limit")))
+	if err != nil {
+		utils.PrintError(i18n.Tf("gallery_failed_to_read", err.Error()))
+		return err
+	}
+
+	if cmd.Bool("json") {
+		if entries == nil {
+			entries = []gallery.Entry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if query.IsEmpty() {
+			utils.PrintInfo(i18n.T("gallery_empty"))
+		} else {
+			utils.PrintInfo(i18n.T("gallery_no_matches"))
+		}
+		return nil
+	}
+
+	utils.PrintSubHeader(i18n.T("gallery_list_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	protocol := preview.Off
+	if cmd.Bool("preview") {
+		protocol = generator.PreviewProtocol()
+	}
+	printGalleryEntries(entries, protocol)
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.Tf("gallery_list_count", len(entries)))
+	utils.PrintDim(i18n.T("gallery_list_hint"))
+	return nil
+}
+
+func galleryFavoriteAction(favorite bool) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		applyConfiguredLanguage(config.DefaultService)
+
+		store := gallery.DefaultStore()
+		entries, ok := findGalleryEntries(store, cmd.Args().Slice())
+		if !ok {
+			return nil
+		}
+
+		if err := store.SetFavorite(galleryIDs(entries), favorite); err != nil {
+			utils.PrintError(i18n.Tf("gallery_failed_to_update", err.Error()))
+			return err
+		}
+		if favorite {
+			utils.PrintSuccess(i18n.Tf("gallery_favorite_success", len(entries)))
+		} else {
+			utils.PrintSuccess(i18n.Tf("gallery_unfavorite_success", len(entries)))
+		}
+		return nil
+	}
+}
+
+func galleryTagAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	if cmd.Args().Len() < 2 {
+		utils.PrintError(i18n.T("gallery_tag_missing"))
+		return nil
+	}
+
+	store := gallery.DefaultStore()
+	entries, ok := findGalleryEntries(store, cmd.Args().Slice()[:1])
+	if !ok {
+		return nil
+	}
+
+	tags := cmd.Args().Slice()[1:]
+	var err error
+	if cmd.Bool("remove") {
+		err = store.Tag(galleryIDs(entries), nil, tags)
+	} else {
+		err = store.Tag(galleryIDs(entries), tags, nil)
+	}
+	if err != nil {
+		utils.PrintError(i18n.Tf("gallery_failed_to_update", err.Error()))
+		return err
+	}
+	utils.PrintSuccess(i18n.Tf("gallery_tag_success", entries[0].ID))
+	return nil
+}
+
+func galleryDeleteAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	store := gallery.DefaultStore()
+	entries, ok := findGalleryEntries(store, cmd.Args().Slice())
+	if !ok {
+		return nil
+	}
+
+	if !cmd.Bool("force") {
+		if !utils.CanPrompt() {
+			utils.PrintError(i18n.T("confirm_requires_terminal"))
+			return cli.Exit("", 1)
+		}
+		for _, entry := range entries {
+			fmt.Printf("  %s %s\n", utils.Cyan(entry.ID), entry.Path)
+		}
+		fmt.Printf("%s (y/N): ", i18n.Tf("gallery_delete_confirm", len(entries)))
+
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "Y" && response != "yes" && response != "Yes" {
+			fmt.Println(i18n.T("gallery_delete_cancelled"))
+			return nil
+		}
+	}
+
+	deleted, err := store.Delete(galleryIDs(entries))
+	if len(deleted) > 0 {
+		utils.PrintSuccess(i18n.Tf("gallery_delete_success", len(deleted)))
+	}
+	if err != nil {
+		utils.PrintError(i18n.Tf("gallery_delete_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+func galleryExportAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("gallery_export_missing_dir"))
+		return nil
+	}
+	dir := utils.ExpandHome(cmd.Args().First())
+	refs := cmd.Args().Slice()[1:]
+
+	query, ok := galleryQuery(cmd, "")
+	if !ok {
+		return nil
+	}
+
+	store := gallery.DefaultStore()
+	var entries []gallery.Entry
+	if len(refs) > 0 {
+		found, ok := findGalleryEntries(store, refs)
+		if !ok {
+			return nil
+		}
+		for _, entry := range found {
+			if query.Match(entry) {
+				entries = append(entries, entry)
+			}
+		}
+	} else {
+		var err error
+		entries, err = store.Search(query, 0)
+		if err != nil {
+			utils.PrintError(i18n.Tf("gallery_failed_to_read", err.Error()))
+			return err
+		}
+	}
+	if len(entries) == 0 {
+		utils.PrintInfo(i18n.T("gallery_no_matches"))
+		return nil
+	}
+
+	exported, err := gallery.Export(entries, dir)
+	if err != nil {
+		utils.PrintError(i18n.Tf("gallery_export_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("gallery_export_success", len(exported), dir))
+	return nil
+}
+
+func galleryScanAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	dirs := cmd.Args().Slice()
+	for i, dir := range dirs {
+		dirs[i] = utils.ExpandHome(dir)
+	}
+	if len(dirs) == 0 {
+		outputPath, err := configService.GetDefaultOutputPath()
+		if err != nil {
+			utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+			return err
+		}
+		dirs = []string{utils.ExpandHome(outputPath)}
+	}
+
+	added, removed, err := gallery.DefaultStore().Scan(dirs)
+	if err != nil {
+		utils.PrintError(i18n.Tf("gallery_scan_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("gallery_scan_success", added, removed))
+	return nil
+}
+
+// printGalleryEntries prints one line per image with its path and, unless
+// protocol is off, a small preview underneath
+func printGalleryEntries(entries []gallery.Entry, protocol preview.Protocol) {
+	for _, entry := range entries {
+		star := " "
+		if entry.Favorite {
+			star = utils.Yellow("★")
+		}
+		prompt := runewidth.Truncate(entry.Metadata.Prompt, historyPromptWidth, "…")
+		line := fmt.Sprintf("  %s %s %s %s", star, utils.Cyan(entry.ID),
+			utils.Gray(entry.Metadata.Timestamp.Local().Format(historyTimeFormat)), prompt)
+		if entry.Metadata.Preset != "" {
+			line += " " + utils.Blue("@"+entry.Metadata.Preset)
+		}
+		for _, tag := range entry.Tags {
+			line += " " + utils.Green("#"+tag)
+		}
+		fmt.Fprintln(utils.Report(), line)
+
+		if !utils.FileExists(entry.Path) {
+			fmt.Fprintf(utils.Report(), "    %s\n", utils.Gray(entry.Path+" "+i18n.T("gallery_file_missing")))
+			continue
+		}
+		fmt.Fprintf(utils.Report(), "    %s\n", utils.Gray(entry.Path))
+		generator.ShowPreview(entry.Path, protocol, preview.SmallWidth)
+	}
+}
+
+// galleryQuery builds a search query from the filter flags, printing an
+// error when a date is malformed
+func galleryQuery(cmd *cli.Command, text string) (gallery.Query, bool) {
+	query := gallery.Query{
+		Text:      text,
+		Preset:    strings.TrimSpace(cmd.String("preset")),
+		Tags:      cmd.StringSlice("tag"),
+		Favorites: cmd.Bool("favorites"),
+	}
+
+	for _, bound := range []struct {
+		flag   string
+		target *time.Time
+		days   int
+	}{
+		{"since", &query.Since, 0},
+		// --until includes the whole day
+		{"until", &query.Until, 1},
+	} {
+		value := strings.TrimSpace(cmd.String(bound.flag))
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation(galleryDateFormat, value, time.Local)
+		if err != nil {
+			utils.PrintError(i18n.Tf("gallery_invalid_date", value))
+			return query, false
+		}
+		*bound.target = date.AddDate(0, 0, bound.days)
+	}
+	return query, true
+}
+
+// findGalleryEntries resolves image references, printing an error when one
+// is missing or matches nothing
+func findGalleryEntries(store *gallery.Store, refs []string) ([]gallery.Entry, bool) {
+	if len(refs) == 0 {
+		utils.PrintError(i18n.T("gallery_missing_ref"))
+		return nil, false
+	}
+
+	var entries []gallery.Entry
+	for _, ref := range refs {
+		entry, err := store.Find(ref)
+		switch {
+		case errors.Is(err, gallery.ErrNotFound):
+			utils.PrintError(i18n.Tf("gallery_not_found", ref))
+			return nil, false
+		case errors.Is(err, gallery.ErrAmbiguous):
+			utils.PrintError(i18n.Tf("gallery_ambiguous", ref))
+			return nil, false
+		case err != nil:
+			utils.PrintError(i18n.Tf("gallery_failed_to_read", err.Error()))
+			return nil, false
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, true
+}
+
+// galleryIDs returns the IDs of the entries
+func galleryIDs(entries []gallery.Entry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/brand"
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/history"
+	"just-icon/internal/i18n"
+	"just-icon/internal/preview"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// defaultHistoryLimit is the number of entries history list shows
+	defaultHistoryLimit = 20
+	// historyPromptWidth truncates prompts in the history list
+	historyPromptWidth = 60
+	// historyTimeFormat formats entry timestamps
+	historyTimeFormat = "2006-01-02 15:04"
+)
+
+// NewHistoryCommand creates the history command
+func NewHistoryCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "history",
+		Usage:       i18n.T("history_usage"),
+		Description: i18n.T("history_description"),
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: i18n.T("history_list_usage"),
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:    "limit",
+						Usage:   i18n.T("history_flag_limit"),
+						Aliases: []string{"n"},
+						Value:   defaultHistoryLimit,
+					},
+				},
+				Action: historyListAction,
+			},
+			{
+				Name:      "show",
+				Usage:     i18n.T("history_show_usage"),
+				ArgsUsage: "<id|n|@n>",
+				Action:    historyShowAction,
+			},
+			{
+				Name:      "rerun",
+				Usage:     i18n.T("history_rerun_usage"),
+				ArgsUsage: "<id|n|@n>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   i18n.T("history_flag_output"),
+						Aliases: []string{"o"},
+					},
+				},
+				Action: historyRerunAction,
+			},
+		},
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "limit",
+				Usage:   i18n.T("history_flag_limit"),
+				Aliases: []string{"n"},
+				Value:   defaultHistoryLimit,
+			},
+		},
+		Action: historyListAction,
+	}
+}
+
+func historyListAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	entries, err := history.DefaultStore().Recent(int(cmd.Int("limit")))
+	if err != nil {
+		utils.PrintError(i18n.Tf("history_failed_to_read", err.Error()))
+		return err
+	}
+	if len(entries) == 0 {
+		utils.PrintInfo(i18n.T("history_empty"))
+		return nil
+	}
+
+	utils.PrintSubHeader(i18n.T("history_list_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	for i, entry := range entries {
+		prompt := runewidth.Truncate(entry.UserPrompt(), historyPromptWidth, "…")
+		fmt.Fprintf(utils.Report(), "  %s %s %s  %s\n",
+			utils.Gray(fmt.Sprintf("%3d", i+1)),
+			utils.Cyan(entry.ID),
+			utils.Gray(entry.Timestamp.Local().Format(historyTimeFormat)),
+			prompt)
+	}
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.T("history_list_hint"))
+	return nil
+}
+
+func historyShowAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	entry, ok := findHistoryEntry(cmd)
+	if !ok {
+		return nil
+	}
+
+	utils.PrintSubHeader(entry.ID)
+	fmt.Fprintln(utils.Diagnostics())
+	options := entry.Options
+	printPresetValue(i18n.T("history_time_label"), entry.Timestamp.Local().Format(historyTimeFormat))
+	fmt.Fprintf(utils.Report(), "%s %s\n", i18n.T("prompt_label"), utils.Bold(options.Prompt))
+	printPresetValue(i18n.T("original_prompt_label"), options.OriginalPrompt)
+	printPresetValue(i18n.T("preset_label"), entry.Preset)
+	printPresetValue(i18n.T("model_label"), options.Model)
+	printPresetValue(i18n.T("template_label"), options.Template)
+	printPresetValue(i18n.T("size_label"), options.Size)
+	printPresetValue(i18n.T("quality_label"), options.Quality)
+	printPresetValue(i18n.T("quantity_label"), strconv.Itoa(options.NumImages))
+	printPresetValue(i18n.T("background_label"), options.Background)
+	printPresetValue(i18n.T("format_label"), options.OutputFormat)
+	if entry.Branded {
+		printPresetValue(i18n.T("brand_label"), i18n.T("history_brand_applied"))
+	}
+	if len(entry.Files) > 0 {
+		fmt.Fprintln(utils.Report())
+		fmt.Fprintln(utils.Report(), i18n.T("history_files_label"))
+		protocol := generator.PreviewProtocol()
+		for _, file := range entry.Files {
+			fmt.Fprintf(utils.Report(), "  %s\n", file)
+			if utils.FileExists(file) {
+				generator.ShowPreview(file, protocol, preview.SmallWidth)
+			}
+		}
+	}
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.Tf("history_rerun_hint", entry.ID))
+	return nil
+}
+
+func historyRerunAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	entry, ok := findHistoryEntry(cmd)
+	if !ok {
+		return nil
+	}
+
+	hasCredentials, err := configService.HasCredentials()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+	if !hasCredentials {
+		utils.PrintError(i18n.T("interactive_api_key_required"))
+		utils.PrintDim(fmt.Sprintf("%s: just-icon config --api-key YOUR_KEY", i18n.T("interactive_api_key_set_hint")))
+		return nil
+	}
+
+	options := entry.Options
+	if output := strings.TrimSpace(cmd.String("output")); output != "" {
+		options.Output = utils.ExpandHome(output)
+	}
+
+	// The brand kit is not stored in history, so apply the current one
+	if entry.Branded {
+		cfg, err := configService.GetConfig()
+		if err != nil {
+			utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+			return err
+		}
+		if !brand.IsEmpty(cfg.Brand) {
+			options.Brand = cfg.Brand
+		} else {
+			utils.PrintWarning(i18n.T("history_brand_missing"))
+		}
+	}
+
+	if _, err := generator.Generate(ctx, &options, entry.Preset); err != nil {
+		utils.PrintError(i18n.Tf("history_rerun_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// findHistoryEntry resolves the command's reference argument, printing an
+// error when it is missing or matches nothing
+func findHistoryEntry(cmd *cli.Command) (*history.Entry, bool) {
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("history_missing_ref"))
+		return nil, false
+	}
+	ref := cmd.Args().First()
+
+	entry, err := history.DefaultStore().Find(ref)
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		utils.PrintError(i18n.Tf("history_not_found", ref))
+		utils.PrintDim(i18n.T("history_list_hint"))
+		return nil, false
+	case errors.Is(err, history.ErrAmbiguous):
+		utils.PrintError(i18n.Tf("history_ambiguous", ref))
+		return nil, false
+	case err != nil:
+		utils.PrintError(i18n.Tf("history_failed_to_read", err.Error()))
+		return nil, false
+	}
+	return entry, true
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/i18n"
+	"just-icon/internal/metadata"
+	"just-icon/pkg/utils"
+)
+
+// NewInspectCommand creates the inspect command
+func NewInspectCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "inspect",
+		Usage:       i18n.T("inspect_usage"),
+		Description: i18n.T("inspect_description"),
+		ArgsUsage:   "<image>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: i18n.T("inspect_flag_json"),
+			},
+		},
+		Action: inspectAction,
+	}
+}
+
+func inspectAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("inspect_missing_files"))
+		return nil
+	}
+
+	failed := false
+	var found []*metadata.Metadata
+	for _, path := range cmd.Args().Slice() {
+		md, source, err := metadata.Read(path)
+		if err != nil {
+			failed = true
+			if errors.Is(err, metadata.ErrNoMetadata) {
+				utils.PrintWarning(i18n.Tf("inspect_no_metadata", path))
+			} else {
+				utils.PrintError(i18n.Tf("inspect_failed", path, err.Error()))
+			}
+			continue
+		}
+
+		if cmd.Bool("json") {
+			found = append(found, md)
+			continue
+		}
+		printMetadata(path, md, source)
+	}
+
+	if cmd.Bool("json") && len(found) > 0 {
+		var data []byte
+		var err error
+		if len(found) == 1 {
+			data, err = json.MarshalIndent(found[0], "", "  ")
+		} else {
+			data, err = json.MarshalIndent(found, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+
+	if failed {
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// printMetadata shows an image's generation metadata
+func printMetadata(path string, md *metadata.Metadata, source metadata.Source) {
+	utils.PrintSubHeader(path)
+	utils.PrintDim(i18n.T("inspect_source_" + string(source)))
+	fmt.Fprintln(utils.Diagnostics())
+
+	fmt.Fprintf(utils.Report(), "%s %s\n", i18n.T("prompt_label"), utils.Bold(md.Prompt))
+	printPresetValue(i18n.T("original_prompt_label"), md.OriginalPrompt)
+	printPresetValue(i18n.T("final_prompt_label"), md.FinalPrompt)
+	printPresetValue(i18n.T("revised_prompt_label"), md.RevisedPrompt)
+	printPresetValue(i18n.T("preset_label"), md.Preset)
+	printPresetValue(i18n.T("reference_label"), md.Reference)
+	printPresetValue(i18n.T("template_label"), md.Template)
+	printPresetValue(i18n.T("model_label"), md.Model)
+	printPresetValue(i18n.T("provider_label"), md.Provider)
+	printPresetValue(i18n.T("size_label"), md.Size)
+	printPresetValue(i18n.T("quality_label"), md.Quality)
+	printPresetValue(i18n.T("background_label"), md.Background)
+	printPresetValue(i18n.T("format_label"), md.OutputFormat)
+	if md.CostUSD > 0 {
+		printPresetValue(i18n.T("cost_label"), generator.FormatCost(md.CostUSD))
+	}
+	if !md.Timestamp.IsZero() {
+		printPresetValue(i18n.T("history_time_label"), md.Timestamp.Local().Format(historyTimeFormat))
+	}
+}
@@ -0,0 +1,472 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/i18n"
+	"just-icon/internal/openai"
+	"just-icon/internal/queue"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// jobPromptWidth truncates prompts in the job list
+const jobPromptWidth = 50
+
+// NewJobsCommand creates the jobs command
+func NewJobsCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "jobs",
+		Usage:       i18n.T("jobs_usage"),
+		Description: i18n.T("jobs_description"),
+		Commands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     i18n.T("jobs_add_usage"),
+				ArgsUsage: "[prompt...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "file",
+						Usage:   i18n.T("jobs_flag_file"),
+						Aliases: []string{"f"},
+					},
+					&cli.StringFlag{
+						Name:    "preset",
+						Usage:   i18n.T("flag_preset"),
+						Aliases: []string{"p"},
+					},
+					&cli.StringFlag{
+						Name:    "template",
+						Usage:   i18n.T("preset_flag_template"),
+						Aliases: []string{"t"},
+					},
+					&cli.StringFlag{
+						Name:  "size",
+						Usage: i18n.T("preset_flag_size"),
+					},
+					&cli.StringFlag{
+						Name:  "quality",
+						Usage: i18n.T("preset_flag_quality"),
+					},
+					&cli.StringFlag{
+						Name:  "background",
+						Usage: i18n.T("preset_flag_background"),
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: i18n.T("preset_flag_format"),
+					},
+					&cli.IntFlag{
+						Name:    "count",
+						Usage:   i18n.T("preset_flag_count"),
+						Aliases: []string{"n"},
+					},
+					&cli.BoolFlag{
+						Name:  "raw",
+						Usage: i18n.T("jobs_flag_raw"),
+					},
+					&cli.BoolFlag{
+						Name:  "brand",
+						Usage: i18n.T("jobs_flag_brand"),
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   i18n.T("jobs_flag_output"),
+						Aliases: []string{"o"},
+					},
+					&cli.BoolFlag{
+						Name:  "no-run",
+						Usage: i18n.T("jobs_flag_no_run"),
+					},
+					jobsConcurrencyFlag(),
+				},
+				Action: jobsAddAction,
+			},
+			{
+				Name:   "list",
+				Usage:  i18n.T("jobs_list_usage"),
+				Action: jobsListAction,
+			},
+			{
+				Name:   "resume",
+				Usage:  i18n.T("jobs_resume_usage"),
+				Flags:  []cli.Flag{jobsConcurrencyFlag()},
+				Action: jobsResumeAction,
+			},
+			{
+				Name:      "cancel",
+				Usage:     i18n.T("jobs_cancel_usage"),
+				ArgsUsage: "<id...>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: i18n.T("jobs_flag_cancel_all"),
+					},
+				},
+				Action: jobsCancelAction,
+			},
+			{
+				Name:      "retry",
+				Usage:     i18n.T("jobs_retry_usage"),
+				ArgsUsage: "<id...>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: i18n.T("jobs_flag_retry_all"),
+					},
+					&cli.BoolFlag{
+						Name:  "no-run",
+						Usage: i18n.T("jobs_flag_no_run"),
+					},
+					jobsConcurrencyFlag(),
+				},
+				Action: jobsRetryAction,
+			},
+			{
+				Name:   "clear",
+				Usage:  i18n.T("jobs_clear_usage"),
+				Action: jobsClearAction,
+			},
+		},
+		Action: jobsListAction,
+	}
+}
+
+func jobsAddAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	prompts := cmd.Args().Slice()
+	if file := cmd.String("file"); file != "" {
+		filePrompts, err := readPrompts(file)
+		if err != nil {
+			utils.PrintError(i18n.Tf("jobs_file_failed", err.Error()))
+			return cli.Exit("", 1)
+		}
+		prompts = append(prompts, filePrompts...)
+	}
+	if len(prompts) == 0 {
+		utils.PrintError(i18n.T("jobs_missing_prompts"))
+		return cli.Exit("", 1)
+	}
+	if !checkJobCredentials(configService) {
+		return cli.Exit("", 1)
+	}
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+	output := utils.ExpandHome(cmd.String("output"))
+	if output == "" {
+		if output, err = configService.GetDefaultOutputPath(); err != nil {
+			utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+			return err
+		}
+		output = utils.ExpandHome(output)
+	}
+	// Jobs may be resumed from another directory, so store an absolute path
+	if output, err = filepath.Abs(output); err != nil {
+		utils.PrintError(err.Error())
+		return cli.Exit("", 1)
+	}
+
+	// Check every job before queueing any, so a bad setting fails at once
+	// rather than halfway through the batch
+	client, err := openai.NewClientFromConfig()
+	if err != nil {
+		utils.PrintError(err.Error())
+		return cli.Exit("", 1)
+	}
+	var batch []types.IconGenerationOptions
+	for _, prompt := range prompts {
+		options, err := generator.OptionsFor(cfg, generator.Settings{
+			Prompt:       prompt,
+			Preset:       cmd.String("preset"),
+			Template:     cmd.String("template"),
+			Size:         cmd.String("size"),
+			Quality:      cmd.String("quality"),
+			Background:   cmd.String("background"),
+			OutputFormat: cmd.String("format"),
+			NumImages:    int(cmd.Int("count")),
+			Raw:          cmd.Bool("raw"),
+			Brand:        cmd.Bool("brand"),
+		})
+		if err == nil {
+			options.Output = output
+			err = client.Validate(options)
+		}
+		if err != nil {
+			utils.PrintError(i18n.Tf("jobs_invalid", prompt, err.Error()))
+			return cli.Exit("", 1)
+		}
+		batch = append(batch, *options)
+	}
+
+	jobs, err := queue.DefaultStore().Add(cmd.String("preset"), batch...)
+	if err != nil {
+		utils.PrintError(i18n.Tf("jobs_failed_to_update", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("jobs_added", len(jobs)))
+	if cmd.Bool("no-run") {
+		utils.PrintInfo(i18n.T("jobs_resume_hint"))
+		return nil
+	}
+	return runJobs(ctx, cmd)
+}
+
+func jobsListAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	jobs, err := queue.DefaultStore().List()
+	if err != nil {
+		utils.PrintError(i18n.Tf("jobs_failed_to_read", err.Error()))
+		return err
+	}
+	if len(jobs) == 0 {
+		utils.PrintInfo(i18n.T("jobs_empty"))
+		return nil
+	}
+
+	utils.PrintSubHeader(i18n.T("jobs_list_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	counts := make(map[queue.Status]int)
+	for _, job := range jobs {
+		counts[job.Status]++
+		detail := ""
+		switch {
+		case job.Status == queue.Failed:
+			detail = utils.Red(runewidth.Truncate(job.Error, jobPromptWidth, "…"))
+		case len(job.Files) > 0:
+			detail = utils.Gray(i18n.Tf("jobs_files", len(job.Files), job.HistoryID))
+		}
+		fmt.Fprintf(utils.Report(), "  %s %s %s  %s  %s\n",
+			utils.Cyan(job.ID),
+			jobStatus(job.Status),
+			utils.Gray(i18n.Tf("jobs_attempts", job.Attempts)),
+			runewidth.Truncate(job.UserPrompt(), jobPromptWidth, "…"),
+			detail)
+	}
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.Tf("jobs_summary",
+		counts[queue.Queued]+counts[queue.Running], counts[queue.Succeeded], counts[queue.Failed], counts[queue.Canceled]))
+	if counts[queue.Queued]+counts[queue.Running] > 0 {
+		utils.PrintDim(i18n.T("jobs_resume_hint"))
+	}
+	return nil
+}
+
+func jobsResumeAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+	if !checkJobCredentials(config.DefaultService) {
+		return cli.Exit("", 1)
+	}
+	return runJobs(ctx, cmd)
+}
+
+func jobsCancelAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	refs, ok := jobRefs(cmd)
+	if !ok {
+		return cli.Exit("", 1)
+	}
+	jobs, err := queue.DefaultStore().Cancel(refs)
+	if err != nil {
+		utils.PrintError(i18n.Tf("jobs_failed_to_update", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("jobs_canceled", len(jobs)))
+	return nil
+}
+
+func jobsRetryAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	refs, ok := jobRefs(cmd)
+	if !ok {
+		return cli.Exit("", 1)
+	}
+	jobs, err := queue.DefaultStore().Retry(refs)
+	if err != nil {
+		utils.PrintError(i18n.Tf("jobs_failed_to_update", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("jobs_retried", len(jobs)))
+	for _, job := range jobs {
+		if len(job.Files) > 0 {
+			utils.PrintWarning(i18n.Tf("jobs_retry_has_files", job.ID, len(job.Files)))
+		}
+	}
+	if cmd.Bool("no-run") || len(jobs) == 0 {
+		return nil
+	}
+	if !checkJobCredentials(config.DefaultService) {
+		return cli.Exit("", 1)
+	}
+	return runJobs(ctx, cmd)
+}
+
+func jobsClearAction(ctx context.Context, cmd *cli.Command) error {
+	applyConfiguredLanguage(config.DefaultService)
+
+	removed, err := queue.DefaultStore().Remove()
+	if err != nil {
+		utils.PrintError(i18n.Tf("jobs_failed_to_update", err.Error()))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.Tf("jobs_cleared", removed))
+	return nil
+}
+
+// runJobs processes the queue until it is empty, printing each job as it
+// starts and finishes. Saved paths go to stdout so they can be piped.
+// Ctrl+C stops the run; its jobs stay queued for the next one.
+func runJobs(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Int("concurrency") <= 0 {
+		utils.PrintError(i18n.T("jobs_invalid_concurrency"))
+		return cli.Exit("", 1)
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := 0
+	worker := queue.NewWorker(queue.DefaultStore(), int(cmd.Int("concurrency")))
+	worker.OnUpdate = func(job queue.Job) {
+		prompt := runewidth.Truncate(job.UserPrompt(), jobPromptWidth, "…")
+		switch job.Status {
+		case queue.Queued:
+			utils.PrintInfo(i18n.Tf("jobs_requeued", job.ID, prompt))
+		case queue.Running:
+			utils.PrintInfo(i18n.Tf("jobs_started", job.ID, prompt))
+		case queue.Succeeded:
+			utils.PrintSuccess(i18n.Tf("jobs_succeeded", job.ID, prompt))
+			for _, warning := range job.Warnings {
+				utils.PrintWarning(warning)
+			}
+			for _, file := range job.Files {
+				fmt.Println(file)
+			}
+		case queue.Failed:
+			failed++
+			utils.PrintError(i18n.Tf("jobs_job_failed", job.ID, prompt, job.Error))
+		}
+	}
+
+	err := worker.Run(ctx)
+	switch {
+	case errors.Is(err, queue.ErrBusy):
+		utils.PrintInfo(i18n.T("jobs_worker_busy"))
+		return nil
+	case errors.Is(err, context.Canceled):
+		utils.PrintWarning(i18n.T("jobs_interrupted"))
+		return cli.Exit("", 1)
+	case err != nil:
+		utils.PrintError(i18n.Tf("jobs_failed_to_update", err.Error()))
+		return cli.Exit("", 1)
+	}
+	if failed > 0 {
+		utils.PrintDim(i18n.T("jobs_retry_hint"))
+		return cli.Exit("", 1)
+	}
+	utils.PrintSuccess(i18n.T("jobs_done"))
+	return nil
+}
+
+// jobsConcurrencyFlag creates the --concurrency flag of the commands that
+// run the queue
+func jobsConcurrencyFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:  "concurrency",
+		Usage: i18n.T("jobs_flag_concurrency"),
+		Value: queue.DefaultConcurrency,
+	}
+}
+
+// checkJobCredentials reports whether an API key is configured, printing
+// how to set one when it is not
+func checkJobCredentials(configService *config.Service) bool {
+	hasCredentials, err := configService.HasCredentials()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return false
+	}
+	if !hasCredentials {
+		utils.PrintError(i18n.T("interactive_api_key_required"))
+		utils.PrintDim(fmt.Sprintf("%s: just-icon config --api-key YOUR_KEY", i18n.T("interactive_api_key_set_hint")))
+		return false
+	}
+	return true
+}
+
+// jobRefs returns the job references of the command, or none with --all
+func jobRefs(cmd *cli.Command) ([]string, bool) {
+	refs := cmd.Args().Slice()
+	if cmd.Bool("all") {
+		if len(refs) > 0 {
+			utils.PrintError(i18n.T("jobs_refs_and_all"))
+			return nil, false
+		}
+		return nil, true
+	}
+	if len(refs) == 0 {
+		utils.PrintError(i18n.T("jobs_missing_ref"))
+		return nil, false
+	}
+	return refs, true
+}
+
+// jobStatus renders a job status padded for the list
+func jobStatus(status queue.Status) string {
+	label := fmt.Sprintf("%-9s", status)
+	switch status {
+	case queue.Succeeded:
+		return utils.Green(label)
+	case queue.Failed:
+		return utils.Red(label)
+	case queue.Running:
+		return utils.Yellow(label)
+	case queue.Canceled:
+		return utils.Gray(label)
+	}
+	return label
+}
+
+// readPrompts reads one prompt per line from a file, or from stdin for "-".
+// Blank lines and lines starting with # are skipped.
+func readPrompts(path string) ([]string, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(utils.ExpandHome(path))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		in = file
+	}
+	var prompts []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			prompts = append(prompts, line)
+		}
+	}
+	return prompts, scanner.Err()
+}
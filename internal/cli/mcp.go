@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/mcp"
+	"just-icon/pkg/utils"
+)
+
+// NewMCPCommand creates the mcp command
+func NewMCPCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "mcp",
+		Usage:       i18n.T("mcp_usage"),
+		Description: i18n.T("mcp_description"),
+		Action:      mcpAction,
+	}
+}
+
+func mcpAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	// Stdout carries the protocol, so everything else goes to stderr. Without
+	// credentials the server still starts: list_presets works and the
+	// generation tools explain what is missing.
+	if hasCredentials, err := configService.HasCredentials(); err == nil && !hasCredentials {
+		utils.PrintWarning(i18n.T("mcp_no_credentials"))
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := mcp.New(cmd.Root().Version).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		utils.PrintError(i18n.Tf("mcp_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/presets"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// NewPresetCommand creates the preset command
+func NewPresetCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "preset",
+		Usage:       i18n.T("preset_usage"),
+		Description: i18n.T("preset_description"),
+		Commands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  i18n.T("preset_list_usage"),
+				Action: presetListAction,
+			},
+			{
+				Name:      "show",
+				Usage:     i18n.T("preset_show_usage"),
+				ArgsUsage: "<name>",
+				Action:    presetShowAction,
+			},
+			{
+				Name:      "add",
+				Usage:     i18n.T("preset_add_usage"),
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "template",
+						Usage:   i18n.T("preset_flag_template"),
+						Aliases: []string{"t"},
+					},
+					&cli.StringFlag{
+						Name:  "size",
+						Usage: i18n.T("preset_flag_size"),
+					},
+					&cli.StringFlag{
+						Name:    "quality",
+						Usage:   i18n.T("preset_flag_quality"),
+						Aliases: []string{"q"},
+					},
+					&cli.StringFlag{
+						Name:    "background",
+						Usage:   i18n.T("preset_flag_background"),
+						Aliases: []string{"b"},
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: i18n.T("preset_flag_format"),
+					},
+					&cli.IntFlag{
+						Name:    "count",
+						Usage:   i18n.T("preset_flag_count"),
+						Aliases: []string{"n"},
+					},
+					&cli.StringFlag{
+						Name:    "description",
+						Usage:   i18n.T("preset_flag_description"),
+						Aliases: []string{"d"},
+					},
+				},
+				Action: presetAddAction,
+			},
+			{
+				Name:      "remove",
+				Usage:     i18n.T("preset_remove_usage"),
+				ArgsUsage: "<name>",
+				Action:    presetRemoveAction,
+			},
+		},
+		Action: presetListAction,
+	}
+}
+
+func presetListAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	list := presets.List(cfg.Presets)
+	if len(list) == 0 {
+		utils.PrintInfo(i18n.T("preset_list_empty"))
+		utils.PrintDim(i18n.T("preset_add_hint"))
+		return nil
+	}
+
+	utils.PrintSubHeader(i18n.T("preset_list_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	for _, preset := range list {
+		fmt.Fprintf(utils.Report(), "  %s %s\n", utils.Cyan(fmt.Sprintf("%-18s", preset.Name)), preset.Description)
+		fmt.Fprintf(utils.Report(), "  %-18s %s\n", "", utils.Gray(presetSummary(preset.Preset)))
+	}
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.T("preset_list_hint"))
+	return nil
+}
+
+func presetShowAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("preset_missing_name"))
+		return nil
+	}
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	name := cmd.Args().First()
+	preset, ok := presets.Lookup(name, cfg.Presets)
+	if !ok {
+		utils.PrintError(i18n.Tf("preset_not_found", name))
+		return nil
+	}
+
+	utils.PrintSubHeader(preset.Name)
+	fmt.Fprintln(utils.Diagnostics())
+	if preset.Description != "" {
+		utils.PrintKeyValue(i18n.T("template_description_label"), preset.Description)
+	}
+	printPresetValue(i18n.T("template_label"), preset.Template)
+	printPresetValue(i18n.T("size_label"), preset.Size)
+	printPresetValue(i18n.T("quality_label"), preset.Quality)
+	printPresetValue(i18n.T("background_label"), preset.Background)
+	printPresetValue(i18n.T("format_label"), preset.OutputFormat)
+	if preset.NumImages != 0 {
+		printPresetValue(i18n.T("quantity_label"), strconv.Itoa(preset.NumImages))
+	}
+	return nil
+}
+
+func presetAddAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("preset_missing_name"))
+		return nil
+	}
+	name := cmd.Args().First()
+	if err := presets.ValidateName(name); err != nil {
+		utils.PrintError(err.Error())
+		return nil
+	}
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	preset := &types.Preset{
+		Description:  cmd.String("description"),
+		Template:     cmd.String("template"),
+		Size:         cmd.String("size"),
+		Quality:      cmd.String("quality"),
+		Background:   cmd.String("background"),
+		OutputFormat: cmd.String("format"),
+		NumImages:    int(cmd.Int("count")),
+	}
+	if err := presets.Validate(*preset, cfg.Templates); err != nil {
+		utils.PrintError(i18n.Tf("preset_invalid", err.Error()))
+		return nil
+	}
+
+	if err := configService.SavePreset(name, preset); err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.Tf("preset_saved", name))
+	utils.PrintDim(i18n.Tf("preset_use_hint", name))
+	return nil
+}
+
+func presetRemoveAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("preset_missing_name"))
+		return nil
+	}
+	name := cmd.Args().First()
+
+	if err := configService.RemovePreset(name); err != nil {
+		if errors.Is(err, config.ErrPresetNotFound) {
+			utils.PrintError(i18n.Tf("preset_not_found", name))
+			return nil
+		}
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.Tf("preset_removed", name))
+	return nil
+}
+
+// printPresetValue prints a preset field, skipping unset ones
+func printPresetValue(label, value string) {
+	if value != "" {
+		fmt.Fprintf(utils.Report(), "%s %s\n", label, utils.Cyan(value))
+	}
+}
+
+// presetSummary describes the preset's overrides on one line
+func presetSummary(preset types.Preset) string {
+	if summary := presets.Summary(preset); summary != "" {
+		return summary
+	}
+	return i18n.T("preset_no_overrides")
+}
@@ -42,6 +42,12 @@ func NewResetCommand() *cli.Command {
 			force := cmd.Bool("force")
 
 			if !force {
+				// Without a terminal nobody can answer, so insist on --force
+				if !utils.CanPrompt() {
+					utils.PrintError(i18n.T("confirm_requires_terminal"))
+					return cli.Exit("", 1)
+				}
+
 				// Ask for confirmation using internationalized text
 				fmt.Printf("⚠️  %s\n", i18n.T("reset_warning"))
 				fmt.Printf("%s (y/N): ", i18n.T("reset_confirm"))
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/server"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// NewServeCommand creates the serve command
+func NewServeCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "serve",
+		Usage:       i18n.T("serve_usage"),
+		Description: i18n.T("serve_description"),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "addr",
+				Value: server.DefaultAddr,
+				Usage: i18n.T("serve_flag_addr"),
+			},
+			&cli.StringFlag{
+				Name:  "token",
+				Usage: i18n.Tf("serve_flag_token", server.TokenEnv),
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   i18n.T("serve_flag_output"),
+			},
+			&cli.IntFlag{
+				Name:  "max-body",
+				Value: server.DefaultMaxBodyMB,
+				Usage: i18n.T("serve_flag_max_body"),
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Value: server.DefaultConcurrency,
+				Usage: i18n.T("serve_flag_concurrency"),
+			},
+		},
+		Action: serveAction,
+	}
+}
+
+func serveAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	hasCredentials, err := configService.HasCredentials()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+	if !hasCredentials {
+		utils.PrintError(i18n.T("interactive_api_key_required"))
+		utils.PrintDim(fmt.Sprintf("%s: just-icon config --api-key YOUR_KEY", i18n.T("interactive_api_key_set_hint")))
+		return nil
+	}
+	if cmd.Int("max-body") <= 0 || cmd.Int("concurrency") <= 0 {
+		utils.PrintError(i18n.T("serve_invalid_limits"))
+		return nil // Don't return error to avoid showing usage
+	}
+
+	// Without a token, make one up for this run so the API is never open
+	token := cmd.String("token")
+	if token == "" {
+		token = os.Getenv(server.TokenEnv)
+	}
+	if token == "" {
+		if token, err = server.NewToken(); err != nil {
+			utils.PrintError(err.Error())
+			return cli.Exit("", 1)
+		}
+		utils.PrintWarning(i18n.Tf("serve_token_generated", token, server.TokenEnv))
+	}
+
+	root := utils.ExpandHome(cmd.String("output"))
+	if root == "" {
+		root = filepath.Join(configService.StateDir(), "serve")
+	}
+	if root, err = filepath.Abs(root); err == nil {
+		err = os.MkdirAll(root, types.ConfigDirPerm)
+	}
+	if err != nil {
+		utils.PrintError(i18n.Tf("serve_failed", err.Error()))
+		return cli.Exit("", 1)
+	}
+
+	api := server.New(server.Options{
+		Token:        token,
+		Root:         root,
+		MaxBodyBytes: int64(cmd.Int("max-body")) << 20,
+		Concurrency:  int(cmd.Int("concurrency")),
+	})
+	httpServer := &http.Server{
+		Addr:              cmd.String("addr"),
+		Handler:           api.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() { errs <- httpServer.ListenAndServe() }()
+	utils.PrintSuccess(i18n.Tf("serve_listening", httpServer.Addr))
+	utils.PrintDim("   " + i18n.Tf("serve_files", root))
+
+	select {
+	case err := <-errs:
+		utils.PrintError(i18n.Tf("serve_failed", err.Error()))
+		return cli.Exit("", 1)
+	case <-ctx.Done():
+	}
+
+	// Stop the jobs, so waiting requests answer, then let requests in
+	// flight finish
+	api.Close()
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdown); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		utils.PrintWarning(err.Error())
+	}
+	utils.PrintInfo(i18n.T("serve_stopped"))
+	return nil
+}
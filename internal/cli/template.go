@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+
+	"just-icon/internal/config"
+	"just-icon/internal/i18n"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// NewTemplateCommand creates the template command
+func NewTemplateCommand() *cli.Command {
+	return &cli.Command{
+		Name:        "template",
+		Usage:       i18n.T("template_usage"),
+		Description: i18n.T("template_description"),
+		Commands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  i18n.T("template_list_usage"),
+				Action: templateListAction,
+			},
+			{
+				Name:      "show",
+				Usage:     i18n.T("template_show_usage"),
+				ArgsUsage: "<name>",
+				Action:    templateShowAction,
+			},
+			{
+				Name:      "add",
+				Usage:     i18n.T("template_add_usage"),
+				ArgsUsage: "<name>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "text",
+						Usage: i18n.T("template_flag_text"),
+					},
+					&cli.StringFlag{
+						Name:    "file",
+						Usage:   i18n.T("template_flag_file"),
+						Aliases: []string{"f"},
+					},
+					&cli.StringFlag{
+						Name:    "description",
+						Usage:   i18n.T("template_flag_description"),
+						Aliases: []string{"d"},
+					},
+					&cli.StringFlag{
+						Name:    "platform",
+						Usage:   i18n.T("template_flag_platform"),
+						Aliases: []string{"p"},
+					},
+				},
+				Action: templateAddAction,
+			},
+			{
+				Name:      "remove",
+				Usage:     i18n.T("template_remove_usage"),
+				ArgsUsage: "<name>",
+				Action:    templateRemoveAction,
+			},
+			{
+				Name:      "default",
+				Usage:     i18n.T("template_default_usage"),
+				ArgsUsage: "<name>",
+				Action:    templateDefaultAction,
+			},
+		},
+		Action: templateListAction,
+	}
+}
+
+func templateListAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return err
+	}
+
+	defaultName := cfg.DefaultTemplate
+	if defaultName == "" {
+		defaultName = templates.Default
+	}
+
+	utils.PrintSubHeader(i18n.T("template_list_title"))
+	fmt.Fprintln(utils.Diagnostics())
+	for _, template := range templates.List(cfg.Templates) {
+		marker := " "
+		if template.Name == defaultName {
+			marker = utils.Green("*")
+		}
+
+		kind := i18n.T("template_builtin")
+		if !template.BuiltIn {
+			kind = i18n.T("template_custom")
+		}
+
+		fmt.Fprintf(utils.Report(), "%s %s %s %s\n", marker, utils.Cyan(fmt.Sprintf("%-18s", template.Name)), utils.Gray("["+kind+"]"), template.Describe())
+	}
+	fmt.Fprintln(utils.Diagnostics())
+	utils.PrintDim(i18n.T("template_list_hint"))
+	return nil
+}
+
+func templateShowAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	template, ok, err := lookupTemplateArg(configService, cmd)
+	if err != nil || !ok {
+		return err
+	}
+
+	utils.PrintSubHeader(template.Name)
+	fmt.Fprintln(utils.Diagnostics())
+	if description := template.Describe(); description != "" {
+		utils.PrintKeyValue(i18n.T("template_description_label"), description)
+	}
+	if template.Platform != "" {
+		utils.PrintKeyValue(i18n.T("template_platform_label"), utils.Cyan(template.Platform))
+	}
+	fmt.Fprintln(utils.Report())
+	fmt.Fprintln(utils.Report(), template.Text)
+	return nil
+}
+
+func templateAddAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("template_missing_name"))
+		return nil
+	}
+	name := cmd.Args().First()
+	if err := templates.ValidateName(name); err != nil {
+		utils.PrintError(err.Error())
+		return nil
+	}
+
+	text := cmd.String("text")
+	if file := cmd.String("file"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			utils.PrintError(i18n.Tf("template_read_failed", err.Error()))
+			return err
+		}
+		text = string(data)
+	}
+	if text == "" {
+		utils.PrintError(i18n.T("template_missing_text"))
+		return nil
+	}
+
+	if err := templates.Validate(text); err != nil {
+		utils.PrintError(i18n.Tf("template_invalid", err.Error()))
+		return nil
+	}
+
+	template := &types.PromptTemplate{
+		Description: cmd.String("description"),
+		Platform:    cmd.String("platform"),
+		Text:        text,
+	}
+	if err := configService.SaveTemplate(name, template); err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	if templates.IsBuiltIn(name) {
+		utils.PrintWarning(i18n.Tf("template_overrides_builtin", name))
+	}
+	utils.PrintSuccess(i18n.Tf("template_saved", name))
+	return nil
+}
+
+func templateRemoveAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("template_missing_name"))
+		return nil
+	}
+	name := cmd.Args().First()
+
+	if err := configService.RemoveTemplate(name); err != nil {
+		if errors.Is(err, config.ErrTemplateNotFound) {
+			if templates.IsBuiltIn(name) {
+				utils.PrintError(i18n.Tf("template_remove_builtin", name))
+			} else {
+				utils.PrintError(i18n.Tf("template_not_found", name))
+			}
+			return nil
+		}
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.Tf("template_removed", name))
+	return nil
+}
+
+func templateDefaultAction(ctx context.Context, cmd *cli.Command) error {
+	configService := config.DefaultService
+	applyConfiguredLanguage(configService)
+
+	template, ok, err := lookupTemplateArg(configService, cmd)
+	if err != nil || !ok {
+		return err
+	}
+
+	if err := configService.SetDefaultTemplate(template.Name); err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_save", err.Error()))
+		return err
+	}
+
+	utils.PrintSuccess(i18n.Tf("template_default_success", template.Name))
+	return nil
+}
+
+// lookupTemplateArg resolves the template named by the first argument and
+// prints an error when it is missing or unknown
+func lookupTemplateArg(configService *config.Service, cmd *cli.Command) (templates.Template, bool, error) {
+	if cmd.Args().Len() < 1 {
+		utils.PrintError(i18n.T("template_missing_name"))
+		return templates.Template{}, false, nil
+	}
+
+	cfg, err := configService.GetConfig()
+	if err != nil {
+		utils.PrintError(i18n.Tf("config_failed_to_read", err.Error()))
+		return templates.Template{}, false, err
+	}
+
+	name := cmd.Args().First()
+	template, ok := templates.Lookup(name, cfg.Templates)
+	if !ok {
+		utils.PrintError(i18n.Tf("template_not_found", name))
+	}
+	return template, ok, nil
+}
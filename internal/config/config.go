@@ -3,14 +3,19 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"just-icon/internal/types"
+	"just-icon/pkg/utils"
 )
 
 const (
 	ConfigFileName = "just-icon.json"
+	LockFileSuffix = ".lock"
+	StateDirName   = ".just-icon"
 )
 
 // Service handles configuration management
@@ -32,6 +37,13 @@ func NewService() *Service {
 	}
 }
 
+// NewServiceWithPath creates a configuration service backed by a specific file
+func NewServiceWithPath(configPath string) *Service {
+	return &Service{
+		configPath: configPath,
+	}
+}
+
 // GetConfig reads the configuration from file
 func (s *Service) GetConfig() (*types.Config, error) {
 	config := &types.Config{}
@@ -58,56 +70,91 @@ func (s *Service) GetConfig() (*types.Config, error) {
 
 // SetConfig writes the configuration to file
 func (s *Service) SetConfig(config *types.Config) error {
+	return s.withLock(func() error {
+		return s.writeConfig(config)
+	})
+}
+
+// UpdateConfig updates specific fields in the configuration
+func (s *Service) UpdateConfig(updates map[string]interface{}) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		// Apply updates
+		for key, value := range updates {
+			switch key {
+			case "openai_api_key":
+				if v, ok := value.(string); ok {
+					config.OpenAIAPIKey = v
+				}
+			case "base_url":
+				if v, ok := value.(string); ok {
+					config.BaseURL = v
+				}
+			case "default_output_path":
+				if v, ok := value.(string); ok {
+					config.DefaultOutputPath = v
+				}
+			case "language":
+				if v, ok := value.(string); ok {
+					config.Language = v
+				}
+			case "provider":
+				if v, ok := value.(string); ok {
+					config.Provider = v
+				}
+			case "initialized":
+				if v, ok := value.(bool); ok {
+					config.Initialized = v
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// modifyConfig runs a read-modify-write cycle on the configuration while
+// holding the config file lock, so concurrent processes don't lose updates
+func (s *Service) modifyConfig(modify func(*types.Config) error) error {
+	return s.withLock(func() error {
+		config, err := s.GetConfig()
+		if err != nil {
+			return err
+		}
+
+		if err := modify(config); err != nil {
+			return err
+		}
+
+		return s.writeConfig(config)
+	})
+}
+
+// withLock runs fn while holding the advisory lock next to the config file
+func (s *Service) withLock(fn func() error) error {
+	lock, err := utils.LockFile(s.configPath + LockFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to lock config file: %w", err)
+	}
+	defer lock.Unlock()
+
+	return fn()
+}
+
+// writeConfig atomically replaces the config file; callers must hold the lock
+func (s *Service) writeConfig(config *types.Config) error {
 	// Marshal config to JSON
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Write to file (directly to home directory)
-	if err := os.WriteFile(s.configPath, data, types.ConfigFilePerm); err != nil {
+	// Write to a temp file and rename it over the config file
+	if err := utils.WriteFileAtomic(s.configPath, data, types.ConfigFilePerm); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
-// UpdateConfig updates specific fields in the configuration
-func (s *Service) UpdateConfig(updates map[string]interface{}) error {
-	config, err := s.GetConfig()
-	if err != nil {
-		return err
-	}
-	
-	// Apply updates
-	for key, value := range updates {
-		switch key {
-		case "openai_api_key":
-			if v, ok := value.(string); ok {
-				config.OpenAIAPIKey = v
-			}
-		case "base_url":
-			if v, ok := value.(string); ok {
-				config.BaseURL = v
-			}
-		case "default_output_path":
-			if v, ok := value.(string); ok {
-				config.DefaultOutputPath = v
-			}
-		case "language":
-			if v, ok := value.(string); ok {
-				config.Language = v
-			}
-		case "initialized":
-			if v, ok := value.(bool); ok {
-				config.Initialized = v
-			}
-		}
-	}
-	
-	return s.SetConfig(config)
-}
-
 // getConfigField is a generic method to get a config field with fallback
 func (s *Service) getConfigField(getter func(*types.Config) string, defaultValue string) (string, error) {
 	config, err := s.GetConfig()
@@ -169,21 +216,18 @@ func (s *Service) SetInitialized() error {
 	return s.setConfigField("initialized", true)
 }
 
-// ResetConfig resets the configuration to default values
+// ResetConfig resets the configuration to default values. The current file
+// is not read, so a corrupt config can be reset too.
 func (s *Service) ResetConfig() error {
-	// Remove existing config file first
-	if err := os.Remove(s.configPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove existing config file: %w", err)
-	}
-
-	defaultConfig := map[string]interface{}{
-		"language":             types.DefaultValues.Language,
-		"base_url":            types.DefaultValues.BaseURL,
-		"default_output_path": types.DefaultValues.OutputPath,
-		"initialized":         false,
-	}
-
-	return s.UpdateConfig(defaultConfig)
+	return s.withLock(func() error {
+		// Drop every existing setting, including the API key
+		return s.writeConfig(&types.Config{
+			Language:          types.DefaultValues.Language,
+			BaseURL:           types.DefaultValues.BaseURL,
+			DefaultOutputPath: types.DefaultValues.OutputPath,
+			Initialized:       false,
+		})
+	})
 }
 
 // SetAPIKey sets the OpenAI API key
@@ -201,11 +245,128 @@ func (s *Service) SetLanguage(language string) error {
 	return s.setConfigField("language", language)
 }
 
+// updateSection applies update to a copy of the config section that
+// section points to, starting from an empty one when it is unset. A section
+// left empty is removed from the config. If update returns an error nothing
+// is saved.
+func updateSection[T any](s *Service, section func(*types.Config) **T, update func(*T) error) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		var value T
+		if current := *section(config); current != nil {
+			value = *current
+		}
+
+		if err := update(&value); err != nil {
+			return err
+		}
+
+		if reflect.ValueOf(value).IsZero() {
+			*section(config) = nil
+		} else {
+			*section(config) = &value
+		}
+		return nil
+	})
+}
+
+// UpdateHTTPConfig applies changes to the HTTP client settings. If update
+// returns an error nothing is saved.
+func (s *Service) UpdateHTTPConfig(update func(*types.HTTPConfig) error) error {
+	return updateSection(s, func(c *types.Config) **types.HTTPConfig { return &c.HTTP }, func(httpConfig *types.HTTPConfig) error {
+		if err := update(httpConfig); err != nil {
+			return err
+		}
+		// Drop the section entirely once every setting is cleared
+		if len(httpConfig.Headers) == 0 {
+			httpConfig.Headers = nil
+		}
+		return nil
+	})
+}
+
+// UpdateAzureConfig applies changes to the Azure OpenAI settings. If update
+// returns an error nothing is saved.
+func (s *Service) UpdateAzureConfig(update func(*types.AzureConfig) error) error {
+	return updateSection(s, func(c *types.Config) **types.AzureConfig { return &c.Azure }, func(azureConfig *types.AzureConfig) error {
+		if err := update(azureConfig); err != nil {
+			return err
+		}
+		if len(azureConfig.Deployments) == 0 {
+			azureConfig.Deployments = nil
+		}
+		return nil
+	})
+}
+
+// UpdateBrandKit applies update to the brand kit and saves it. An empty kit
+// is removed from the config.
+func (s *Service) UpdateBrandKit(update func(*types.BrandKit) error) error {
+	return updateSection(s, func(c *types.Config) **types.BrandKit { return &c.Brand }, update)
+}
+
+// UpdateEnhanceConfig applies update to the prompt enhancement settings and
+// saves them
+func (s *Service) UpdateEnhanceConfig(update func(*types.EnhanceConfig) error) error {
+	return updateSection(s, func(c *types.Config) **types.EnhanceConfig { return &c.Enhance }, update)
+}
+
+// UpdateOutputConfig applies update to the output naming settings and saves
+// them
+func (s *Service) UpdateOutputConfig(update func(*types.OutputConfig) error) error {
+	return updateSection(s, func(c *types.Config) **types.OutputConfig { return &c.Output }, update)
+}
+
+// UpdateLogConfig applies update to the request log settings and saves them
+func (s *Service) UpdateLogConfig(update func(*types.LogConfig) error) error {
+	return updateSection(s, func(c *types.Config) **types.LogConfig { return &c.Log }, update)
+}
+
+// GetProvider returns the configured API provider
+func (s *Service) GetProvider() (string, error) {
+	return s.getConfigField(func(c *types.Config) string { return c.Provider }, types.ProviderOpenAI)
+}
+
+// HasCredentials reports whether the selected provider has credentials
+// configured, either in the config file or through the environment
+func (s *Service) HasCredentials() (bool, error) {
+	config, err := s.GetConfig()
+	if err != nil {
+		return false, err
+	}
+
+	if config.Provider != types.ProviderAzure {
+		return config.OpenAIAPIKey != "", nil
+	}
+
+	azure := config.Azure
+	if azure == nil || azure.Endpoint == "" {
+		return false, nil
+	}
+	if azure.Auth == types.AzureAuthAAD {
+		return azure.TokenCommand != "" || os.Getenv(types.AzureADTokenEnv) != "", nil
+	}
+	return azure.APIKey != "" || os.Getenv(types.AzureAPIKeyEnv) != "", nil
+}
+
+// ValidateProvider validates a provider name
+func ValidateProvider(provider string) error {
+	if provider != types.ProviderOpenAI && provider != types.ProviderAzure {
+		return fmt.Errorf("unsupported provider: %s. Supported providers: %s, %s", provider, types.ProviderOpenAI, types.ProviderAzure)
+	}
+	return nil
+}
+
 // GetConfigPath returns the path to the config file
 func (s *Service) GetConfigPath() string {
 	return s.configPath
 }
 
+// StateDir returns the directory next to the config file that holds
+// history and other generated state
+func (s *Service) StateDir() string {
+	return filepath.Join(filepath.Dir(s.configPath), StateDirName)
+}
+
 // ValidateAPIKey validates the format of an API key
 func ValidateAPIKey(apiKey string) error {
 	if apiKey == "" {
@@ -215,5 +376,27 @@ func ValidateAPIKey(apiKey string) error {
 	return nil
 }
 
+// ValidateBaseURL validates that a base URL is an absolute http(s) URL
+func ValidateBaseURL(baseURL string) error {
+	if baseURL == "" {
+		return fmt.Errorf("base URL cannot be empty")
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("base URL must start with http:// or https://")
+	}
+
+	if parsed.Host == "" {
+		return fmt.Errorf("base URL must include a host")
+	}
+
+	return nil
+}
+
 // DefaultService returns a default configuration service instance
 var DefaultService = NewService()
@@ -1,9 +1,17 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+
+	"just-icon/internal/types"
 )
 
 func TestNewService(t *testing.T) {
@@ -165,3 +173,231 @@ func TestUpdateConfig(t *testing.T) {
 		t.Errorf("Output path not updated correctly")
 	}
 }
+
+func TestUpdateConfigConcurrent(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	const workers = 8
+	const iterations = 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*iterations)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			// Separate service instances behave like separate processes
+			service := &Service{configPath: configPath}
+			for i := 0; i < iterations; i++ {
+				err := service.UpdateConfig(map[string]interface{}{
+					"default_output_path": fmt.Sprintf("/worker/%d/%d", w, i),
+					"language":            "en",
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Every read must see a complete, parseable file
+				if _, err := service.GetConfig(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("concurrent UpdateConfig failed: %v", err)
+	}
+
+	config, err := (&Service{configPath: configPath}).GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() failed: %v", err)
+	}
+	if !strings.HasPrefix(config.DefaultOutputPath, "/worker/") {
+		t.Errorf("unexpected output path after concurrent updates: %q", config.DefaultOutputPath)
+	}
+
+	// No temp files should be left behind
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(configPath), ".config.json.tmp-*"))
+	if len(matches) != 0 {
+		t.Errorf("leftover temp files: %v", matches)
+	}
+}
+
+func TestModifyConfigNoLostUpdates(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	const workers = 8
+	const iterations = 20
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service := &Service{configPath: configPath}
+			for i := 0; i < iterations; i++ {
+				if err := incrementCounter(service); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assertCounter(t, configPath, workers*iterations)
+}
+
+// TestUpdateConfigAcrossProcesses re-runs the test binary as several child
+// processes that increment a shared counter through the config file lock
+func TestUpdateConfigAcrossProcesses(t *testing.T) {
+	if path := os.Getenv("JUST_ICON_TEST_CONFIG_PATH"); path != "" {
+		service := &Service{configPath: path}
+		for i := 0; i < 20; i++ {
+			if err := incrementCounter(service); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	const processes = 4
+	var cmds []*exec.Cmd
+	for p := 0; p < processes; p++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestUpdateConfigAcrossProcesses$")
+		cmd.Env = append(os.Environ(), "JUST_ICON_TEST_CONFIG_PATH="+configPath)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("failed to start child process: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("child process failed: %v", err)
+		}
+	}
+
+	assertCounter(t, configPath, processes*20)
+}
+
+// incrementCounter uses the output path as a counter to detect lost updates
+func incrementCounter(service *Service) error {
+	return service.modifyConfig(func(config *types.Config) error {
+		n := 0
+		if config.DefaultOutputPath != "" {
+			var err error
+			if n, err = strconv.Atoi(config.DefaultOutputPath); err != nil {
+				return err
+			}
+		}
+		config.DefaultOutputPath = strconv.Itoa(n + 1)
+		return nil
+	})
+}
+
+func assertCounter(t *testing.T, configPath string, want int) {
+	t.Helper()
+
+	config, err := (&Service{configPath: configPath}).GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() failed: %v", err)
+	}
+	if config.DefaultOutputPath != strconv.Itoa(want) {
+		t.Errorf("Expected counter %d, got %s (lost updates)", want, config.DefaultOutputPath)
+	}
+}
+
+func TestResetCorruptConfig(t *testing.T) {
+	service := NewServiceWithPath(filepath.Join(t.TempDir(), ConfigFileName))
+	if err := os.WriteFile(service.GetConfigPath(), []byte(`{"openai_api_key": "sk-`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.GetConfig(); err == nil {
+		t.Fatal("expected the corrupt config to fail to parse")
+	}
+
+	if err := service.ResetConfig(); err != nil {
+		t.Fatalf("ResetConfig() failed: %v", err)
+	}
+	config, err := service.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() after reset failed: %v", err)
+	}
+	if config.OpenAIAPIKey != "" || config.Language != types.DefaultValues.Language || config.Initialized {
+		t.Errorf("reset config = %+v", config)
+	}
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	service := NewServiceWithPath(filepath.Join(t.TempDir(), "config.json"))
+	if err := service.SaveTemplate("team", &types.PromptTemplate{Text: "{{.Prompt}}"}); err != nil {
+		t.Fatalf("SaveTemplate() failed: %v", err)
+	}
+	if err := service.SetDefaultTemplate("team"); err != nil {
+		t.Fatalf("SetDefaultTemplate() failed: %v", err)
+	}
+
+	if err := service.RemoveTemplate("team"); err != nil {
+		t.Fatalf("RemoveTemplate() failed: %v", err)
+	}
+	config, err := service.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() failed: %v", err)
+	}
+	if config.Templates != nil || config.DefaultTemplate != "" {
+		t.Errorf("expected template and default to be cleared, got %+v, %q", config.Templates, config.DefaultTemplate)
+	}
+
+	if err := service.RemoveTemplate("team"); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("expected ErrTemplateNotFound, got %v", err)
+	}
+}
+
+func TestUpdateSection(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+
+	if err := service.UpdateHTTPConfig(func(c *types.HTTPConfig) error {
+		c.UserAgent = "team"
+		c.Headers = map[string]string{}
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateHTTPConfig() failed: %v", err)
+	}
+	config, _ := service.GetConfig()
+	if config.HTTP == nil || config.HTTP.UserAgent != "team" || config.HTTP.Headers != nil {
+		t.Errorf("expected the user agent to be saved without headers, got %+v", config.HTTP)
+	}
+
+	// A failed update saves nothing
+	failed := errors.New("invalid")
+	if err := service.UpdateHTTPConfig(func(c *types.HTTPConfig) error {
+		c.UserAgent = ""
+		return failed
+	}); !errors.Is(err, failed) {
+		t.Errorf("expected the update error, got %v", err)
+	}
+	config, _ = service.GetConfig()
+	if config.HTTP == nil || config.HTTP.UserAgent != "team" {
+		t.Errorf("a failed update changed the config: %+v", config.HTTP)
+	}
+
+	// An emptied section is removed
+	if err := service.UpdateHTTPConfig(func(c *types.HTTPConfig) error {
+		c.UserAgent = ""
+		return nil
+	}); err != nil {
+		t.Fatalf("UpdateHTTPConfig() failed: %v", err)
+	}
+	config, _ = service.GetConfig()
+	if config.HTTP != nil {
+		t.Errorf("expected an empty section to be removed, got %+v", config.HTTP)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+
+	"just-icon/internal/types"
+)
+
+// ErrPresetNotFound is returned when removing a preset that is not in the config
+var ErrPresetNotFound = errors.New("preset not found")
+
+// SavePreset adds or replaces a style preset
+func (s *Service) SavePreset(name string, preset *types.Preset) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		if config.Presets == nil {
+			config.Presets = make(map[string]*types.Preset)
+		}
+		config.Presets[name] = preset
+		return nil
+	})
+}
+
+// RemovePreset deletes a style preset
+func (s *Service) RemovePreset(name string) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		if _, ok := config.Presets[name]; !ok {
+			return ErrPresetNotFound
+		}
+		delete(config.Presets, name)
+		if len(config.Presets) == 0 {
+			config.Presets = nil
+		}
+		return nil
+	})
+}
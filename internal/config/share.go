@@ -0,0 +1,399 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// SharedConfigVersion is the format version written by ExportConfig
+const SharedConfigVersion = 1
+
+// secretKeys are never written to a shared config file and are ignored on
+// import. Nested settings use dotted paths. Token commands count as secrets:
+// they run on this machine, so a shared file must not be able to set one.
+// Gateway headers usually carry credentials.
+var secretKeys = map[string]bool{
+	"openai_api_key":      true,
+	"azure.api_key":       true,
+	"azure.token_command": true,
+	"http.headers":        true,
+}
+
+// trustKeys decide where requests and credentials are sent or which
+// servers are trusted. They are exported, but a shared file only changes them
+// when the import is explicitly trusted, since a tampered file could otherwise
+// route the API key through a host of its choosing.
+var trustKeys = map[string]bool{
+	"base_url":       true,
+	"azure.endpoint": true,
+	"http.proxy_url": true,
+	"http.ca_file":   true,
+}
+
+// localKeys describe the local installation and are not portable
+var localKeys = map[string]bool{
+	"initialized": true,
+}
+
+// pathKeys hold filesystem paths that are made home-relative on export
+var pathKeys = map[string]bool{
+	"default_output_path":   true,
+	"brand.reference_logo":  true,
+	"http.ca_file":          true,
+	"http.client_cert_file": true,
+	"http.client_key_file":  true,
+}
+
+// Import change statuses
+const (
+	ImportAdded       = "added"
+	ImportUnchanged   = "unchanged"
+	ImportConflict    = "conflict"
+	ImportOverwritten = "overwritten"
+	ImportSkipped     = "skipped"
+	ImportUntrusted   = "untrusted"
+)
+
+// ImportChange describes what happened to a single setting during import
+type ImportChange struct {
+	Key      string
+	Status   string
+	Local    string
+	Incoming string
+}
+
+// IsSecretKey reports whether a config key holds a credential
+func IsSecretKey(key string) bool {
+	return secretKeys[key]
+}
+
+// ExportConfig returns a portable copy of the configuration with secrets and
+// machine-specific settings removed. The omitted secrets that were set are
+// returned by key so callers can report them; values that are not strings,
+// such as headers, are returned empty.
+func (s *Service) ExportConfig() (*types.SharedConfig, map[string]string, error) {
+	config, err := s.GetConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings, err := configToMap(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	omitted := make(map[string]string)
+	for key, raw := range settings {
+		switch {
+		case secretKeys[key]:
+			if !isEmptyJSON(raw) {
+				omitted[key] = unquote(raw)
+			}
+			delete(settings, key)
+		case localKeys[key] || isEmptyJSON(raw):
+			delete(settings, key)
+		case pathKeys[key]:
+			settings[key] = mustMarshal(collapseHome(unquote(raw)))
+		}
+	}
+
+	// Remove secrets nested inside objects such as azure.api_key
+	for secret := range secretKeys {
+		if raw, ok := removeNested(settings, secret); ok && !isEmptyJSON(raw) {
+			omitted[secret] = unquote(raw)
+		}
+	}
+
+	// Make nested paths such as brand.reference_logo home-relative
+	for key := range pathKeys {
+		updateNested(settings, key, func(raw json.RawMessage) json.RawMessage {
+			return mustMarshal(collapseHome(unquote(raw)))
+		})
+	}
+
+	return &types.SharedConfig{
+		Version:    SharedConfigVersion,
+		ExportedAt: time.Now().Format(time.RFC3339),
+		Settings:   settings,
+	}, omitted, nil
+}
+
+// ImportConfig merges a shared config into the local configuration. Settings
+// missing locally are added; settings that differ are reported as conflicts
+// and only replaced when overwrite is true. Endpoint and trust settings that
+// would change are reported as untrusted and left alone unless trust is true.
+// With dryRun nothing is written.
+func (s *Service) ImportConfig(shared *types.SharedConfig, overwrite, trust, dryRun bool) ([]ImportChange, error) {
+	if shared.Version > SharedConfigVersion {
+		return nil, fmt.Errorf("unsupported shared config version %d (max %d)", shared.Version, SharedConfigVersion)
+	}
+
+	known := configKeys()
+	var changes []ImportChange
+
+	// Expand nested paths such as brand.reference_logo without touching the
+	// caller's settings
+	settings := make(map[string]json.RawMessage, len(shared.Settings))
+	for key, raw := range shared.Settings {
+		settings[key] = raw
+	}
+	for key := range pathKeys {
+		updateNested(settings, key, func(raw json.RawMessage) json.RawMessage {
+			return mustMarshal(expandHome(unquote(raw)))
+		})
+	}
+
+	apply := func(config *types.Config) error {
+		changes = nil
+
+		local, err := configToMap(config)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range sortedKeys(settings) {
+			incoming := settings[key]
+			if secretKeys[key] || localKeys[key] || !known[key] {
+				changes = append(changes, ImportChange{Key: key, Status: ImportSkipped})
+				continue
+			}
+			if pathKeys[key] {
+				incoming = mustMarshal(expandHome(unquote(incoming)))
+			}
+
+			merged, keyChanges := mergeValue(key, local[key], incoming, overwrite, trust)
+			local[key] = merged
+			changes = append(changes, keyChanges...)
+		}
+
+		merged, err := mapToConfig(local)
+		if err != nil {
+			return err
+		}
+		*config = *merged
+		return nil
+	}
+
+	if dryRun {
+		config, err := s.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		if err := apply(config); err != nil {
+			return nil, err
+		}
+		return changes, nil
+	}
+
+	if err := s.modifyConfig(apply); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ReadSharedConfig loads a shared config file written by ExportConfig
+func ReadSharedConfig(path string) (*types.SharedConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared config: %w", err)
+	}
+
+	shared := &types.SharedConfig{}
+	if err := json.Unmarshal(data, shared); err != nil {
+		return nil, fmt.Errorf("failed to parse shared config: %w", err)
+	}
+	return shared, nil
+}
+
+// mergeValue merges one incoming setting into the local value. Objects are
+// merged entry by entry so shared collections extend local ones.
+func mergeValue(key string, local, incoming json.RawMessage, overwrite, trust bool) (json.RawMessage, []ImportChange) {
+	if secretKeys[key] {
+		return local, []ImportChange{{Key: key, Status: ImportSkipped}}
+	}
+	if trustKeys[key] && !trust && !jsonEqual(local, incoming) {
+		return local, []ImportChange{{Key: key, Status: ImportUntrusted, Local: string(local), Incoming: string(incoming)}}
+	}
+	var incomingObj map[string]json.RawMessage
+	isObject := json.Unmarshal(incoming, &incomingObj) == nil && incomingObj != nil
+
+	// New objects are still merged entry by entry, so secrets nested in them
+	// are skipped
+	if isEmptyJSON(local) && !isObject {
+		return incoming, []ImportChange{{Key: key, Status: ImportAdded, Incoming: string(incoming)}}
+	}
+	if jsonEqual(local, incoming) {
+		return local, []ImportChange{{Key: key, Status: ImportUnchanged}}
+	}
+
+	var localObj map[string]json.RawMessage
+	if isEmptyJSON(local) {
+		localObj = make(map[string]json.RawMessage)
+	} else if json.Unmarshal(local, &localObj) != nil {
+		localObj = nil
+	}
+	if isObject && localObj != nil {
+		var changes []ImportChange
+		for _, sub := range sortedKeys(incomingObj) {
+			merged, subChanges := mergeValue(key+"."+sub, localObj[sub], incomingObj[sub], overwrite, trust)
+			if merged != nil {
+				localObj[sub] = merged
+			}
+			changes = append(changes, subChanges...)
+		}
+		return mustMarshal(localObj), changes
+	}
+
+	change := ImportChange{Key: key, Local: string(local), Incoming: string(incoming)}
+	if overwrite {
+		change.Status = ImportOverwritten
+		return incoming, []ImportChange{change}
+	}
+	change.Status = ImportConflict
+	return local, []ImportChange{change}
+}
+
+// configToMap converts a config into its JSON fields
+func configToMap(config *types.Config) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to convert config: %w", err)
+	}
+	return fields, nil
+}
+
+// mapToConfig converts JSON fields back into a config
+func mapToConfig(fields map[string]json.RawMessage) (*types.Config, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	config := &types.Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to convert config: %w", err)
+	}
+	return config, nil
+}
+
+// configKeys returns the JSON keys of all config fields
+func configKeys() map[string]bool {
+	keys := make(map[string]bool)
+	configType := reflect.TypeOf(types.Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		name := strings.Split(configType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// isEmptyJSON reports whether a raw JSON value is missing, null or an empty
+// string. Explicit false and 0 are values like any other.
+func isEmptyJSON(raw json.RawMessage) bool {
+	switch strings.TrimSpace(string(raw)) {
+	case "", "null", `""`:
+		return true
+	}
+	return false
+}
+
+// jsonEqual compares two raw JSON values ignoring formatting
+func jsonEqual(a, b json.RawMessage) bool {
+	var bufA, bufB bytes.Buffer
+	if json.Compact(&bufA, a) != nil || json.Compact(&bufB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
+// collapseHome rewrites paths inside the home directory as ~/...
+func collapseHome(path string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || path == "" {
+		return path
+	}
+	rel, err := filepath.Rel(homeDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(filepath.Join("~", rel))
+}
+
+// removeNested deletes a dotted parent.child key from settings and returns
+// the removed value
+func removeNested(settings map[string]json.RawMessage, key string) (json.RawMessage, bool) {
+	var removed json.RawMessage
+	found := false
+	updateNested(settings, key, func(raw json.RawMessage) json.RawMessage {
+		removed, found = raw, true
+		return nil
+	})
+	return removed, found
+}
+
+// updateNested replaces the value of a dotted parent.child key in settings;
+// returning nil from update deletes the key
+func updateNested(settings map[string]json.RawMessage, key string, update func(json.RawMessage) json.RawMessage) {
+	parent, child, nested := strings.Cut(key, ".")
+	if !nested || settings[parent] == nil {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(settings[parent], &fields) != nil || fields == nil {
+		return
+	}
+	raw, ok := fields[child]
+	if !ok {
+		return
+	}
+
+	if value := update(raw); value != nil {
+		fields[child] = value
+	} else {
+		delete(fields, child)
+	}
+	settings[parent] = mustMarshal(fields)
+}
+
+// expandHome expands a leading ~ to the home directory
+func expandHome(path string) string {
+	return utils.ExpandHome(path)
+}
+
+func unquote(raw json.RawMessage) string {
+	var value string
+	json.Unmarshal(raw, &value)
+	return value
+}
+
+func mustMarshal(value interface{}) json.RawMessage {
+	data, _ := json.Marshal(value)
+	return data
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,306 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestExportConfigOmitsSecrets(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{
+		OpenAIAPIKey: "sk-secret1234567890",
+		BaseURL:      "https://gateway.example.com",
+		Language:     "zh",
+		Initialized:  true,
+	}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared, omitted, err := service.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig() failed: %v", err)
+	}
+
+	data, _ := json.Marshal(shared)
+	if strings.Contains(string(data), "sk-secret1234567890") {
+		t.Errorf("exported config contains the API key: %s", data)
+	}
+	if omitted["openai_api_key"] != "sk-secret1234567890" {
+		t.Errorf("expected API key to be reported as omitted, got %v", omitted)
+	}
+	if _, ok := shared.Settings["initialized"]; ok {
+		t.Errorf("exported config should not contain machine-specific settings")
+	}
+	if string(shared.Settings["base_url"]) != `"https://gateway.example.com"` {
+		t.Errorf("unexpected base_url: %s", shared.Settings["base_url"])
+	}
+}
+
+func TestImportConfigMerge(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{
+		OpenAIAPIKey: "sk-local",
+		Language:     "en",
+	}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared := &types.SharedConfig{
+		Version: SharedConfigVersion,
+		Settings: map[string]json.RawMessage{
+			"base_url":       json.RawMessage(`"https://gateway.example.com"`),
+			"language":       json.RawMessage(`"zh"`),
+			"openai_api_key": json.RawMessage(`"sk-incoming"`),
+			"unknown_key":    json.RawMessage(`true`),
+		},
+	}
+
+	changes, err := service.ImportConfig(shared, false, true, false)
+	if err != nil {
+		t.Fatalf("ImportConfig() failed: %v", err)
+	}
+
+	statuses := make(map[string]string)
+	for _, change := range changes {
+		statuses[change.Key] = change.Status
+	}
+	expected := map[string]string{
+		"base_url":       ImportAdded,
+		"language":       ImportConflict,
+		"openai_api_key": ImportSkipped,
+		"unknown_key":    ImportSkipped,
+	}
+	for key, status := range expected {
+		if statuses[key] != status {
+			t.Errorf("%s: expected status %s, got %s", key, status, statuses[key])
+		}
+	}
+
+	config, err := service.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() failed: %v", err)
+	}
+	if config.BaseURL != "https://gateway.example.com" {
+		t.Errorf("base_url not imported, got %s", config.BaseURL)
+	}
+	if config.Language != "en" {
+		t.Errorf("conflicting language should be kept without overwrite, got %s", config.Language)
+	}
+	if config.OpenAIAPIKey != "sk-local" {
+		t.Errorf("API key must never be imported, got %s", config.OpenAIAPIKey)
+	}
+
+	// Overwrite replaces conflicting values
+	if _, err := service.ImportConfig(shared, true, true, false); err != nil {
+		t.Fatalf("ImportConfig() failed: %v", err)
+	}
+	config, _ = service.GetConfig()
+	if config.Language != "zh" {
+		t.Errorf("expected language to be overwritten, got %s", config.Language)
+	}
+}
+
+func TestImportConfigDryRun(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+
+	shared := &types.SharedConfig{
+		Version:  SharedConfigVersion,
+		Settings: map[string]json.RawMessage{"base_url": json.RawMessage(`"https://gateway.example.com"`)},
+	}
+
+	changes, err := service.ImportConfig(shared, false, true, true)
+	if err != nil {
+		t.Fatalf("ImportConfig() failed: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Status != ImportAdded {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+
+	config, _ := service.GetConfig()
+	if config.BaseURL != "" {
+		t.Errorf("dry run must not write the config, got base_url %s", config.BaseURL)
+	}
+}
+
+func TestExportConfigOmitsNestedSecrets(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{
+		Provider: types.ProviderAzure,
+		Azure: &types.AzureConfig{
+			Endpoint:    "https://my-resource.openai.azure.com",
+			APIKey:      "azure-secret-key",
+			Deployments: map[string]string{"icons": types.ModelGPTImage1},
+		},
+	}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared, omitted, err := service.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig() failed: %v", err)
+	}
+
+	data, _ := json.Marshal(shared)
+	if strings.Contains(string(data), "azure-secret-key") {
+		t.Errorf("exported config contains the Azure key: %s", data)
+	}
+	if omitted["azure.api_key"] != "azure-secret-key" {
+		t.Errorf("expected Azure key to be reported as omitted, got %v", omitted)
+	}
+	if !strings.Contains(string(shared.Settings["azure"]), "my-resource.openai.azure.com") {
+		t.Errorf("expected Azure endpoint to be exported, got %s", shared.Settings["azure"])
+	}
+}
+
+func TestImportConfigSkipsNestedSecrets(t *testing.T) {
+	incoming := json.RawMessage(`{"endpoint":"https://team.openai.azure.com","api_key":"STOLEN","token_command":"touch /tmp/pwned"}`)
+	for _, local := range []*types.AzureConfig{nil, {Endpoint: "https://mine.openai.azure.com"}} {
+		service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+		if err := service.SetConfig(&types.Config{Azure: local}); err != nil {
+			t.Fatalf("SetConfig() failed: %v", err)
+		}
+
+		shared := &types.SharedConfig{Version: SharedConfigVersion, Settings: map[string]json.RawMessage{"azure": incoming}}
+		changes, err := service.ImportConfig(shared, true, true, false)
+		if err != nil {
+			t.Fatalf("ImportConfig() failed: %v", err)
+		}
+		statuses := make(map[string]string)
+		for _, change := range changes {
+			statuses[change.Key] = change.Status
+		}
+		if statuses["azure.api_key"] != ImportSkipped || statuses["azure.token_command"] != ImportSkipped {
+			t.Errorf("local %+v: expected nested secrets to be skipped, got %+v", local, changes)
+		}
+
+		config, _ := service.GetConfig()
+		if config.Azure == nil || config.Azure.Endpoint != "https://team.openai.azure.com" {
+			t.Errorf("local %+v: expected the endpoint to be imported, got %+v", local, config.Azure)
+		}
+		if config.Azure != nil && (config.Azure.APIKey != "" || config.Azure.TokenCommand != "") {
+			t.Errorf("local %+v: secrets were imported: %+v", local, config.Azure)
+		}
+	}
+}
+
+func TestExportConfigCollapsesNestedPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{
+		Brand: &types.BrandKit{ReferenceLogo: filepath.Join(home, "brand", "logo.png")},
+	}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared, _, err := service.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig() failed: %v", err)
+	}
+	if !strings.Contains(string(shared.Settings["brand"]), `"~/brand/logo.png"`) {
+		t.Errorf("expected home-relative logo path, got %s", shared.Settings["brand"])
+	}
+}
+
+func TestShareHTTPSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{HTTP: &types.HTTPConfig{
+		ProxyURL: "http://proxy:8080",
+		CAFile:   filepath.Join(home, "certs", "ca.pem"),
+		Headers:  map[string]string{"X-Gateway-Key": "secret"},
+	}}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared, omitted, err := service.ExportConfig()
+	if err != nil {
+		t.Fatalf("ExportConfig() failed: %v", err)
+	}
+	exported := string(shared.Settings["http"])
+	if strings.Contains(exported, "X-Gateway-Key") || !strings.Contains(exported, `"~/certs/ca.pem"`) {
+		t.Errorf("expected headers omitted and a home-relative CA path, got %s", exported)
+	}
+	if _, ok := omitted["http.headers"]; !ok {
+		t.Errorf("expected http.headers to be reported as omitted, got %v", omitted)
+	}
+
+	// Headers in a shared file are ignored and paths are expanded on import
+	target := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	shared.Settings["http"] = json.RawMessage(`{"ca_file":"~/certs/ca.pem","headers":{"X-Gateway-Key":"stolen"}}`)
+	if _, err := target.ImportConfig(shared, false, true, false); err != nil {
+		t.Fatalf("ImportConfig() failed: %v", err)
+	}
+	config, _ := target.GetConfig()
+	if config.HTTP == nil || config.HTTP.CAFile != filepath.Join(home, "certs", "ca.pem") || len(config.HTTP.Headers) != 0 {
+		t.Errorf("imported http settings = %+v", config.HTTP)
+	}
+	if string(shared.Settings["http"]) != `{"ca_file":"~/certs/ca.pem","headers":{"X-Gateway-Key":"stolen"}}` {
+		t.Errorf("ImportConfig() changed the shared settings: %s", shared.Settings["http"])
+	}
+}
+
+func TestImportConfigRequiresTrustForEndpoints(t *testing.T) {
+	service := &Service{configPath: filepath.Join(t.TempDir(), "config.json")}
+	if err := service.SetConfig(&types.Config{
+		HTTP: &types.HTTPConfig{ProxyURL: "http://proxy:8080"},
+	}); err != nil {
+		t.Fatalf("SetConfig() failed: %v", err)
+	}
+
+	shared := &types.SharedConfig{
+		Version: SharedConfigVersion,
+		Settings: map[string]json.RawMessage{
+			"base_url": json.RawMessage(`"https://evil.example.com"`),
+			"azure":    json.RawMessage(`{"endpoint":"https://evil.openai.azure.com","api_version":"2025-04-01-preview"}`),
+			"http":     json.RawMessage(`{"proxy_url":"http://proxy:8080","ca_file":"/tmp/evil.pem","user_agent":"team"}`),
+		},
+	}
+
+	changes, err := service.ImportConfig(shared, true, false, false)
+	if err != nil {
+		t.Fatalf("ImportConfig() failed: %v", err)
+	}
+	statuses := make(map[string]string)
+	for _, change := range changes {
+		statuses[change.Key] = change.Status
+	}
+	expected := map[string]string{
+		"base_url":          ImportUntrusted,
+		"azure.endpoint":    ImportUntrusted,
+		"azure.api_version": ImportAdded,
+		"http.proxy_url":    ImportUnchanged,
+		"http.ca_file":      ImportUntrusted,
+		"http.user_agent":   ImportAdded,
+	}
+	for key, status := range expected {
+		if statuses[key] != status {
+			t.Errorf("%s: expected status %s, got %s", key, status, statuses[key])
+		}
+	}
+
+	config, _ := service.GetConfig()
+	if config.BaseURL != "" || config.Azure.Endpoint != "" || config.HTTP.CAFile != "" {
+		t.Errorf("untrusted settings were imported: %+v %+v %+v", config.BaseURL, config.Azure, config.HTTP)
+	}
+	if config.Azure.APIVersion != "2025-04-01-preview" || config.HTTP.UserAgent != "team" {
+		t.Errorf("other settings were not imported: %+v %+v", config.Azure, config.HTTP)
+	}
+}
+
+func TestMergeValueKeepsExplicitFalse(t *testing.T) {
+	merged, changes := mergeValue("output.prompt_subdirs", json.RawMessage(`false`), json.RawMessage(`true`), false, false)
+	if string(merged) != "false" || len(changes) != 1 || changes[0].Status != ImportConflict {
+		t.Errorf("expected an explicit false to conflict, got %s %+v", merged, changes)
+	}
+	if isEmptyJSON(json.RawMessage(`0`)) || isEmptyJSON(json.RawMessage(`false`)) {
+		t.Error("false and 0 must not count as empty")
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+)
+
+// ErrTemplateNotFound is returned when removing a template that is not in the config
+var ErrTemplateNotFound = errors.New("template not found")
+
+// SaveTemplate adds or replaces a custom prompt template
+func (s *Service) SaveTemplate(name string, template *types.PromptTemplate) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		if config.Templates == nil {
+			config.Templates = make(map[string]*types.PromptTemplate)
+		}
+		config.Templates[name] = template
+		return nil
+	})
+}
+
+// RemoveTemplate deletes a custom prompt template. If it was the default and
+// does not shadow a built-in, the default falls back to the built-in one.
+func (s *Service) RemoveTemplate(name string) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		if _, ok := config.Templates[name]; !ok {
+			return ErrTemplateNotFound
+		}
+		delete(config.Templates, name)
+		if len(config.Templates) == 0 {
+			config.Templates = nil
+		}
+		if config.DefaultTemplate == name && !templates.IsBuiltIn(name) {
+			config.DefaultTemplate = ""
+		}
+		return nil
+	})
+}
+
+// SetDefaultTemplate sets the template used when none is selected
+func (s *Service) SetDefaultTemplate(name string) error {
+	return s.modifyConfig(func(config *types.Config) error {
+		config.DefaultTemplate = name
+		return nil
+	})
+}
@@ -0,0 +1,417 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/httpclient"
+	"just-icon/internal/openai"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Check names, also used as i18n key suffixes by the CLI
+const (
+	CheckConfig    = "config"
+	CheckAPIKey    = "api_key"
+	CheckBaseURL   = "base_url"
+	CheckHTTP      = "http"
+	CheckOutputDir = "output_dir"
+	CheckDNS       = "dns"
+	CheckTLS       = "tls"
+	CheckAuth      = "auth"
+	CheckModels    = "models"
+)
+
+// Hint keys describing how to fix a failed check
+const (
+	HintConfig          = "doctor_hint_config"
+	HintAPIKey          = "doctor_hint_api_key"
+	HintBaseURL         = "doctor_hint_base_url"
+	HintBaseURLVersion  = "doctor_hint_base_url_version"
+	HintHTTP            = "doctor_hint_http"
+	HintOutputDir       = "doctor_hint_output_dir"
+	HintDNS             = "doctor_hint_dns"
+	HintTLS             = "doctor_hint_tls"
+	HintTLSExpiry       = "doctor_hint_tls_expiry"
+	HintPlainHTTP       = "doctor_hint_plain_http"
+	HintAuthKey         = "doctor_hint_auth_key"
+	HintAuthEndpoint    = "doctor_hint_auth_endpoint"
+	HintAuthNetwork     = "doctor_hint_auth_network"
+	HintModelsMissing   = "doctor_hint_models_missing"
+	HintAzureCredential = "doctor_hint_azure_credential"
+	HintAzureDeployment = "doctor_hint_azure_deployment"
+)
+
+// checkTimeout bounds every network check
+const checkTimeout = 10 * time.Second
+
+// certExpiryWarning is how close to expiry a certificate triggers a warning
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// Result is the outcome of one diagnostic check
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Hint   string
+}
+
+// Doctor runs diagnostics against the local configuration and the API
+type Doctor struct {
+	configService *config.Service
+	newClient     func(cfg *types.Config, credential string, httpClient *http.Client) *openai.Client
+}
+
+// New creates a doctor for the given configuration service
+func New(configService *config.Service) *Doctor {
+	return &Doctor{
+		configService: configService,
+		newClient:     newClient,
+	}
+}
+
+// Run executes all checks in order. Checks that depend on an earlier failed
+// check are reported as skipped.
+func (d *Doctor) Run(ctx context.Context) []Result {
+	var results []Result
+
+	cfg, err := d.configService.GetConfig()
+	if err != nil {
+		results = append(results, Result{Name: CheckConfig, Status: StatusFail, Detail: err.Error(), Hint: HintConfig})
+		return append(results, skipped(CheckAPIKey, CheckBaseURL, CheckHTTP, CheckOutputDir, CheckDNS, CheckTLS, CheckAuth, CheckModels)...)
+	}
+	results = append(results, Result{Name: CheckConfig, Status: StatusPass, Detail: d.configService.GetConfigPath()})
+
+	azure := cfg.Provider == types.ProviderAzure
+
+	var apiKeyResult Result
+	var credential string
+	baseURL := openai.APIURL(cfg)
+	if azure {
+		apiKeyResult, credential = checkAzureCredential(ctx, cfg.Azure)
+	} else {
+		apiKeyResult, credential = checkAPIKey(cfg), cfg.OpenAIAPIKey
+	}
+	results = append(results, apiKeyResult)
+
+	baseURLResult := checkBaseURL(baseURL, !azure)
+	results = append(results, baseURLResult)
+
+	httpResult, httpClient, transport := checkHTTP(cfg.HTTP, baseURL)
+	results = append(results, httpResult)
+
+	outputDir := cfg.DefaultOutputPath
+	if outputDir == "" {
+		outputDir = types.DefaultValues.OutputPath
+	}
+	results = append(results, checkOutputDir(utils.ExpandHome(outputDir)))
+
+	if baseURLResult.Status == StatusFail || httpResult.Status == StatusFail {
+		return append(results, skipped(CheckDNS, CheckTLS, CheckAuth, CheckModels)...)
+	}
+
+	parsed, _ := url.Parse(baseURL)
+	dnsResult := checkDNS(ctx, dnsHost(transport, parsed))
+	results = append(results, dnsResult)
+	if dnsResult.Status == StatusFail {
+		return append(results, skipped(CheckTLS, CheckAuth, CheckModels)...)
+	}
+
+	tlsResult := checkTLS(ctx, httpClient, parsed)
+	results = append(results, tlsResult)
+	if tlsResult.Status == StatusFail || apiKeyResult.Status == StatusFail {
+		return append(results, skipped(CheckAuth, CheckModels)...)
+	}
+
+	client := d.newClient(cfg, credential, httpClient)
+	authResult, models := checkAuth(ctx, client)
+	results = append(results, authResult)
+	if authResult.Status == StatusFail {
+		return append(results, skipped(CheckModels)...)
+	}
+
+	if azure {
+		return append(results, checkAzureDeployments(cfg.Azure))
+	}
+	return append(results, checkModels(models))
+}
+
+// newClient creates an API client for the configured provider
+func newClient(cfg *types.Config, credential string, httpClient *http.Client) *openai.Client {
+	if cfg.Provider == types.ProviderAzure {
+		return openai.NewAzureClient(cfg.Azure, credential, httpClient)
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = types.DefaultValues.BaseURL
+	}
+	return openai.NewClientWithHTTPClient(credential, baseURL, httpClient)
+}
+
+// HasFailures reports whether any check failed
+func HasFailures(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+func skipped(names ...string) []Result {
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		results = append(results, Result{Name: name, Status: StatusSkip})
+	}
+	return results
+}
+
+func checkAPIKey(cfg *types.Config) Result {
+	if err := config.ValidateAPIKey(cfg.OpenAIAPIKey); err != nil {
+		return Result{Name: CheckAPIKey, Status: StatusFail, Detail: err.Error(), Hint: HintAPIKey}
+	}
+	return Result{Name: CheckAPIKey, Status: StatusPass, Detail: utils.MaskAPIKey(cfg.OpenAIAPIKey)}
+}
+
+func checkBaseURL(baseURL string, appendsVersion bool) Result {
+	if err := config.ValidateBaseURL(baseURL); err != nil {
+		return Result{Name: CheckBaseURL, Status: StatusFail, Detail: fmt.Sprintf("%s: %v", baseURL, err), Hint: HintBaseURL}
+	}
+
+	// The API version is appended by the client, so including it doubles it
+	if appendsVersion && strings.HasSuffix(strings.TrimRight(baseURL, "/"), types.APIVersion) {
+		return Result{Name: CheckBaseURL, Status: StatusWarn, Detail: baseURL, Hint: HintBaseURLVersion}
+	}
+
+	return Result{Name: CheckBaseURL, Status: StatusPass, Detail: baseURL}
+}
+
+func checkAzureCredential(ctx context.Context, azure *types.AzureConfig) (Result, string) {
+	if azure == nil {
+		azure = &types.AzureConfig{}
+	}
+
+	credential, err := openai.AzureCredential(ctx, azure)
+	if err != nil {
+		return Result{Name: CheckAPIKey, Status: StatusFail, Detail: err.Error(), Hint: HintAzureCredential}, ""
+	}
+
+	mode := "api-key"
+	if azure.Auth == types.AzureAuthAAD {
+		mode = "Entra ID token"
+	}
+	return Result{Name: CheckAPIKey, Status: StatusPass, Detail: fmt.Sprintf("%s %s", mode, utils.MaskAPIKey(credential))}, credential
+}
+
+func checkAzureDeployments(azure *types.AzureConfig) Result {
+	if err := openai.ValidateAzureConfig(azure); err != nil {
+		return Result{Name: CheckModels, Status: StatusFail, Detail: err.Error(), Hint: HintAzureDeployment}
+	}
+
+	var details []string
+	for name, model := range azure.Deployments {
+		details = append(details, name+" → "+model)
+	}
+	sort.Strings(details)
+	return Result{Name: CheckModels, Status: StatusPass, Detail: strings.Join(details, ", ")}
+}
+
+func checkHTTP(httpConfig *types.HTTPConfig, baseURL string) (Result, *http.Client, *http.Transport) {
+	transport, err := httpclient.NewTransport(httpConfig)
+	if err != nil {
+		return Result{Name: CheckHTTP, Status: StatusFail, Detail: err.Error(), Hint: HintHTTP}, nil, nil
+	}
+	httpClient, err := httpclient.New(httpConfig, baseURL)
+	if err != nil {
+		return Result{Name: CheckHTTP, Status: StatusFail, Detail: err.Error(), Hint: HintHTTP}, nil, nil
+	}
+
+	var details []string
+	if httpConfig != nil && httpConfig.ProxyURL != "" {
+		details = append(details, "proxy "+redactURL(httpConfig.ProxyURL))
+	}
+	if httpConfig != nil && httpConfig.CAFile != "" {
+		details = append(details, "CA "+httpConfig.CAFile)
+	}
+	if httpConfig != nil && httpConfig.ClientCertFile != "" {
+		details = append(details, "client certificate")
+	}
+	if httpConfig != nil && len(httpConfig.Headers) > 0 {
+		details = append(details, fmt.Sprintf("%d extra header(s)", len(httpConfig.Headers)))
+	}
+	if len(details) == 0 {
+		details = append(details, "defaults")
+	}
+
+	return Result{Name: CheckHTTP, Status: StatusPass, Detail: strings.Join(details, ", ")}, httpClient, transport
+}
+
+// dnsHost returns the host that must resolve locally: the proxy when one
+// applies to the base URL, otherwise the API host itself
+func dnsHost(transport *http.Transport, baseURL *url.URL) string {
+	if transport.Proxy != nil {
+		proxyURL, err := transport.Proxy(&http.Request{URL: baseURL})
+		if err == nil && proxyURL != nil {
+			return proxyURL.Hostname()
+		}
+	}
+	return baseURL.Hostname()
+}
+
+// redactURL removes the user name and password from a URL for display
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	u.User = nil
+	return u.String()
+}
+
+// checkOutputDir checks that images can be saved in outputDir without
+// creating it: a missing directory is reported, and the nearest existing
+// parent must be writable for it to be created on the first generation
+func checkOutputDir(outputDir string) Result {
+	dir := filepath.Clean(outputDir)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return Result{Name: CheckOutputDir, Status: StatusFail, Detail: dir + " is not a directory", Hint: HintOutputDir}
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return Result{Name: CheckOutputDir, Status: StatusFail, Detail: err.Error(), Hint: HintOutputDir}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Result{Name: CheckOutputDir, Status: StatusFail, Detail: err.Error(), Hint: HintOutputDir}
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(dir, ".just-icon-doctor-*")
+	if err != nil {
+		return Result{Name: CheckOutputDir, Status: StatusFail, Detail: err.Error(), Hint: HintOutputDir}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	if dir != filepath.Clean(outputDir) {
+		return Result{Name: CheckOutputDir, Status: StatusWarn, Detail: outputDir + " does not exist yet; it will be created on the first generation"}
+	}
+	return Result{Name: CheckOutputDir, Status: StatusPass, Detail: outputDir}
+}
+
+func checkDNS(ctx context.Context, host string) Result {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return Result{Name: CheckDNS, Status: StatusFail, Detail: err.Error(), Hint: HintDNS}
+	}
+	return Result{Name: CheckDNS, Status: StatusPass, Detail: fmt.Sprintf("%s → %s", host, strings.Join(addrs, ", "))}
+}
+
+func checkTLS(ctx context.Context, httpClient *http.Client, baseURL *url.URL) Result {
+	if baseURL.Scheme != "https" {
+		return Result{Name: CheckTLS, Status: StatusWarn, Detail: baseURL.Host, Hint: HintPlainHTTP}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	// Go through the configured client so the proxy, CA bundle and client
+	// certificate take part in the handshake
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL.String(), nil)
+	if err != nil {
+		return Result{Name: CheckTLS, Status: StatusFail, Detail: err.Error(), Hint: HintTLS}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return Result{Name: CheckTLS, Status: StatusFail, Detail: err.Error(), Hint: HintTLS}
+	}
+	resp.Body.Close()
+
+	state := resp.TLS
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return Result{Name: CheckTLS, Status: StatusPass, Detail: baseURL.Host}
+	}
+
+	expires := state.PeerCertificates[0].NotAfter
+	detail := fmt.Sprintf("%s, certificate valid until %s", tls.VersionName(state.Version), expires.Format("2006-01-02"))
+	if time.Until(expires) < certExpiryWarning {
+		return Result{Name: CheckTLS, Status: StatusWarn, Detail: detail, Hint: HintTLSExpiry}
+	}
+	return Result{Name: CheckTLS, Status: StatusPass, Detail: detail}
+}
+
+func checkAuth(ctx context.Context, client *openai.Client) (Result, []string) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		result := Result{Name: CheckAuth, Status: StatusFail, Detail: err.Error()}
+		switch status := openai.StatusCode(err); {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			result.Hint = HintAuthKey
+		case status == http.StatusNotFound:
+			result.Hint = HintAuthEndpoint
+		case errors.Is(err, context.DeadlineExceeded) || status == 0:
+			result.Hint = HintAuthNetwork
+		default:
+			result.Hint = HintAuthEndpoint
+		}
+		return result, nil
+	}
+
+	detail := fmt.Sprintf("%d models, %s", len(models), time.Since(start).Round(time.Millisecond))
+	return Result{Name: CheckAuth, Status: StatusPass, Detail: detail}, models
+}
+
+func checkModels(available []string) Result {
+	availableSet := make(map[string]bool, len(available))
+	for _, model := range available {
+		availableSet[model] = true
+	}
+
+	var missing []string
+	for _, model := range types.SupportedModels {
+		if !availableSet[model] {
+			missing = append(missing, model)
+		}
+	}
+
+	// Some gateways don't list every model they serve, so this only warns
+	if len(missing) > 0 {
+		return Result{Name: CheckModels, Status: StatusWarn, Detail: strings.Join(missing, ", "), Hint: HintModelsMissing}
+	}
+	return Result{Name: CheckModels, Status: StatusPass, Detail: strings.Join(types.SupportedModels, ", ")}
+}
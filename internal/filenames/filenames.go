@@ -0,0 +1,172 @@
+package filenames
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultTemplate keeps the historical icon_<timestamp> naming
+	DefaultTemplate = "icon_{timestamp}.{ext}"
+	// maxSlugLength bounds the prompt slug in runes
+	maxSlugLength = 40
+	// hashLength is the number of hex characters in {hash}
+	hashLength = 8
+	// fallbackSlug is used when a prompt has no letters or digits
+	fallbackSlug = "icon"
+)
+
+// Placeholders lists the supported template placeholders
+var Placeholders = []string{"slug", "date", "time", "timestamp", "n", "size", "quality", "template", "hash", "ext"}
+
+// Vars are the values available to a filename template
+type Vars struct {
+	Prompt   string
+	Size     string
+	Quality  string
+	Template string
+	Ext      string
+	// Index is the 1-based position of the image in its generation
+	Index int
+	Time  time.Time
+}
+
+// Validate checks that a template only uses known placeholders and stays
+// inside the output directory
+func Validate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("filename template cannot be empty")
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("filename template cannot contain path separators")
+	}
+	_, err := expand(template, func(string) string { return "x" })
+	return err
+}
+
+// Render expands a template into a file name. Invalid templates fall back
+// to DefaultTemplate; the extension is appended when the template has no
+// {ext} placeholder.
+func Render(template string, vars Vars) string {
+	if Validate(template) != nil {
+		template = DefaultTemplate
+	}
+
+	values := map[string]string{
+		"slug":      Slug(vars.Prompt),
+		"date":      vars.Time.Format("20060102"),
+		"time":      vars.Time.Format("150405"),
+		"timestamp": vars.Time.Format("20060102150405"),
+		"n":         strconv.Itoa(vars.Index),
+		"size":      vars.Size,
+		"quality":   vars.Quality,
+		"template":  vars.Template,
+		"hash":      Hash(vars.Prompt),
+		"ext":       vars.Ext,
+	}
+	name, _ := expand(template, func(key string) string { return sanitize(values[key]) })
+	if !strings.Contains(template, "{ext}") && vars.Ext != "" {
+		name += "." + vars.Ext
+	}
+	return sanitize(name)
+}
+
+// Slug turns a prompt into a lowercase, hyphen-separated name of letters and
+// digits, at most maxSlugLength runes long
+func Slug(prompt string) string {
+	var b strings.Builder
+	count := 0
+	pendingHyphen := false
+	for _, r := range strings.ToLower(prompt) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingHyphen = count > 0
+			continue
+		}
+		if pendingHyphen {
+			if count+1 >= maxSlugLength {
+				break
+			}
+			b.WriteRune('-')
+			count++
+			pendingHyphen = false
+		}
+		if count >= maxSlugLength {
+			break
+		}
+		b.WriteRune(r)
+		count++
+	}
+	if b.Len() == 0 {
+		return fallbackSlug
+	}
+	return b.String()
+}
+
+// Hash returns a short stable hash of the prompt
+func Hash(prompt string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(prompt)))
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// expand replaces each {placeholder} with value(name), rejecting unknown
+// placeholders and unbalanced braces
+func expand(template string, value func(string) string) (string, error) {
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		if rest[start] == '}' {
+			return "", fmt.Errorf("unexpected '}' in filename template")
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed '{' in filename template")
+		}
+		name := rest[start+1 : start+end]
+		if !isPlaceholder(name) {
+			return "", fmt.Errorf("unknown placeholder {%s}; available: %s", name, placeholderList())
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(value(name))
+		rest = rest[start+end+1:]
+	}
+}
+
+// isPlaceholder reports whether name is a supported placeholder
+func isPlaceholder(name string) bool {
+	for _, placeholder := range Placeholders {
+		if name == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholderList formats the placeholders for error messages
+func placeholderList() string {
+	names := make([]string, len(Placeholders))
+	for i, name := range Placeholders {
+		names[i] = "{" + name + "}"
+	}
+	return strings.Join(names, ", ")
+}
+
+// sanitize replaces characters that are not allowed in file names on common
+// platforms
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,84 @@
+package filenames
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"just-icon/internal/types"
+)
+
+func TestRender(t *testing.T) {
+	vars := Vars{
+		Prompt:   "Glass-like Camera: aperture!",
+		Size:     types.SizeSmall,
+		Quality:  types.QualityHigh,
+		Template: "ios",
+		Ext:      "png",
+		Index:    2,
+		Time:     time.Date(2025, 7, 6, 22, 1, 23, 0, time.UTC),
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{slug}-{date}-{n}.{ext}", "glass-like-camera-aperture-20250706-2.png"},
+		{DefaultTemplate, "icon_20250706220123.png"},
+		{"{template}_{size}_{quality}_{time}", "ios_1024x1024_high_220123.png"},
+		{"{hash}.{ext}", Hash(vars.Prompt) + ".png"},
+		{"", "icon_20250706220123.png"},
+		{"{nope}", "icon_20250706220123.png"},
+	}
+	for _, tt := range tests {
+		if got := Render(tt.template, vars); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{"{slug}-{date}-{n}.{ext}", false},
+		{"plain", false},
+		{"", true},
+		{"{unknown}", true},
+		{"{slug", true},
+		{"slug}", true},
+		{"icons/{slug}", true},
+		{`..\{slug}`, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.template); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"Minimalist calculator app", "minimalist-calculator-app"},
+		{"  --neon//lens--  ", "neon-lens"},
+		{"玻璃质感 天气", "玻璃质感-天气"},
+		{"!!!", "icon"},
+		{strings.Repeat("a", 50), strings.Repeat("a", maxSlugLength)},
+		{strings.Repeat("abc ", 20), "abc-abc-abc-abc-abc-abc-abc-abc-abc-abc"},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.prompt); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.prompt, got, tt.want)
+		}
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	if Hash("rocket") != Hash(" rocket ") || Hash("rocket") == Hash("camera") || len(Hash("rocket")) != hashLength {
+		t.Errorf("unexpected hashes %q %q", Hash("rocket"), Hash("camera"))
+	}
+}
@@ -0,0 +1,439 @@
+package gallery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/jsonl"
+	"just-icon/internal/metadata"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// FileName is the gallery index inside the state directory
+const FileName = "gallery.jsonl"
+
+var (
+	ErrNotFound  = jsonl.ErrNotFound
+	ErrAmbiguous = jsonl.ErrAmbiguous
+)
+
+// imageExtensions are the file types scanned into the gallery
+var imageExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".webp": true}
+
+// Entry is one indexed image
+type Entry struct {
+	ID string `json:"id"`
+	// Path is the absolute path of the image
+	Path     string   `json:"path"`
+	Favorite bool     `json:"favorite,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// History links the image to the generation that produced it
+	History  string            `json:"history,omitempty"`
+	Metadata metadata.Metadata `json:"metadata"`
+}
+
+// HasTag reports whether the entry carries tag, ignoring case
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query filters gallery entries. Zero fields match everything.
+type Query struct {
+	// Text must appear, word by word, in one of the entry's prompts or its file name
+	Text string
+	// Since and Until bound the generation time; Until is exclusive
+	Since     time.Time
+	Until     time.Time
+	Preset    string
+	Tags      []string
+	Favorites bool
+}
+
+// IsEmpty reports whether the query matches every entry
+func (q Query) IsEmpty() bool {
+	return strings.TrimSpace(q.Text) == "" && q.Since.IsZero() && q.Until.IsZero() &&
+		q.Preset == "" && len(q.Tags) == 0 && !q.Favorites
+}
+
+// Match reports whether the entry satisfies every filter in the query
+func (q Query) Match(e Entry) bool {
+	if q.Favorites && !e.Favorite {
+		return false
+	}
+	if q.Preset != "" && !strings.EqualFold(q.Preset, e.Metadata.Preset) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Metadata.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Metadata.Timestamp.Before(q.Until) {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !e.HasTag(tag) {
+			return false
+		}
+	}
+
+	haystack := strings.ToLower(strings.Join([]string{
+		e.Metadata.Prompt,
+		e.Metadata.OriginalPrompt,
+		e.Metadata.RevisedPrompt,
+		filepath.Base(e.Path),
+	}, "\n"))
+	for _, word := range strings.Fields(strings.ToLower(q.Text)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store is a JSON lines index of generated images. Appends are cheap; edits
+// rewrite the whole file, which stays small even for thousands of images.
+type Store struct {
+	file *jsonl.File[Entry]
+}
+
+// NewStore creates a store backed by the index file at path
+func NewStore(path string) *Store {
+	return &Store{file: jsonl.New[Entry](path, "gallery index")}
+}
+
+// DefaultStore returns the store in the default config service's state directory
+func DefaultStore() *Store {
+	return NewStore(filepath.Join(config.DefaultService.StateDir(), FileName))
+}
+
+// Path returns the index file path
+func (s *Store) Path() string {
+	return s.file.Path()
+}
+
+// Add indexes images, filling in IDs and making paths absolute. Images that
+// are already indexed are skipped.
+func (s *Store) Add(entries ...Entry) ([]Entry, error) {
+	var added []Entry
+	err := s.file.Modify(func(existing []Entry) ([]Entry, error) {
+		indexed := make(map[string]bool, len(existing))
+		for _, entry := range existing {
+			indexed[entry.Path] = true
+		}
+		for _, entry := range entries {
+			entry.Path = absPath(entry.Path)
+			if indexed[entry.Path] {
+				continue
+			}
+			if entry.ID == "" {
+				id, err := jsonl.NewID()
+				if err != nil {
+					return nil, err
+				}
+				entry.ID = id
+			}
+			indexed[entry.Path] = true
+			added = append(added, entry)
+		}
+		return append(existing, added...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// List returns all entries, oldest first. Lines that fail to parse are
+// skipped so a torn write doesn't hide the rest of the gallery.
+func (s *Store) List() ([]Entry, error) {
+	return s.file.Read()
+}
+
+// Search returns up to limit entries matching the query, newest first; a
+// limit of zero or less returns all of them
+func (s *Store) Search(query Query, limit int) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var matches []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !query.Match(entries[i]) {
+			continue
+		}
+		matches = append(matches, entries[i])
+		if limit > 0 && len(matches) == limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// Find resolves a reference to an entry: an ID prefix or the path of an
+// indexed image
+func (s *Store) Find(ref string) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return find(entries, ref)
+}
+
+// SetFavorite marks or unmarks the entries with the given IDs
+func (s *Store) SetFavorite(ids []string, favorite bool) error {
+	return s.update(ids, func(entry *Entry) {
+		entry.Favorite = favorite
+	})
+}
+
+// Tag adds and removes tags on the entries with the given IDs
+func (s *Store) Tag(ids []string, add, remove []string) error {
+	return s.update(ids, func(entry *Entry) {
+		var tags []string
+		for _, tag := range entry.Tags {
+			if !containsFold(remove, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		for _, tag := range add {
+			tag = strings.TrimSpace(tag)
+			if tag != "" && !containsFold(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		sort.Strings(tags)
+		entry.Tags = tags
+	})
+}
+
+// Delete removes the images with the given IDs, their sidecars and their
+// index entries. The index is written first, so a failed write leaves every
+// image in place; images that then fail to be removed are indexed again.
+// Images that are already gone are still removed from the index. It returns
+// the entries that were deleted.
+func (s *Store) Delete(ids []string) ([]Entry, error) {
+	var removed []Entry
+	err := s.file.Modify(func(entries []Entry) ([]Entry, error) {
+		removed = nil
+		kept := entries[:0]
+		for _, entry := range entries {
+			if contains(ids, entry.ID) {
+				removed = append(removed, entry)
+			} else {
+				kept = append(kept, entry)
+			}
+		}
+		return kept, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted, failed []Entry
+	var firstErr error
+	for _, entry := range removed {
+		if err := removeImage(entry.Path); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed = append(failed, entry)
+			continue
+		}
+		deleted = append(deleted, entry)
+	}
+	if len(failed) > 0 {
+		if _, err := s.Add(failed...); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return deleted, firstErr
+}
+
+// Scan indexes the images with just-icon metadata under the given
+// directories and drops entries whose image no longer exists. It returns
+// the number of images added and entries removed.
+func (s *Store) Scan(dirs []string) (added, removed int, err error) {
+	var found []Entry
+	for _, dir := range dirs {
+		walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !imageExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			md, _, err := metadata.Read(path)
+			if err != nil {
+				return nil
+			}
+			found = append(found, Entry{Path: path, Metadata: *md})
+			return nil
+		})
+		if walkErr != nil {
+			return 0, 0, fmt.Errorf("failed to scan %s: %w", dir, walkErr)
+		}
+	}
+
+	err = s.file.Modify(func(entries []Entry) ([]Entry, error) {
+		kept := entries[:0]
+		indexed := make(map[string]bool, len(entries))
+		for _, entry := range entries {
+			if !utils.FileExists(entry.Path) {
+				removed++
+				continue
+			}
+			indexed[entry.Path] = true
+			kept = append(kept, entry)
+		}
+		for _, entry := range found {
+			entry.Path = absPath(entry.Path)
+			if indexed[entry.Path] {
+				continue
+			}
+			id, err := jsonl.NewID()
+			if err != nil {
+				return nil, err
+			}
+			entry.ID = id
+			indexed[entry.Path] = true
+			kept = append(kept, entry)
+			added++
+		}
+		// Keep the index in generation order after adding older images
+		sort.SliceStable(kept, func(i, j int) bool {
+			return kept[i].Metadata.Timestamp.Before(kept[j].Metadata.Timestamp)
+		})
+		return kept, nil
+	})
+	return added, removed, err
+}
+
+// Export copies the entries' images and sidecars into dir, never replacing
+// existing files. It returns the paths of the copied images.
+func Export(entries []Entry, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, types.ConfigDirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	var exported []string
+	for _, entry := range entries {
+		data, err := os.ReadFile(entry.Path)
+		if err != nil {
+			return exported, err
+		}
+		target, err := utils.WriteFileExclusive(filepath.Join(dir, filepath.Base(entry.Path)), data, 0644, func(path string) bool {
+			return utils.FileExists(metadata.SidecarPath(path))
+		})
+		if err != nil {
+			return exported, err
+		}
+		exported = append(exported, target)
+
+		sidecar, err := os.ReadFile(metadata.SidecarPath(entry.Path))
+		if err == nil {
+			err = os.WriteFile(metadata.SidecarPath(target), sidecar, 0644)
+		}
+		if err != nil && !os.IsNotExist(err) {
+			return exported, err
+		}
+	}
+	return exported, nil
+}
+
+// update applies fn to the entries with the given IDs
+func (s *Store) update(ids []string, fn func(*Entry)) error {
+	return s.file.Modify(func(entries []Entry) ([]Entry, error) {
+		for i := range entries {
+			if contains(ids, entries[i].ID) {
+				fn(&entries[i])
+			}
+		}
+		return entries, nil
+	})
+}
+
+// find matches ref against entry IDs by prefix, then against image paths
+func find(entries []Entry, ref string) (*Entry, error) {
+	i, err := jsonl.Find(entries, func(e Entry) string { return e.ID }, ref)
+	if err == nil {
+		return &entries[i], nil
+	}
+	if !errors.Is(err, ErrNotFound) || strings.TrimSpace(ref) == "" {
+		return nil, err
+	}
+
+	path := absPath(strings.TrimSpace(ref))
+	for i := range entries {
+		if entries[i].Path == path {
+			return &entries[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// removeImage deletes an image and its sidecar, ignoring files that are
+// already gone. A JSON file next to the image that was not written by
+// just-icon is left alone.
+func removeImage(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	sidecar := metadata.SidecarPath(path)
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var md metadata.Metadata
+	if json.Unmarshal(data, &md) != nil || md.Generator != metadata.Generator {
+		return nil
+	}
+	if err := os.Remove(sidecar); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// absPath returns the absolute, cleaned form of path
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// contains reports whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values holds value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
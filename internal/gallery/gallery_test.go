@@ -0,0 +1,307 @@
+package gallery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"just-icon/internal/jsonl"
+	"just-icon/internal/metadata"
+)
+
+func testEntry(path, prompt, preset string, at time.Time) Entry {
+	return Entry{
+		Path: path,
+		Metadata: metadata.Metadata{
+			Generator: metadata.Generator,
+			Version:   metadata.Version,
+			Prompt:    prompt,
+			Preset:    preset,
+			Timestamp: at,
+		},
+	}
+}
+
+// writeImage creates an image file and its sidecar
+func writeImage(t *testing.T, entry Entry) {
+	t.Helper()
+	if err := os.WriteFile(entry.Path, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := metadata.WriteSidecar(entry.Path, entry.Metadata); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddSkipsIndexedImages(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "state", FileName))
+	entry := testEntry(filepath.Join(dir, "rocket.png"), "a rocket", "", time.Now())
+
+	added, err := store.Add(entry, entry)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(added) != 1 || len(added[0].ID) != jsonl.IDLength {
+		t.Fatalf("Add() = %+v, want one entry with an ID", added)
+	}
+	if added, _ := store.Add(entry); len(added) != 0 {
+		t.Errorf("Add() of an indexed image = %+v, want nothing", added)
+	}
+
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("List() = %+v, %v; want one entry", entries, err)
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	day := time.Date(2025, 7, 6, 12, 0, 0, 0, time.UTC)
+	entry := testEntry("/out/glass-rocket.png", "A glass Rocket", "brand", day)
+	entry.Metadata.OriginalPrompt = "rocket"
+	entry.Favorite = true
+	entry.Tags = []string{"launch", "Space"}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{"empty", Query{}, true},
+		{"words in any order", Query{Text: "rocket GLASS"}, true},
+		{"file name", Query{Text: "glass-rocket.png"}, true},
+		{"missing word", Query{Text: "rocket camera"}, false},
+		{"preset", Query{Preset: "Brand"}, true},
+		{"other preset", Query{Preset: "mono"}, false},
+		{"tags", Query{Tags: []string{"space", "launch"}}, true},
+		{"missing tag", Query{Tags: []string{"space", "draft"}}, false},
+		{"favorites", Query{Favorites: true}, true},
+		{"since", Query{Since: day.Add(-time.Hour)}, true},
+		{"since after", Query{Since: day.Add(time.Hour)}, false},
+		{"until", Query{Until: day.Add(time.Hour)}, true},
+		{"until is exclusive", Query{Until: day}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.query.Match(entry); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	entry.Favorite = false
+	if (Query{Favorites: true}).Match(entry) {
+		t.Error("Match() should reject non-favorites when filtering favorites")
+	}
+}
+
+func TestSearchNewestFirst(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	now := time.Now()
+	for i, prompt := range []string{"red rocket", "blue camera", "blue rocket"} {
+		if _, err := store.Add(testEntry("/out/"+prompt, prompt, "", now.Add(time.Duration(i)*time.Minute))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := store.Search(Query{Text: "rocket"}, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Metadata.Prompt != "blue rocket" || entries[1].Metadata.Prompt != "red rocket" {
+		t.Errorf("Search() = %+v, want blue rocket then red rocket", entries)
+	}
+	if entries, _ := store.Search(Query{}, 1); len(entries) != 1 || entries[0].Metadata.Prompt != "blue rocket" {
+		t.Errorf("Search() with limit 1 = %+v", entries)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, FileName))
+	for _, entry := range []Entry{
+		{ID: "ab12cd34", Path: filepath.Join(dir, "one.png")},
+		{ID: "ab98ef76", Path: filepath.Join(dir, "two.png")},
+	} {
+		if _, err := store.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{"ab12", "ab12cd34", nil},
+		{filepath.Join(dir, "two.png"), "ab98ef76", nil},
+		{"ab", "", ErrAmbiguous},
+		{"zz", "", ErrNotFound},
+		{"", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		entry, err := store.Find(tt.ref)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Find(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || entry.ID != tt.want {
+			t.Errorf("Find(%q) = %+v, %v; want %s", tt.ref, entry, err, tt.want)
+		}
+	}
+}
+
+func TestFavoriteAndTag(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	added, err := store.Add(Entry{Path: "/out/one.png"}, Entry{Path: "/out/two.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := added[0].ID
+
+	if err := store.SetFavorite([]string{id}, true); err != nil {
+		t.Fatalf("SetFavorite() error = %v", err)
+	}
+	if err := store.Tag([]string{id}, []string{"space", "draft", "Space", " "}, nil); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+	if err := store.Tag([]string{id}, nil, []string{"DRAFT"}); err != nil {
+		t.Fatalf("Tag() error = %v", err)
+	}
+
+	entries, _ := store.List()
+	if !entries[0].Favorite || !reflect.DeepEqual(entries[0].Tags, []string{"space"}) {
+		t.Errorf("entry = %+v, want a favorite tagged space", entries[0])
+	}
+	if entries[1].Favorite || entries[1].Tags != nil {
+		t.Errorf("other entry changed: %+v", entries[1])
+	}
+}
+
+func TestDeleteRemovesImageAndSidecar(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, FileName))
+
+	ours := testEntry(filepath.Join(dir, "ours.png"), "ours", "", time.Now())
+	writeImage(t, ours)
+	// A JSON file that happens to share the image's name is not a sidecar
+	foreign := Entry{Path: filepath.Join(dir, "foreign.png")}
+	if err := os.WriteFile(foreign.Path, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(metadata.SidecarPath(foreign.Path), []byte(`{"name":"package"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added, err := store.Add(ours, foreign)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := store.Delete(galleryIDs(added))
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("Delete() = %+v, %v; want both entries", deleted, err)
+	}
+	for _, path := range []string{ours.Path, metadata.SidecarPath(ours.Path), foreign.Path} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s should be deleted", path)
+		}
+	}
+	if _, err := os.Stat(metadata.SidecarPath(foreign.Path)); err != nil {
+		t.Errorf("foreign JSON file should be kept: %v", err)
+	}
+	if entries, _ := store.List(); len(entries) != 0 {
+		t.Errorf("List() after Delete() = %+v, want empty", entries)
+	}
+}
+
+func TestDeleteKeepsImagesThatCannotBeRemoved(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, FileName))
+
+	// A non-empty directory in place of the image cannot be removed
+	stuck := Entry{Path: filepath.Join(dir, "stuck.png")}
+	if err := os.MkdirAll(filepath.Join(stuck.Path, "inside"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	gone := Entry{Path: filepath.Join(dir, "gone.png")}
+	added, err := store.Add(stuck, gone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := store.Delete(galleryIDs(added))
+	if err == nil || len(deleted) != 1 || deleted[0].Path != gone.Path {
+		t.Fatalf("Delete() = %+v, %v; want only the missing image deleted and an error", deleted, err)
+	}
+	entries, _ := store.List()
+	if len(entries) != 1 || entries[0].ID != added[0].ID {
+		t.Errorf("List() after Delete() = %+v, want the stuck image indexed under its ID", entries)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(filepath.Join(dir, "state", FileName))
+	now := time.Now()
+
+	gone := testEntry(filepath.Join(dir, "gone.png"), "gone", "", now)
+	kept := testEntry(filepath.Join(dir, "kept.png"), "kept", "", now)
+	writeImage(t, kept)
+	if _, err := store.Add(gone, kept); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	older := testEntry(filepath.Join(dir, "sub", "older.webp"), "older", "", now.Add(-time.Hour))
+	writeImage(t, older)
+	// Images without just-icon metadata are not indexed
+	if err := os.WriteFile(filepath.Join(dir, "other.png"), []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, removed, err := store.Scan([]string{dir})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if added != 1 || removed != 1 {
+		t.Errorf("Scan() = %d added, %d removed; want 1 and 1", added, removed)
+	}
+
+	entries, _ := store.List()
+	if len(entries) != 2 || entries[0].Metadata.Prompt != "older" || entries[1].Metadata.Prompt != "kept" {
+		t.Errorf("List() after Scan() = %+v, want older then kept", entries)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	entry := testEntry(filepath.Join(dir, "rocket.png"), "rocket", "", time.Now())
+	writeImage(t, entry)
+
+	target := filepath.Join(dir, "export")
+	for _, want := range []string{"rocket.png", "rocket-2.png"} {
+		exported, err := Export([]Entry{entry}, target)
+		if err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if len(exported) != 1 || exported[0] != filepath.Join(target, want) {
+			t.Fatalf("Export() = %v, want %s", exported, want)
+		}
+		if md, source, err := metadata.Read(exported[0]); err != nil || source != metadata.SourceSidecar || md.Prompt != "rocket" {
+			t.Errorf("exported sidecar = %+v, %s, %v", md, source, err)
+		}
+	}
+}
+
+func galleryIDs(entries []Entry) []string {
+	ids := make([]string, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	return ids
+}
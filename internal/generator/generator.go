@@ -0,0 +1,469 @@
+package generator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"just-icon/internal/brand"
+	"just-icon/internal/config"
+	"just-icon/internal/filenames"
+	"just-icon/internal/gallery"
+	"just-icon/internal/history"
+	"just-icon/internal/i18n"
+	"just-icon/internal/metadata"
+	"just-icon/internal/openai"
+	"just-icon/internal/presets"
+	"just-icon/internal/preview"
+	"just-icon/internal/progress"
+	"just-icon/internal/report"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// estimateSamples is how many recent runs EstimateDuration looks at
+const estimateSamples = 10
+
+// newClient creates the API client for Run; tests replace it to replay
+// recorded responses
+var newClient = openai.NewClientFromConfig
+
+// NewOptions returns the default generation options; the quality, quantity
+// and template are left for the user or a preset to choose
+func NewOptions() *types.IconGenerationOptions {
+	return &types.IconGenerationOptions{
+		Model:        types.ModelGPTImage1, // Fixed model
+		Size:         types.DefaultValues.Size,
+		Background:   types.DefaultValues.Background,
+		OutputFormat: types.DefaultValues.OutputFormat,
+		Moderation:   types.DefaultValues.Moderation,
+		RawPrompt:    false,
+	}
+}
+
+// SimilarOptions returns options for variations of the image at path: the
+// same settings with the image attached as a reference
+func SimilarOptions(base types.IconGenerationOptions, path string) *types.IconGenerationOptions {
+	options := base
+	options.Reference = path
+	return &options
+}
+
+// EditOptions returns options that change the image at path as the
+// instruction says. The instruction is sent as is, without a template, and
+// one image is generated.
+func EditOptions(base types.IconGenerationOptions, path, instruction string) *types.IconGenerationOptions {
+	options := base
+	options.Prompt = instruction
+	options.OriginalPrompt = ""
+	options.RawPrompt = true
+	options.NumImages = 1
+	options.Reference = path
+	return &options
+}
+
+// Settings are a caller's choices for a generation, as the API server and
+// the MCP tools receive them. Unset fields come from the preset, then from
+// the configured defaults.
+type Settings struct {
+	Prompt       string
+	Preset       string
+	Template     string
+	Size         string
+	Quality      string
+	Background   string
+	OutputFormat string
+	NumImages    int
+	// Raw sends the prompt without a template
+	Raw bool
+	// Brand applies the configured brand kit
+	Brand bool
+}
+
+// OptionsFor builds the generation options for settings. It fails on an
+// empty prompt, an unknown preset or a missing brand kit; the values
+// themselves are validated by the client before the request is sent.
+func OptionsFor(cfg *types.Config, settings Settings) (*types.IconGenerationOptions, error) {
+	prompt := strings.TrimSpace(settings.Prompt)
+	if prompt == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+
+	options := NewOptions()
+	options.Prompt = prompt
+	options.RawPrompt = settings.Raw
+	options.Template = cfg.DefaultTemplate
+	if options.Template == "" {
+		options.Template = templates.Default
+	}
+	options.Quality = types.DefaultValues.Quality
+	options.NumImages = types.DefaultValues.NumImages
+	if settings.Preset != "" {
+		preset, ok := presets.Lookup(settings.Preset, cfg.Presets)
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q", settings.Preset)
+		}
+		presets.Apply(preset.Preset, options)
+	}
+	for _, field := range []struct {
+		value  string
+		option *string
+	}{
+		{settings.Template, &options.Template},
+		{settings.Size, &options.Size},
+		{settings.Quality, &options.Quality},
+		{settings.Background, &options.Background},
+		{settings.OutputFormat, &options.OutputFormat},
+	} {
+		if field.value != "" {
+			*field.option = field.value
+		}
+	}
+	if settings.NumImages != 0 {
+		options.NumImages = settings.NumImages
+	}
+	if settings.Brand {
+		if brand.IsEmpty(cfg.Brand) {
+			return nil, fmt.Errorf("no brand kit is configured")
+		}
+		options.Brand = cfg.Brand
+	}
+	return options, nil
+}
+
+// Validate checks options with a client for the configured provider, the
+// way the request is checked before it is sent, so callers that queue work
+// can reject bad options up front
+func Validate(options *types.IconGenerationOptions) error {
+	hasCredentials, err := config.DefaultService.HasCredentials()
+	if err != nil {
+		return err
+	}
+	if !hasCredentials {
+		return errors.New("no API key is configured; run: just-icon config --api-key YOUR_KEY")
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	return client.Validate(options)
+}
+
+// Generate generates icons with the given options, saves them to the output
+// directory and records the generation in history. It returns the saved files.
+func Generate(ctx context.Context, options *types.IconGenerationOptions, presetName string) ([]string, error) {
+	// Show generation info; messages go to stderr so stdout only lists the
+	// saved files
+	out := utils.Diagnostics()
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%s %s\n", i18n.T("prompt_label"), utils.Bold(options.Prompt))
+	if options.OriginalPrompt != "" {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("original_prompt_label"), utils.Gray(options.OriginalPrompt))
+	}
+	if presetName != "" {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("preset_label"), utils.Cyan(presetName))
+	}
+	fmt.Fprintf(out, "%s %s\n", i18n.T("model_label"), utils.Blue(types.ModelGPTImage1))
+	fmt.Fprintf(out, "%s %s\n", i18n.T("template_label"), utils.Cyan(options.Template))
+	fmt.Fprintf(out, "%s %s\n", i18n.T("size_label"), utils.Cyan(options.Size))
+	fmt.Fprintf(out, "%s %s\n", i18n.T("quality_label"), utils.Cyan(options.Quality))
+	fmt.Fprintf(out, "%s %s\n", i18n.T("quantity_label"), utils.Cyan(fmt.Sprintf("%d", options.NumImages)))
+	if options.Background != types.DefaultValues.Background {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("background_label"), utils.Cyan(options.Background))
+	}
+	if options.OutputFormat != types.DefaultValues.OutputFormat {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("format_label"), utils.Cyan(options.OutputFormat))
+	}
+	if options.Brand != nil {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("brand_label"), utils.Cyan(i18n.Tf("brand_summary", len(options.Brand.Colors))))
+	}
+	fmt.Fprintln(out)
+
+	// Follow the request live on a terminal, or as log lines otherwise
+	tracker := progress.NewTracker([]string{i18n.T("interactive_generating_spinner")}, EstimateDuration(options))
+	stop := func() {}
+	if !utils.Quiet() {
+		stop = progress.Render(os.Stderr, tracker, utils.Decorated() && term.IsTerminal(int(os.Stderr.Fd())))
+	}
+	outcome, genErr := Run(ctx, options, presetName, tracker.Reporter(0))
+	stop()
+
+	if genErr != nil {
+		if outcome != nil {
+			for _, warning := range outcome.Warnings {
+				utils.PrintWarning(warning)
+			}
+		}
+		return nil, genErr
+	}
+	utils.PrintSuccess(i18n.T("interactive_generating_success"))
+
+	// Saved files are listed with previews on a terminal and one path per
+	// line otherwise
+	protocol := PreviewProtocol()
+	for _, filePath := range outcome.Files {
+		if !utils.Decorated() || utils.Quiet() {
+			fmt.Println(filePath)
+			continue
+		}
+		fmt.Fprintln(out)
+		utils.PrintSuccess(i18n.Tf("image_saved", filePath))
+		ShowPreview(filePath, protocol, preview.DefaultWidth)
+	}
+	for _, warning := range outcome.Warnings {
+		utils.PrintWarning(warning)
+	}
+	if outcome.ContactSheet != "" {
+		utils.PrintSuccess(i18n.Tf("contact_sheet_written", outcome.ContactSheet))
+	}
+
+	// Show summary
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, i18n.T("icon_generation_summary")+"\n",
+		utils.Green(fmt.Sprintf("%d", len(outcome.Files))),
+		utils.Blue(outcome.OutputDir))
+	if outcome.Result.Cost > 0 {
+		fmt.Fprintf(out, "%s %s\n", i18n.T("cost_label"), utils.Cyan(FormatCost(outcome.Result.Cost)))
+	}
+	fmt.Fprintln(out)
+	return outcome.Files, nil
+}
+
+// Outcome describes a finished generation
+type Outcome struct {
+	Result *types.GenerationResult
+	// Files are the saved images
+	Files     []string
+	OutputDir string
+	// HistoryID identifies the history entry, empty if it was not recorded
+	HistoryID string
+	// ContactSheet is the path of the contact sheet, empty when none was written
+	ContactSheet string
+	// Warnings are problems that did not stop the generation
+	Warnings []string
+}
+
+// Run generates icons without printing anything: it calls the API, saves
+// the images with their metadata, records history, indexes the gallery,
+// writes the contact sheet and checks the brand palette. Problems after the
+// API call are returned as warnings; an error is returned when no image
+// could be saved, together with the warnings explaining why. The request's
+// progress goes to reporter, which may be nil. Once ctx is canceled the
+// request is abandoned and nothing is saved.
+func Run(ctx context.Context, options *types.IconGenerationOptions, presetName string, reporter *progress.Reporter) (*Outcome, error) {
+	client, err := newClient()
+	if err != nil {
+		reporter.Finish(err)
+		return nil, err
+	}
+	started := time.Now()
+	result, err := client.GenerateIconWithProgress(ctx, options, reporter)
+	if err == nil {
+		err = ctx.Err()
+	}
+	reporter.Finish(err)
+	if err != nil {
+		return nil, err
+	}
+	duration := time.Since(started)
+	outcome := &Outcome{Result: result}
+	warn := func(message string) {
+		outcome.Warnings = append(outcome.Warnings, message)
+	}
+
+	// Resolve the output directory and file naming
+	naming := outputConfig()
+	outputDir := options.Output
+	if naming.PromptSubdirs {
+		outputDir = filepath.Join(outputDir, filenames.Slug(userPrompt(options)))
+	}
+	outcome.OutputDir = outputDir
+
+	// Create output directory if it doesn't exist
+	if err := os.MkdirAll(outputDir, types.ConfigDirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Save images with their generation metadata embedded and in a sidecar.
+	// Files are created exclusively, so existing files are never replaced.
+	var galleryEntries []gallery.Entry
+	now := time.Now()
+	for i, image := range result.Images {
+		md := metadata.New(options, result, i, presetName)
+		data, err := base64.StdEncoding.DecodeString(image.Base64)
+		if err != nil {
+			warn(i18n.Tf("image_decode_failed", i+1, err.Error()))
+			continue
+		}
+		if embedded, err := metadata.Embed(data, md); err != nil {
+			warn(i18n.Tf("metadata_embed_failed", i+1, err.Error()))
+		} else {
+			data = embedded
+		}
+
+		filename := filenames.Render(naming.FilenameTemplate, filenames.Vars{
+			Prompt:   userPrompt(options),
+			Size:     result.Size,
+			Quality:  result.Quality,
+			Template: result.Template,
+			Ext:      result.OutputFormat,
+			Index:    i + 1,
+			Time:     now,
+		})
+		filePath, err := utils.WriteFileExclusive(filepath.Join(outputDir, filename), data, 0644, sidecarTaken)
+		if err != nil {
+			warn(i18n.Tf("image_save_failed", i+1, err.Error()))
+			continue
+		}
+		outcome.Files = append(outcome.Files, filePath)
+
+		if err := metadata.WriteSidecar(filePath, md); err != nil {
+			warn(err.Error())
+		}
+		galleryEntries = append(galleryEntries, gallery.Entry{Path: filePath, Metadata: md})
+	}
+
+	if len(outcome.Files) == 0 {
+		return outcome, fmt.Errorf("no images were saved successfully")
+	}
+
+	// Record the generation so it can be recalled and re-run
+	entry, err := history.DefaultStore().Append(history.Entry{
+		Preset:     presetName,
+		Options:    *options,
+		Files:      outcome.Files,
+		DurationMS: duration.Milliseconds(),
+	})
+	if err != nil {
+		warn(i18n.Tf("history_record_failed", err.Error()))
+	} else {
+		outcome.HistoryID = entry.ID
+		for i := range galleryEntries {
+			galleryEntries[i].History = entry.ID
+		}
+	}
+
+	// Index the images in the gallery
+	if _, err := gallery.DefaultStore().Add(galleryEntries...); err != nil {
+		warn(i18n.Tf("gallery_record_failed", err.Error()))
+	}
+
+	// Write a contact sheet of the run for side-by-side review
+	if naming.ContactSheet {
+		items := make([]report.Item, len(galleryEntries))
+		for i, entry := range galleryEntries {
+			items[i] = report.Item{Path: entry.Path, Metadata: entry.Metadata}
+		}
+		if path, err := report.WriteContactSheet(outputDir, items); err != nil {
+			warn(i18n.Tf("contact_sheet_failed", err.Error()))
+		} else {
+			outcome.ContactSheet = path
+		}
+	}
+
+	// Warn when the generated colors drift from the brand palette
+	outcome.Warnings = append(outcome.Warnings, checkBrandPalette(options.Brand, outcome.Files)...)
+	return outcome, nil
+}
+
+// checkBrandPalette runs the brand palette check on saved images and
+// returns a warning for every color that does not fit the kit
+func checkBrandPalette(kit *types.BrandKit, paths []string) []string {
+	if kit == nil || (len(kit.Colors) == 0 && len(kit.ForbiddenColors) == 0) {
+		return nil
+	}
+
+	var warnings []string
+	for _, path := range paths {
+		report, err := brand.Check(kit, path)
+		if err != nil {
+			warnings = append(warnings, i18n.Tf("brand_check_failed", filepath.Base(path), err.Error()))
+			continue
+		}
+		for _, issue := range report.Issues {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", filepath.Base(path), issue.Message()))
+		}
+	}
+	return warnings
+}
+
+// EstimateDuration guesses how long a request with the options will take
+// from the durations in history: the median of the latest runs with the
+// same quality, size and image count, or of those with the same quality when
+// there are none. It returns zero without any.
+func EstimateDuration(options *types.IconGenerationOptions) time.Duration {
+	entries, err := history.DefaultStore().Recent(0)
+	if err != nil {
+		return 0
+	}
+	var same, similar []time.Duration
+	for _, entry := range entries {
+		if entry.DurationMS <= 0 || entry.Options.Quality != options.Quality {
+			continue
+		}
+		if len(similar) < estimateSamples {
+			similar = append(similar, entry.Duration())
+		}
+		if entry.Options.Size == options.Size && entry.Options.NumImages == options.NumImages && len(same) < estimateSamples {
+			same = append(same, entry.Duration())
+		}
+	}
+	if len(same) > 0 {
+		return progress.Median(same)
+	}
+	return progress.Median(similar)
+}
+
+// outputConfig returns the configured output naming, or the defaults when
+// the config cannot be read
+func outputConfig() types.OutputConfig {
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil || cfg.Output == nil {
+		return types.OutputConfig{}
+	}
+	return *cfg.Output
+}
+
+// userPrompt returns the prompt as the user typed it, before enhancement
+func userPrompt(options *types.IconGenerationOptions) string {
+	if options.OriginalPrompt != "" {
+		return options.OriginalPrompt
+	}
+	return options.Prompt
+}
+
+// sidecarTaken reports whether an image path's sidecar name is already used,
+// so a new image never adopts another image's sidecar
+func sidecarTaken(imagePath string) bool {
+	return utils.FileExists(metadata.SidecarPath(imagePath))
+}
+
+// ShowPreview draws an image in the terminal, warning instead of failing
+// when it cannot be drawn
+func ShowPreview(path string, protocol preview.Protocol, width int) {
+	if err := preview.Show(path, protocol, width); err != nil {
+		utils.PrintWarning(i18n.Tf("preview_failed", filepath.Base(path), err.Error()))
+	}
+}
+
+// PreviewProtocol returns the configured terminal preview protocol, or Off
+// when colors are disabled
+func PreviewProtocol() preview.Protocol {
+	if !utils.ColorEnabled() {
+		return preview.Off
+	}
+	return preview.Resolve(outputConfig().Preview)
+}
+
+// FormatCost formats an estimated cost in US dollars
+func FormatCost(cost float64) string {
+	return fmt.Sprintf("~$%.4f", cost)
+}
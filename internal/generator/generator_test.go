@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"just-icon/internal/cassette"
+	"just-icon/internal/config"
+	"just-icon/internal/iconset"
+	"just-icon/internal/logging"
+	"just-icon/internal/metadata"
+	"just-icon/internal/openai"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+)
+
+var (
+	record = flag.Bool("record", false, "record the cassettes against the API with OPENAI_API_KEY (and OPENAI_BASE_URL)")
+	update = flag.Bool("update", false, "rewrite the golden files")
+)
+
+// golden is what a generation and export leave on disk
+type golden struct {
+	Files    []string          `json:"files"`
+	Sidecar  json.RawMessage   `json:"sidecar"`
+	Checksum map[string]string `json:"checksums"`
+}
+
+func TestGenerateAndExportGolden(t *testing.T) {
+	// The b64 cassette returns the image inline, the url one as a link to
+	// download
+	tests := []struct {
+		name       string
+		prompt     string
+		template   string
+		background string
+	}{
+		{"b64", "rocket", templates.IOS, "auto"},
+		{"url", "paper plane", templates.FlatWeb, "transparent"},
+	}
+
+	// Sidecars and embedded metadata carry the response's timestamp
+	local := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = local }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			service := config.DefaultService
+			config.DefaultService = config.NewServiceWithPath(filepath.Join(dir, config.ConfigFileName))
+			defer func() { config.DefaultService = service }()
+			err := config.DefaultService.UpdateOutputConfig(func(output *types.OutputConfig) error {
+				output.FilenameTemplate = "{slug}-{n}.{ext}"
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			replay := useCassette(t, filepath.Join("testdata", "cassettes", tt.name))
+
+			options := NewOptions()
+			options.Prompt, options.Template, options.Background = tt.prompt, tt.template, tt.background
+			options.Quality, options.NumImages = types.QualityLow, 1
+			options.Output = filepath.Join(dir, "out")
+			outcome, err := Run(context.Background(), options, "", nil)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if len(outcome.Warnings) > 0 || outcome.HistoryID == "" {
+				t.Errorf("warnings = %v, history ID = %q", outcome.Warnings, outcome.HistoryID)
+			}
+			if _, err := iconset.Export(outcome.Files[0], filepath.Join(dir, "out", "icons"), iconset.Targets); err != nil {
+				t.Fatalf("Export() error = %v", err)
+			}
+			if replay != nil && replay.Unused() > 0 {
+				t.Errorf("%d recorded responses were not requested", replay.Unused())
+			}
+
+			got := snapshot(t, options.Output, outcome.Files)
+			checkGolden(t, filepath.Join("testdata", "golden", tt.name+".json"), got)
+		})
+	}
+}
+
+// useCassette points newClient at the cassette in dir: replaying it, or
+// recording it against the API with -record. It returns the replayer.
+func useCassette(t *testing.T, dir string) *cassette.Replayer {
+	t.Helper()
+	previous := newClient
+	t.Cleanup(func() { newClient = previous })
+
+	if *record {
+		cfg := &types.Config{OpenAIAPIKey: os.Getenv("OPENAI_API_KEY"), BaseURL: os.Getenv("OPENAI_BASE_URL")}
+		if cfg.OpenAIAPIKey == "" {
+			t.Fatal("-record needs OPENAI_API_KEY")
+		}
+		recorder := cassette.NewRecorder(nil, dir, logging.Secrets(cfg))
+		t.Cleanup(func() {
+			if err := recorder.Save(); err != nil {
+				t.Errorf("saving cassette: %v", err)
+			}
+		})
+		newClient = func() (*openai.Client, error) {
+			return openai.NewClientForConfig(context.Background(), cfg, &http.Client{Transport: recorder})
+		}
+		return nil
+	}
+
+	replay, err := cassette.NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient = func() (*openai.Client, error) {
+		cfg := &types.Config{OpenAIAPIKey: "sk-replay"}
+		return openai.NewClientForConfig(context.Background(), cfg, replay.Client())
+	}
+	return replay
+}
+
+// snapshot describes the files under root: the saved images, the first
+// sidecar and the checksum of every file
+func snapshot(t *testing.T, root string, files []string) golden {
+	t.Helper()
+	got := golden{Checksum: make(map[string]string)}
+	for _, file := range files {
+		got.Files = append(got.Files, filepath.Base(file))
+	}
+	sidecar, err := os.ReadFile(metadata.SidecarPath(files[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Sidecar = json.RawMessage(sidecar)
+
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		sum := sha256.Sum256(data)
+		got.Checksum[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:8])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+// checkGolden compares got with the golden file, or rewrites it with -update
+func checkGolden(t *testing.T, path string, got golden) {
+	t.Helper()
+	data, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, '\n')
+	if *update || *record {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v; run go test -update to create it", err)
+	}
+	if string(want) != string(data) {
+		t.Errorf("output differs from %s; run go test -update if the change is intended\ngot:\n%s", path, data)
+	}
+}
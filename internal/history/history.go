@@ -0,0 +1,161 @@
+package history
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/jsonl"
+	"just-icon/internal/types"
+)
+
+// FileName is the history file inside the state directory
+const FileName = "history.jsonl"
+
+var (
+	ErrNotFound  = jsonl.ErrNotFound
+	ErrAmbiguous = jsonl.ErrAmbiguous
+)
+
+// Entry is one recorded generation
+type Entry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	// Preset names the style preset the generation started from
+	Preset string `json:"preset,omitempty"`
+	// Branded records that the brand kit was applied; the kit itself is not
+	// stored so a re-run uses the current one
+	Branded bool                        `json:"branded,omitempty"`
+	Options types.IconGenerationOptions `json:"options"`
+	Files   []string                    `json:"files,omitempty"`
+	// DurationMS is how long the API request took in milliseconds, used to
+	// estimate the time left in later runs
+	DurationMS int64 `json:"duration_ms,omitempty"`
+}
+
+// UserPrompt returns the prompt as the user typed it, before enhancement
+func (e Entry) UserPrompt() string {
+	if e.Options.OriginalPrompt != "" {
+		return e.Options.OriginalPrompt
+	}
+	return e.Options.Prompt
+}
+
+// Duration returns how long the API request took, zero when not recorded
+func (e Entry) Duration() time.Duration {
+	return time.Duration(e.DurationMS) * time.Millisecond
+}
+
+// Store is an append-only JSON lines history file
+type Store struct {
+	file *jsonl.File[Entry]
+}
+
+// NewStore creates a store backed by the history file at path
+func NewStore(path string) *Store {
+	return &Store{file: jsonl.New[Entry](path, "history file")}
+}
+
+// DefaultStore returns the store in the default config service's state directory
+func DefaultStore() *Store {
+	return NewStore(filepath.Join(config.DefaultService.StateDir(), FileName))
+}
+
+// Path returns the history file path
+func (s *Store) Path() string {
+	return s.file.Path()
+}
+
+// Append records a generation, filling in the ID and timestamp when unset.
+// The brand kit is replaced by the Branded flag.
+func (s *Store) Append(entry Entry) (Entry, error) {
+	if entry.ID == "" {
+		id, err := jsonl.NewID()
+		if err != nil {
+			return entry, err
+		}
+		entry.ID = id
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	if entry.Options.Brand != nil {
+		entry.Branded = true
+		entry.Options.Brand = nil
+	}
+	return entry, s.file.Append(entry)
+}
+
+// List returns all entries, oldest first. Lines that fail to parse are
+// skipped so a torn write doesn't hide the rest of the history.
+func (s *Store) List() ([]Entry, error) {
+	return s.file.Read()
+}
+
+// Recent returns up to limit entries, newest first; a limit of zero or less
+// returns all of them
+func (s *Store) Recent(limit int) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	reversed := make([]Entry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		reversed = append(reversed, entries[i])
+		if limit > 0 && len(reversed) == limit {
+			break
+		}
+	}
+	return reversed, nil
+}
+
+// Find resolves a reference to an entry. @N counts back from the newest
+// entry (@1 is the latest). Anything else is matched as an ID prefix; a
+// small number that does not match exactly one ID is taken as a position.
+func (s *Store) Find(ref string) (*Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	ref = strings.TrimSpace(ref)
+
+	if position, ok := strings.CutPrefix(ref, "@"); ok {
+		return at(entries, position)
+	}
+
+	i, err := jsonl.Find(entries, func(e Entry) string { return e.ID }, ref)
+	if err != nil {
+		if _, numErr := strconv.Atoi(ref); numErr == nil && len(ref) < jsonl.IDLength {
+			return at(entries, ref)
+		}
+		return nil, err
+	}
+	return &entries[i], nil
+}
+
+// at returns the entry at a position counting back from the newest one
+func at(entries []Entry, position string) (*Entry, error) {
+	n, err := strconv.Atoi(position)
+	if err != nil || n < 1 || n > len(entries) {
+		return nil, ErrNotFound
+	}
+	entry := entries[len(entries)-n]
+	return &entry, nil
+}
+
+// Prompts returns the distinct prompts the user typed, newest first
+func Prompts(entries []Entry) []string {
+	seen := make(map[string]bool)
+	var prompts []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		prompt := strings.TrimSpace(entries[i].UserPrompt())
+		if prompt == "" || seen[prompt] {
+			continue
+		}
+		seen[prompt] = true
+		prompts = append(prompts, prompt)
+	}
+	return prompts
+}
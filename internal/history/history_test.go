@@ -0,0 +1,165 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"just-icon/internal/jsonl"
+	"just-icon/internal/types"
+)
+
+func TestAppendAndList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "state", FileName))
+
+	first, err := store.Append(Entry{
+		Preset: "brand",
+		Options: types.IconGenerationOptions{
+			Prompt:    "a calculator",
+			Quality:   types.QualityHigh,
+			NumImages: 2,
+			Brand:     &types.BrandKit{Mood: []string{"friendly"}},
+		},
+		Files: []string{"out/icon_1.png", "out/icon_2.png"},
+	})
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if len(first.ID) != jsonl.IDLength || first.Timestamp.IsZero() {
+		t.Errorf("Append() did not fill ID and timestamp: %+v", first)
+	}
+	if _, err := store.Append(Entry{Options: types.IconGenerationOptions{Prompt: "a camera"}}); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("List() returned %d entries, want 2", len(entries))
+	}
+	got := entries[0]
+	if got.ID != first.ID || got.Preset != "brand" || got.Options.Quality != types.QualityHigh ||
+		!reflect.DeepEqual(got.Files, first.Files) {
+		t.Errorf("List()[0] = %+v, want %+v", got, first)
+	}
+	if !got.Branded || got.Options.Brand != nil {
+		t.Errorf("brand kit should be replaced by the Branded flag: %+v", got)
+	}
+}
+
+func TestListSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `{"id":"aaaa1111","options":{"prompt":"one"}}
+{"id":"bbbb
+{"id":"cccc3333","options":{"prompt":"three"}}
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := NewStore(path).List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 || entries[1].Options.Prompt != "three" {
+		t.Errorf("List() = %+v, want the two valid entries", entries)
+	}
+}
+
+func TestListMissingFile(t *testing.T) {
+	entries, err := NewStore(filepath.Join(t.TempDir(), FileName)).List()
+	if err != nil || entries != nil {
+		t.Errorf("List() = %v, %v; want nil, nil", entries, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	for _, entry := range []Entry{
+		{ID: "20251019", Options: types.IconGenerationOptions{Prompt: "numeric"}},
+		{ID: "ab12cd34", Options: types.IconGenerationOptions{Prompt: "oldest"}},
+		{ID: "ab98ef76", Options: types.IconGenerationOptions{Prompt: "middle"}},
+		{ID: "ff00ff00", Options: types.IconGenerationOptions{Prompt: "newest"}},
+	} {
+		if _, err := store.Append(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr error
+	}{
+		{"1", "newest", nil},
+		{"3", "oldest", nil},
+		{"4", "numeric", nil},
+		{"5", "", ErrNotFound},
+		{"0", "", ErrNotFound},
+		{"@1", "newest", nil},
+		{"@3", "oldest", nil},
+		{"@5", "", ErrNotFound},
+		{"@x", "", ErrNotFound},
+		// A numeric ID prefix wins over a position
+		{"2", "numeric", nil},
+		{"2025", "numeric", nil},
+		{"@2", "middle", nil},
+		{"ff", "newest", nil},
+		{"ab12", "oldest", nil},
+		{"ab98ef76", "middle", nil},
+		{"ab", "", ErrAmbiguous},
+		{"zz", "", ErrNotFound},
+		{"", "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		entry, err := store.Find(tt.ref)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Find(%q) error = %v, want %v", tt.ref, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Find(%q) error = %v", tt.ref, err)
+			continue
+		}
+		if entry.Options.Prompt != tt.want {
+			t.Errorf("Find(%q) = %q, want %q", tt.ref, entry.Options.Prompt, tt.want)
+		}
+	}
+}
+
+func TestRecent(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	for _, prompt := range []string{"a", "b", "c"} {
+		if _, err := store.Append(Entry{Options: types.IconGenerationOptions{Prompt: prompt}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := store.Recent(2)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Options.Prompt != "c" || entries[1].Options.Prompt != "b" {
+		t.Errorf("Recent(2) = %+v, want c then b", entries)
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	entries := []Entry{
+		{Options: types.IconGenerationOptions{Prompt: "rocket"}},
+		{Options: types.IconGenerationOptions{Prompt: "a detailed glass rocket", OriginalPrompt: "rocket"}},
+		{Options: types.IconGenerationOptions{Prompt: "camera"}},
+		{Options: types.IconGenerationOptions{Prompt: "  "}},
+	}
+
+	want := []string{"camera", "rocket"}
+	if got := Prompts(entries); !reflect.DeepEqual(got, want) {
+		t.Errorf("Prompts() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// DefaultTimeout bounds a single HTTP request, including image downloads
+const DefaultTimeout = 5 * time.Minute
+
+// New builds an HTTP client from the configured proxy, TLS and header
+// settings. The headers and user agent are only sent to the host of apiURL,
+// so gateway credentials don't leak to image download hosts. A nil config
+// yields a client that honors the proxy environment.
+func New(cfg *types.HTTPConfig, apiURL string) (*http.Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if cfg != nil && (len(cfg.Headers) > 0 || cfg.UserAgent != "") {
+		host := ""
+		if parsed, err := url.Parse(apiURL); err == nil {
+			host = parsed.Host
+		}
+		roundTripper = &headerTransport{
+			base:      transport,
+			host:      host,
+			headers:   cfg.Headers,
+			userAgent: cfg.UserAgent,
+		}
+	}
+
+	return &http.Client{
+		Transport: roundTripper,
+		Timeout:   DefaultTimeout,
+	}, nil
+}
+
+// NewTransport builds the underlying transport with proxy and TLS settings
+func NewTransport(cfg *types.HTTPConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg == nil {
+		return transport, nil
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := ParseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := TLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// TLSConfig returns the TLS settings for a custom CA bundle and client
+// certificate, or nil when neither is configured. File paths may start
+// with ~, as shared configs write them.
+func TLSConfig(cfg *types.HTTPConfig) (*tls.Config, error) {
+	if cfg == nil || (cfg.CAFile == "" && cfg.ClientCertFile == "" && cfg.ClientKeyFile == "") {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(utils.ExpandHome(cfg.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+
+		// Trust the private CA in addition to the system roots
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		if cfg.ClientCertFile == "" || cfg.ClientKeyFile == "" {
+			return nil, fmt.Errorf("client certificate and client key must be configured together")
+		}
+		cert, err := tls.LoadX509KeyPair(utils.ExpandHome(cfg.ClientCertFile), utils.ExpandHome(cfg.ClientKeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// ParseProxyURL validates a proxy URL (http, https or socks5)
+func ParseProxyURL(proxyURL string) (*url.URL, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https or socks5)", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("proxy URL must include a host")
+	}
+
+	return parsed, nil
+}
+
+// ParseHeader parses a "Name: value" or "Name=value" header definition
+func ParseHeader(header string) (string, string, error) {
+	index := strings.IndexAny(header, ":=")
+	if index <= 0 {
+		return "", "", fmt.Errorf("invalid header %q, expected Name: value", header)
+	}
+
+	name := http.CanonicalHeaderKey(strings.TrimSpace(header[:index]))
+	value := strings.TrimSpace(header[index+1:])
+	if strings.EqualFold(name, "Authorization") {
+		return "", "", fmt.Errorf("the Authorization header is managed by the API key setting")
+	}
+
+	return name, value, nil
+}
+
+// headerTransport adds configured headers and a user agent to requests for
+// the API host
+type headerTransport struct {
+	base      http.RoundTripper
+	host      string
+	headers   map[string]string
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.host == "" || !strings.EqualFold(req.URL.Host, t.host) {
+		return t.base.RoundTrip(req)
+	}
+
+	// Requests must not be modified in place
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,170 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestNewAddsHeadersAndUserAgent(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client, err := New(&types.HTTPConfig{
+		Headers:   map[string]string{"X-Org-Id": "org-42"},
+		UserAgent: "just-icon-test/1.0",
+	}, server.URL+"/v1")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("X-Org-Id") != "org-42" {
+		t.Errorf("expected X-Org-Id header, got %q", got.Get("X-Org-Id"))
+	}
+	if got.Get("User-Agent") != "just-icon-test/1.0" {
+		t.Errorf("expected custom User-Agent, got %q", got.Get("User-Agent"))
+	}
+}
+
+func TestNewKeepsHeadersFromOtherHosts(t *testing.T) {
+	var got http.Header
+	download := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer download.Close()
+
+	client, err := New(&types.HTTPConfig{
+		Headers:   map[string]string{"X-Gateway-Key": "secret"},
+		UserAgent: "just-icon-test/1.0",
+	}, "https://gateway.example.com/v1")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	resp, err := client.Get(download.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("X-Gateway-Key") != "" || got.Get("User-Agent") == "just-icon-test/1.0" {
+		t.Errorf("gateway headers were sent to another host: %v", got)
+	}
+}
+
+func TestNewTrustsCustomCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	// Without the CA the self-signed test certificate is rejected
+	plain, _ := New(nil, "")
+	if _, err := plain.Get(server.URL); err == nil {
+		t.Fatal("expected certificate error without custom CA")
+	}
+
+	// Shared configs write the path relative to the home directory
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(home, "ca.pem"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(&types.HTTPConfig{CAFile: "~/ca.pem"}, "")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request with custom CA failed: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNewUsesProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "from proxy")
+	}))
+	defer proxy.Close()
+
+	client, err := New(&types.HTTPConfig{ProxyURL: proxy.URL}, "")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	resp, err := client.Get("http://api.example.invalid/v1/models")
+	if err != nil {
+		t.Fatalf("request through proxy failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if proxied != "http://api.example.invalid/v1/models" {
+		t.Errorf("expected request to go through proxy, proxy saw %q", proxied)
+	}
+}
+
+func TestNewRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *types.HTTPConfig
+	}{
+		{"bad proxy scheme", &types.HTTPConfig{ProxyURL: "ftp://proxy:21"}},
+		{"missing CA file", &types.HTTPConfig{CAFile: "/does/not/exist.pem"}},
+		{"cert without key", &types.HTTPConfig{ClientCertFile: "cert.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg, ""); err == nil {
+				t.Errorf("New() expected error for %+v", tt.cfg)
+			}
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		header    string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{"X-Org-Id: 123", "X-Org-Id", "123", false},
+		{"x-org-id=123", "X-Org-Id", "123", false},
+		{"X-Org-Id:", "X-Org-Id", "", false},
+		{"no-separator", "", "", true},
+		{"Authorization: Bearer x", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			name, value, err := ParseHeader(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHeader(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("ParseHeader(%q) = %q, %q, want %q, %q", tt.header, name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
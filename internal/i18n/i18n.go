@@ -124,13 +124,13 @@ func (l *Localizer) loadEmbeddedFile(lang Language, filePath string) {
 	data, err := localesFS.ReadFile(filePath)
 	if err != nil {
 		// This should never happen if files are properly embedded
-		fmt.Printf("Warning: Failed to load embedded file %s: %v\n", filePath, err)
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load embedded file %s: %v\n", filePath, err)
 		return
 	}
 
 	messages, err := NewMessages(data)
 	if err != nil {
-		fmt.Printf("Warning: Failed to parse embedded file %s: %v\n", filePath, err)
+		fmt.Fprintf(os.Stderr, "Warning: Failed to parse embedded file %s: %v\n", filePath, err)
 		return
 	}
 
@@ -0,0 +1,39 @@
+package iconset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+)
+
+// encodeICO packs the images into an ICO file with PNG entries, which every
+// browser and Windows since Vista read
+func encodeICO(images []image.Image) ([]byte, error) {
+	entries := make([][]byte, len(images))
+	for i, img := range images {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		entries[i] = buf.Bytes()
+	}
+
+	var out bytes.Buffer
+	// ICONDIR: reserved, type 1 (icon), image count
+	binary.Write(&out, binary.LittleEndian, [3]uint16{0, 1, uint16(len(images))})
+
+	offset := 6 + 16*len(images)
+	for i, img := range images {
+		bounds := img.Bounds()
+		// ICONDIRENTRY: a width or height of 0 means 256 pixels
+		out.Write([]byte{byte(bounds.Dx()), byte(bounds.Dy()), 0, 0})
+		binary.Write(&out, binary.LittleEndian, [2]uint16{1, 32}) // color planes, bits per pixel
+		binary.Write(&out, binary.LittleEndian, [2]uint32{uint32(len(entries[i])), uint32(offset)})
+		offset += len(entries[i])
+	}
+	for _, entry := range entries {
+		out.Write(entry)
+	}
+	return out.Bytes(), nil
+}
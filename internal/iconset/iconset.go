@@ -0,0 +1,251 @@
+package iconset
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// Target is a platform an icon set can be exported for
+type Target string
+
+const (
+	// IOS writes an Xcode AppIcon.appiconset with a single 1024px icon
+	IOS Target = "ios"
+	// Android writes launcher icons for every mipmap density and the Play
+	// Store icon
+	Android Target = "android"
+	// Web writes favicons, touch icons and a web app manifest
+	Web Target = "web"
+	// MacOS writes an AppIcon.iconset ready for iconutil
+	MacOS Target = "macos"
+)
+
+// Targets lists the supported targets in export order
+var Targets = []Target{IOS, Android, Web, MacOS}
+
+// icon is one file of an icon set
+type icon struct {
+	path string
+	size int
+	// opaque flattens transparency onto white for platforms that reject it
+	opaque bool
+}
+
+// icons lists the files written for each target, relative to its directory
+var icons = map[Target][]icon{
+	IOS: {
+		{"AppIcon.appiconset/AppIcon-1024.png", 1024, true},
+	},
+	Android: {
+		{"mipmap-mdpi/ic_launcher.png", 48, false},
+		{"mipmap-hdpi/ic_launcher.png", 72, false},
+		{"mipmap-xhdpi/ic_launcher.png", 96, false},
+		{"mipmap-xxhdpi/ic_launcher.png", 144, false},
+		{"mipmap-xxxhdpi/ic_launcher.png", 192, false},
+		{"ic_launcher-playstore.png", 512, false},
+	},
+	Web: {
+		{"favicon-16x16.png", 16, false},
+		{"favicon-32x32.png", 32, false},
+		{"apple-touch-icon.png", 180, true},
+		{"android-chrome-192x192.png", 192, false},
+		{"android-chrome-512x512.png", 512, false},
+	},
+	MacOS: {
+		{"AppIcon.iconset/icon_16x16.png", 16, false},
+		{"AppIcon.iconset/icon_16x16@2x.png", 32, false},
+		{"AppIcon.iconset/icon_32x32.png", 32, false},
+		{"AppIcon.iconset/icon_32x32@2x.png", 64, false},
+		{"AppIcon.iconset/icon_128x128.png", 128, false},
+		{"AppIcon.iconset/icon_128x128@2x.png", 256, false},
+		{"AppIcon.iconset/icon_256x256.png", 256, false},
+		{"AppIcon.iconset/icon_256x256@2x.png", 512, false},
+		{"AppIcon.iconset/icon_512x512.png", 512, false},
+		{"AppIcon.iconset/icon_512x512@2x.png", 1024, false},
+	},
+}
+
+// faviconSizes are the images packed into favicon.ico
+var faviconSizes = []int{16, 32, 48}
+
+// ParseTargets parses target names, accepting comma separated lists. No
+// names select every target.
+func ParseTargets(names []string) ([]Target, error) {
+	var targets []Target
+	seen := make(map[Target]bool)
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			target := Target(strings.ToLower(strings.TrimSpace(part)))
+			if target == "" || seen[target] {
+				continue
+			}
+			if !isTarget(target) {
+				return nil, fmt.Errorf("unknown export target %q, use one of %s", target, Names())
+			}
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return Targets, nil
+	}
+	return targets, nil
+}
+
+// Names joins the target names for messages
+func Names() string {
+	names := make([]string, len(Targets))
+	for i, target := range Targets {
+		names[i] = string(target)
+	}
+	return strings.Join(names, ", ")
+}
+
+// isTarget reports whether target is supported
+func isTarget(target Target) bool {
+	for _, t := range Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDir returns a new directory next to the image for its icon sets,
+// e.g. rocket-icons, or rocket-icons-2 when that exists
+func DefaultDir(imagePath string) string {
+	base := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + "-icons"
+	dir := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return dir
+		}
+		dir = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// Export writes the icon sets of the image at src into one subdirectory of
+// dir per target and returns the target directories. The image is fitted
+// into a square, keeping its aspect ratio.
+func Export(src, dir string, targets []Target) ([]string, error) {
+	img, err := utils.LoadImage(src)
+	if err != nil {
+		return nil, err
+	}
+	img = square(img)
+
+	var dirs []string
+	for _, target := range targets {
+		targetDir := filepath.Join(dir, string(target))
+		for _, icon := range icons[target] {
+			if err := writePNG(filepath.Join(targetDir, icon.path), resize(img, icon.size, icon.opaque)); err != nil {
+				return dirs, err
+			}
+		}
+		if err := writeExtras(target, targetDir, img); err != nil {
+			return dirs, err
+		}
+		dirs = append(dirs, targetDir)
+	}
+	return dirs, nil
+}
+
+// writeExtras writes the files of a target that are not plain PNGs
+func writeExtras(target Target, dir string, img image.Image) error {
+	switch target {
+	case IOS:
+		return writeJSON(filepath.Join(dir, "AppIcon.appiconset", "Contents.json"), map[string]interface{}{
+			"images": []map[string]string{{
+				"filename": "AppIcon-1024.png",
+				"idiom":    "universal",
+				"platform": "ios",
+				"size":     "1024x1024",
+			}},
+			"info": map[string]interface{}{"author": "xcode", "version": 1},
+		})
+	case Web:
+		images := make([]image.Image, len(faviconSizes))
+		for i, size := range faviconSizes {
+			images[i] = resize(img, size, false)
+		}
+		data, err := encodeICO(images)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(filepath.Join(dir, "favicon.ico"), data); err != nil {
+			return err
+		}
+		return writeJSON(filepath.Join(dir, "site.webmanifest"), map[string]interface{}{
+			"icons": []map[string]string{
+				{"src": "/android-chrome-192x192.png", "sizes": "192x192", "type": "image/png"},
+				{"src": "/android-chrome-512x512.png", "sizes": "512x512", "type": "image/png"},
+			},
+			"display": "standalone",
+		})
+	}
+	return nil
+}
+
+// square centers img on a transparent square canvas
+func square(img image.Image) image.Image {
+	bounds := img.Bounds()
+	side := max(bounds.Dx(), bounds.Dy())
+	if bounds.Dx() == bounds.Dy() {
+		return img
+	}
+	canvas := image.NewNRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt((side-bounds.Dx())/2, (side-bounds.Dy())/2)
+	draw.Draw(canvas, bounds.Sub(bounds.Min).Add(offset), img, bounds.Min, draw.Src)
+	return canvas
+}
+
+// resize scales a square image to size pixels, flattening it onto white
+// when opaque is set
+func resize(img image.Image, size int, opaque bool) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	if opaque {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	}
+	return dst
+}
+
+// writePNG encodes img as PNG at path
+func writePNG(path string, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return writeFile(path, buf.Bytes())
+}
+
+// writeJSON writes v as indented JSON at path
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, append(data, '\n'))
+}
+
+// writeFile creates the parent directories and writes data at path
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), types.ConfigDirPerm); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, data, 0644)
+}
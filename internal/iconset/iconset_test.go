@@ -0,0 +1,101 @@
+package iconset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"just-icon/pkg/utils"
+)
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "rocket.png")
+	// A wide red image leaves transparent bands once squared
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 32))
+	for i := 0; i < len(img.Pix); i += 4 {
+		copy(img.Pix[i:], []byte{0xff, 0, 0, 0xff})
+	}
+	f, err := os.Create(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(f, img)
+	f.Close()
+
+	dirs, err := Export(src, filepath.Join(dir, "out"), Targets)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(dirs) != len(Targets) {
+		t.Fatalf("Export() returned %d dirs, want %d", len(dirs), len(Targets))
+	}
+
+	for target, list := range icons {
+		for _, icon := range list {
+			got, err := utils.LoadImage(filepath.Join(dir, "out", string(target), icon.path))
+			if err != nil {
+				t.Fatalf("%s %s: %v", target, icon.path, err)
+			}
+			if b := got.Bounds(); b.Dx() != icon.size || b.Dy() != icon.size {
+				t.Errorf("%s %s is %v, want %dpx", target, icon.path, b.Size(), icon.size)
+			}
+			corner := color.NRGBAModel.Convert(got.At(0, 0)).(color.NRGBA)
+			if icon.opaque && corner != (color.NRGBA{0xff, 0xff, 0xff, 0xff}) {
+				t.Errorf("%s %s corner = %v, want white", target, icon.path, corner)
+			}
+			if !icon.opaque && corner.A != 0 {
+				t.Errorf("%s %s corner = %v, want transparent", target, icon.path, corner)
+			}
+		}
+	}
+
+	for _, extra := range []string{"ios/AppIcon.appiconset/Contents.json", "web/site.webmanifest"} {
+		if _, err := os.Stat(filepath.Join(dir, "out", extra)); err != nil {
+			t.Errorf("missing %s: %v", extra, err)
+		}
+	}
+
+	ico, err := os.ReadFile(filepath.Join(dir, "out", "web", "favicon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := binary.LittleEndian.Uint16(ico[4:]); count != uint16(len(faviconSizes)) {
+		t.Errorf("favicon.ico has %d images, want %d", count, len(faviconSizes))
+	}
+	offset := binary.LittleEndian.Uint32(ico[6+12:])
+	if _, err := png.Decode(bytes.NewReader(ico[offset:])); err != nil {
+		t.Errorf("first favicon entry is not a PNG: %v", err)
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	got, err := ParseTargets([]string{"ios, web", "IOS"})
+	if err != nil || !reflect.DeepEqual(got, []Target{IOS, Web}) {
+		t.Errorf("ParseTargets() = %v, %v", got, err)
+	}
+	if got, _ := ParseTargets(nil); !reflect.DeepEqual(got, Targets) {
+		t.Errorf("ParseTargets(nil) = %v, want all targets", got)
+	}
+	if _, err := ParseTargets([]string{"windows"}); err == nil {
+		t.Error("ParseTargets() accepted an unknown target")
+	}
+}
+
+func TestDefaultDir(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "rocket.png")
+	if got := DefaultDir(src); got != filepath.Join(dir, "rocket-icons") {
+		t.Errorf("DefaultDir() = %q", got)
+	}
+	os.Mkdir(filepath.Join(dir, "rocket-icons"), 0755)
+	if got := DefaultDir(src); got != filepath.Join(dir, "rocket-icons-2") {
+		t.Errorf("DefaultDir() with an existing dir = %q", got)
+	}
+}
@@ -0,0 +1,278 @@
+package interactive
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/sahilm/fuzzy"
+
+	"just-icon/internal/history"
+	"just-icon/internal/i18n"
+	"just-icon/internal/types"
+)
+
+const (
+	// maxSearchResults is the number of fuzzy matches shown while searching
+	maxSearchResults = 5
+	// maxResultWidth truncates long prompts in the search results
+	maxResultWidth = 72
+)
+
+// Styles mirror the prompti input so the recall prompt fits in with the
+// other steps
+var (
+	recallPurple      = lipgloss.AdaptiveColor{Light: "#7e22ce", Dark: "#a855f7"}
+	recallRed         = lipgloss.AdaptiveColor{Light: "#ef4444", Dark: "#ef4444"}
+	recallIconStyle   = lipgloss.NewStyle().MarginRight(1).Bold(true).Foreground(recallPurple)
+	recallTitleStyle  = lipgloss.NewStyle().MarginRight(1).Bold(true)
+	recallMarkStyle   = lipgloss.NewStyle().Faint(true)
+	recallHintStyle   = lipgloss.NewStyle().Faint(true).PaddingLeft(2)
+	recallErrorStyle  = lipgloss.NewStyle().Bold(true).Foreground(recallRed)
+	recallActiveStyle = lipgloss.NewStyle().Foreground(recallPurple).Bold(true)
+)
+
+// promptModel is a text input that recalls earlier prompts with the arrow
+// keys and finds them with a fuzzy search on Ctrl+R
+type promptModel struct {
+	input  textinput.Model
+	search textinput.Model
+
+	// history holds the previous prompts, newest first
+	history []string
+	// index is the recalled history entry, -1 while editing a new prompt
+	index int
+	// draft keeps the typed text while browsing history
+	draft string
+
+	searching bool
+	matches   []string
+	selected  int
+
+	err      error
+	quitting bool
+}
+
+// newPromptModel creates the prompt input with the given history, newest first
+func newPromptModel(previous []string) promptModel {
+	input := textinput.New()
+	input.Prompt = promptTitle(i18n.T("interactive_prompt_input"))
+	input.Placeholder = types.DefaultPromptPlaceholder
+	input.PlaceholderStyle = lipgloss.NewStyle().Faint(true)
+	input.Focus()
+
+	search := textinput.New()
+	search.Prompt = promptTitle(i18n.T("interactive_history_search"))
+
+	return promptModel{
+		input:   input,
+		search:  search,
+		history: previous,
+		index:   -1,
+	}
+}
+
+// promptTitle renders the "? Question ›" prefix
+func promptTitle(question string) string {
+	return lipgloss.JoinHorizontal(lipgloss.Center,
+		recallIconStyle.Render("?"),
+		recallTitleStyle.Render(question),
+		recallMarkStyle.Render("› "))
+}
+
+// Init starts the cursor blinking
+func (m promptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles key presses for editing, recall and search
+func (m promptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		if m.searching {
+			m.search, cmd = m.search.Update(msg)
+		} else {
+			m.input, cmd = m.input.Update(msg)
+		}
+		return m, cmd
+	}
+
+	if key.Type == tea.KeyCtrlC {
+		m.quitting = true
+		return m, tea.Quit
+	}
+	if m.searching {
+		return m.updateSearch(key)
+	}
+
+	switch key.Type {
+	case tea.KeyEsc:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyEnter:
+		m.err = validatePrompt(m.input.Value())
+		if m.err == nil {
+			return m, tea.Quit
+		}
+		return m, nil
+	case tea.KeyUp:
+		m.recall(m.index + 1)
+		return m, nil
+	case tea.KeyDown:
+		m.recall(m.index - 1)
+		return m, nil
+	case tea.KeyCtrlR:
+		if len(m.history) > 0 {
+			m.searching = true
+			m.search.SetValue("")
+			m.search.Focus()
+			m.input.Blur()
+			m.refreshMatches()
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.err = nil
+	return m, cmd
+}
+
+// updateSearch handles keys while the fuzzy search is open
+func (m promptModel) updateSearch(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.Type {
+	case tea.KeyEsc, tea.KeyCtrlR:
+		m.closeSearch()
+		return m, nil
+	case tea.KeyEnter:
+		if len(m.matches) > 0 {
+			m.input.SetValue(m.matches[m.selected])
+			m.input.CursorEnd()
+			m.index = -1
+			m.err = nil
+		}
+		m.closeSearch()
+		return m, nil
+	case tea.KeyUp, tea.KeyShiftTab:
+		if m.selected > 0 {
+			m.selected--
+		}
+		return m, nil
+	case tea.KeyDown, tea.KeyTab:
+		if m.selected < len(m.matches)-1 {
+			m.selected++
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(key)
+	m.refreshMatches()
+	return m, cmd
+}
+
+// recall shows the history entry at index, restoring the draft below zero
+func (m *promptModel) recall(index int) {
+	if index >= len(m.history) || index < -1 || index == m.index {
+		return
+	}
+	if m.index == -1 {
+		m.draft = m.input.Value()
+	}
+	m.index = index
+	if index == -1 {
+		m.input.SetValue(m.draft)
+	} else {
+		m.input.SetValue(m.history[index])
+	}
+	m.input.CursorEnd()
+	m.err = nil
+}
+
+// refreshMatches reruns the fuzzy search for the current query
+func (m *promptModel) refreshMatches() {
+	m.matches = searchHistory(m.search.Value(), m.history, maxSearchResults)
+	m.selected = 0
+}
+
+// closeSearch leaves search mode and returns focus to the prompt
+func (m *promptModel) closeSearch() {
+	m.searching = false
+	m.matches = nil
+	m.search.Blur()
+	m.input.Focus()
+}
+
+// searchHistory returns up to limit prompts matching query, best first; an
+// empty query lists the most recent prompts
+func searchHistory(query string, prompts []string, limit int) []string {
+	var results []string
+	if strings.TrimSpace(query) == "" {
+		results = prompts
+	} else {
+		for _, match := range fuzzy.Find(query, prompts) {
+			results = append(results, match.Str)
+		}
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// View renders the prompt, the search results or the validation error
+func (m promptModel) View() string {
+	if m.searching {
+		lines := []string{m.search.View()}
+		if len(m.matches) == 0 {
+			lines = append(lines, recallHintStyle.Render(i18n.T("interactive_history_no_match")))
+		}
+		for i, match := range m.matches {
+			match = runewidth.Truncate(match, maxResultWidth, "…")
+			if i == m.selected {
+				lines = append(lines, recallActiveStyle.Render("› "+match))
+			} else {
+				lines = append(lines, "  "+match)
+			}
+		}
+		lines = append(lines, recallHintStyle.Render(i18n.T("interactive_history_search_hint")))
+		return lipgloss.NewStyle().MarginTop(1).Render(strings.Join(lines, "\n"))
+	}
+
+	view := m.input.View()
+	if m.quitting {
+		return lipgloss.NewStyle().MarginTop(1).Render(view)
+	}
+	if m.err != nil {
+		view += "\n" + recallErrorStyle.Render("✘ "+promptErrorMessage(m.err))
+	} else if len(m.history) > 0 && m.input.Focused() {
+		view += "\n" + recallHintStyle.Render(i18n.T("interactive_history_hint"))
+	}
+	return lipgloss.NewStyle().MarginTop(1).Render(view)
+}
+
+// promptErrorMessage localizes a prompt validation error
+func promptErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrEmptyPrompt):
+		return i18n.T("validation_prompt_empty")
+	case errors.Is(err, ErrPlaceholderPrompt):
+		return i18n.T("validation_prompt_placeholder")
+	default:
+		return err.Error()
+	}
+}
+
+// previousPrompts loads the prompts to recall; history is best effort so
+// read errors just disable recall
+func previousPrompts() []string {
+	entries, err := history.DefaultStore().List()
+	if err != nil {
+		return nil
+	}
+	return history.Prompts(entries)
+}
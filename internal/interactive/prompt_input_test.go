@@ -0,0 +1,100 @@
+package interactive
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// press feeds keys to the model and returns the result
+func press(m promptModel, keys ...tea.KeyMsg) promptModel {
+	for _, key := range keys {
+		next, _ := m.Update(key)
+		m = next.(promptModel)
+	}
+	return m
+}
+
+// typeText feeds each rune as a key press
+func typeText(m promptModel, text string) promptModel {
+	for _, r := range text {
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestPromptRecall(t *testing.T) {
+	m := newPromptModel([]string{"camera", "rocket"})
+	m = typeText(m, "dra")
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyUp})
+	if got := m.input.Value(); got != "camera" {
+		t.Errorf("first Up = %q, want newest prompt", got)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp})
+	if got := m.input.Value(); got != "rocket" {
+		t.Errorf("Up past the oldest prompt = %q, want rocket", got)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown})
+	if got := m.input.Value(); got != "dra" {
+		t.Errorf("Down back to the draft = %q, want dra", got)
+	}
+}
+
+func TestPromptSearch(t *testing.T) {
+	m := newPromptModel([]string{"glass calculator", "neon camera lens", "rocket"})
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	if !m.searching || len(m.matches) != 3 {
+		t.Fatalf("Ctrl+R should list recent prompts, got searching=%v matches=%v", m.searching, m.matches)
+	}
+
+	m = typeText(m, "cam")
+	if len(m.matches) == 0 || m.matches[0] != "neon camera lens" {
+		t.Fatalf("search matches = %v, want neon camera lens first", m.matches)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.searching || m.input.Value() != "neon camera lens" {
+		t.Errorf("Enter should fill the prompt, got searching=%v value=%q", m.searching, m.input.Value())
+	}
+}
+
+func TestPromptSearchEscKeepsDraft(t *testing.T) {
+	m := newPromptModel([]string{"rocket"})
+	m = typeText(m, "draft")
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlR})
+	m = typeText(m, "roc")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	if m.searching || m.quitting || m.input.Value() != "draft" {
+		t.Errorf("Esc in search should return to the draft, got searching=%v quitting=%v value=%q",
+			m.searching, m.quitting, m.input.Value())
+	}
+}
+
+func TestPromptEnterValidates(t *testing.T) {
+	m := newPromptModel(nil)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if !errors.Is(m.err, ErrEmptyPrompt) {
+		t.Errorf("Enter on empty prompt error = %v, want ErrEmptyPrompt", m.err)
+	}
+
+	m = typeText(m, "x")
+	if m.err != nil {
+		t.Errorf("typing should clear the error, got %v", m.err)
+	}
+}
+
+func TestSearchHistory(t *testing.T) {
+	prompts := []string{"a", "b", "c", "d", "e", "f"}
+	if got := searchHistory("", prompts, 3); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("empty query = %v, want the three most recent", got)
+	}
+	if got := searchHistory("zzz", prompts, 3); len(got) != 0 {
+		t.Errorf("unmatched query = %v, want none", got)
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 var ErrEmptyAPIKey = errors.New("API key cannot be empty")
 var ErrSkipAPIKey = errors.New("user skipped API key setup")
+var ErrEmptyValue = errors.New("value cannot be empty")
 
 // checkUserQuit checks if the error indicates user quit
 func checkUserQuit(err error) error {
@@ -50,6 +51,15 @@ func validateAPIKey(apiKey string) error {
 	return nil
 }
 
+// validateBaseURL allows an empty value (the default is used) or a valid URL
+func validateBaseURL(baseURL string) error {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return nil
+	}
+	return config.ValidateBaseURL(baseURL)
+}
+
 // SetupWizard runs the initial setup wizard for first-time users
 func SetupWizard() error {
 	fmt.Println()
@@ -74,24 +84,43 @@ func SetupWizard() error {
 	// Clear screen after language selection
 	fmt.Print("\033[2J\033[H") // Clear screen and move cursor to top
 
-	// Step 2: Base URL configuration
-	if err := setupBaseURL(configService); err != nil {
+	// Step 2: Provider selection
+	provider, err := setupProvider(configService)
+	if err != nil {
 		if errors.Is(err, ErrUserQuit) {
 			return ErrUserQuit
 		}
 		return err
 	}
 
-	// Step 2: API Key configuration
-	if err := setupAPIKey(configService); err != nil {
-		if errors.Is(err, ErrSkipAPIKey) {
-			// User pressed Ctrl+C to quit, return special error
-			return ErrUserQuit
+	if provider == types.ProviderAzure {
+		// Step 3: Azure OpenAI resource, deployment and credentials
+		if err := setupAzure(configService); err != nil {
+			if errors.Is(err, ErrUserQuit) || errors.Is(err, ErrSkipAPIKey) {
+				return ErrUserQuit
+			}
+			return err
+		}
+	} else {
+		// Step 3: Base URL configuration
+		if err := setupBaseURL(configService); err != nil {
+			if errors.Is(err, ErrUserQuit) {
+				return ErrUserQuit
+			}
+			return err
+		}
+
+		// Step 4: API Key configuration
+		if err := setupAPIKey(configService); err != nil {
+			if errors.Is(err, ErrSkipAPIKey) {
+				// User pressed Ctrl+C to quit, return special error
+				return ErrUserQuit
+			}
+			return err
 		}
-		return err
 	}
 
-	// Step 3: Output directory configuration
+	// Step 5: Output directory configuration
 	if err := setupOutputDirectory(configService); err != nil {
 		if errors.Is(err, ErrUserQuit) {
 			return ErrUserQuit
@@ -158,15 +187,139 @@ func setupLanguage(configService *config.Service) error {
 	return nil
 }
 
+// setupProvider handles API provider selection
+func setupProvider(configService *config.Service) (string, error) {
+	cfg := &choose.Config{
+		Title:    i18n.T("interactive_provider_prompt"),
+		ErrorMsg: i18n.T("interactive_provider_error"),
+	}
+
+	entries := []list.Item{
+		choose.Item{Name: i18n.T("interactive_provider_openai"), Desc: i18n.T("interactive_provider_openai_desc")},
+		choose.Item{Name: i18n.T("interactive_provider_azure"), Desc: i18n.T("interactive_provider_azure_desc")},
+	}
+
+	result, err := choose.Run(cfg, entries)
+	if err := checkUserQuit(err); err != nil {
+		return "", err
+	}
+
+	provider := types.ProviderOpenAI
+	if result == i18n.T("interactive_provider_azure") {
+		provider = types.ProviderAzure
+	}
+
+	if err := configService.UpdateConfig(map[string]interface{}{
+		"provider": provider,
+	}); err != nil {
+		return "", err
+	}
+
+	return provider, nil
+}
+
+// setupAzure handles Azure OpenAI endpoint, deployment and credential configuration
+func setupAzure(configService *config.Service) error {
+	endpoint, err := runSetupInput(i18n.T("interactive_azure_endpoint_prompt"), "", "https://YOUR-RESOURCE.openai.azure.com", false, config.ValidateBaseURL)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := runSetupInput(i18n.T("interactive_azure_deployment_prompt"), "", "gpt-image-1", false, validateRequired)
+	if err != nil {
+		return err
+	}
+
+	apiVersion, err := runSetupInput(i18n.T("interactive_azure_api_version_prompt"), types.DefaultAzureAPIVersion, "", false, nil)
+	if err != nil {
+		return err
+	}
+
+	authCfg := &choose.Config{
+		Title:    i18n.T("interactive_azure_auth_prompt"),
+		ErrorMsg: i18n.T("interactive_provider_error"),
+	}
+	authEntries := []list.Item{
+		choose.Item{Name: i18n.T("config_azure_auth_key"), Desc: i18n.T("config_azure_auth_key")},
+		choose.Item{Name: i18n.T("config_azure_auth_aad"), Desc: i18n.T("config_azure_auth_aad")},
+	}
+	authResult, err := choose.Run(authCfg, authEntries)
+	if err := checkUserQuit(err); err != nil {
+		return err
+	}
+
+	auth := types.AzureAuthKey
+	var apiKey, tokenCommand string
+	if authResult == i18n.T("config_azure_auth_aad") {
+		auth = types.AzureAuthAAD
+		fmt.Println(utils.Gray(i18n.Tf("interactive_azure_token_help", types.AzureADTokenEnv)))
+		tokenCommand, err = runSetupInput(i18n.T("interactive_azure_token_command_prompt"),
+			"az account get-access-token --resource https://cognitiveservices.azure.com --query accessToken -o tsv", "", false, nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		apiKey, err = runSetupInput(i18n.T("interactive_api_key_prompt"), "", "", true, validateAPIKey)
+		if err != nil {
+			return ErrSkipAPIKey
+		}
+	}
+
+	if strings.TrimSpace(apiVersion) == "" {
+		apiVersion = types.DefaultAzureAPIVersion
+	}
+
+	return configService.UpdateAzureConfig(func(azure *types.AzureConfig) error {
+		azure.Endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+		azure.APIVersion = strings.TrimSpace(apiVersion)
+		azure.Auth = auth
+		azure.APIKey = strings.TrimSpace(apiKey)
+		azure.TokenCommand = strings.TrimSpace(tokenCommand)
+		azure.Deployments = map[string]string{
+			strings.TrimSpace(deployment): types.ModelGPTImage1,
+		}
+		return nil
+	})
+}
+
+// runSetupInput shows a single text input and returns the trimmed result
+func runSetupInput(message, initial, placeholder string, password bool, validate func(string) error) (string, error) {
+	cfg := &input.Config{
+		Message:      message,
+		Initial:      initial,
+		Placeholder:  placeholder,
+		Password:     password,
+		ValidateFunc: validate,
+		ShowResult:   false,
+		Styles:       input.DefaultStyles(),
+	}
+
+	result, err := input.Run(cfg)
+	if err := checkUserQuit(err); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+// validateRequired rejects empty input
+func validateRequired(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return ErrEmptyValue
+	}
+	return nil
+}
+
 // setupBaseURL handles base URL configuration
 func setupBaseURL(configService *config.Service) error {
 	defaultURL := types.DefaultValues.BaseURL
 
 	cfg := &input.Config{
-		Message:     i18n.T("interactive_base_url_prompt"),
-		Initial:     defaultURL,
-		ShowResult:  false,
-		Styles:      input.DefaultStyles(),
+		Message:      i18n.T("interactive_base_url_prompt"),
+		Initial:      defaultURL,
+		ValidateFunc: validateBaseURL,
+		ShowResult:   false,
+		Styles:       input.DefaultStyles(),
 	}
 
 	result, err := input.Run(cfg)
@@ -174,6 +327,7 @@ func setupBaseURL(configService *config.Service) error {
 		return err
 	}
 
+	result = strings.TrimSpace(result)
 	if result == "" {
 		result = defaultURL
 	}
@@ -0,0 +1,1121 @@
+package interactive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"just-icon/internal/brand"
+	"just-icon/internal/gallery"
+	"just-icon/internal/generator"
+	"just-icon/internal/i18n"
+	"just-icon/internal/iconset"
+	"just-icon/internal/openai"
+	"just-icon/internal/presets"
+	"just-icon/internal/preview"
+	"just-icon/internal/progress"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// workspaceField is a row of the options panel
+type workspaceField int
+
+const (
+	fieldPrompt workspaceField = iota
+	fieldPreset
+	fieldTemplate
+	fieldSize
+	fieldQuality
+	fieldBackground
+	fieldFormat
+	fieldQuantity
+	fieldModeration
+	fieldRaw
+	fieldEnhance
+	fieldBrand
+	fieldOutput
+	// fieldResults focuses the results pane for reviewing images
+	fieldResults
+)
+
+// runKind tells how a run was started
+type runKind int
+
+const (
+	runGenerate runKind = iota
+	// runSimilar generates variations of an image
+	runSimilar
+	// runEdit changes an image with an instruction
+	runEdit
+)
+
+const (
+	// stackedWidth is the terminal width below which the results pane moves
+	// under the options panel
+	stackedWidth = 96
+	// optionsWidth is the width of the options panel beside the results
+	optionsWidth = 46
+	// labelWidth aligns the option values
+	labelWidth = 13
+	// visibleRuns is the number of runs listed in the results pane
+	visibleRuns = 5
+	// maxPreviewCols caps the preview width in cells
+	maxPreviewCols = 40
+)
+
+var (
+	workspaceBorderStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(recallPurple).Padding(0, 1)
+	workspaceTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(recallPurple)
+	workspaceFaintStyle  = lipgloss.NewStyle().Faint(true)
+	workspaceOkStyle     = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#16a34a", Dark: "#22c55e"})
+	workspaceWarnStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#ca8a04", Dark: "#eab308"})
+)
+
+// workspaceRun is one generation started from the workspace
+type workspaceRun struct {
+	options types.IconGenerationOptions
+	preset  string
+	kind    runKind
+	started time.Time
+	elapsed time.Duration
+	done    bool
+	outcome *generator.Outcome
+	err     error
+	// tracker follows the request while it runs
+	tracker *progress.Tracker
+
+	// images are the saved images under review and current the selected one
+	images  []*reviewImage
+	current int
+}
+
+// reviewImage is a saved image and what the user decided about it
+type reviewImage struct {
+	path      string
+	kept      bool
+	discarded bool
+
+	// image is the decoded image, nil when previews are off
+	image image.Image
+	// rendered caches the preview drawn at renderedSize
+	rendered     string
+	renderedSize preview.Cells
+}
+
+// generateDoneMsg reports the end of a generation
+type generateDoneMsg struct {
+	run     *workspaceRun
+	outcome *generator.Outcome
+	err     error
+}
+
+// exportDoneMsg reports the end of an icon set export
+type exportDoneMsg struct {
+	dir string
+	err error
+}
+
+// enhanceDoneMsg reports the end of a prompt enhancement
+type enhanceDoneMsg struct {
+	original string
+	enhanced string
+	err      error
+}
+
+// workspaceModel is the full-screen generation workspace: an options panel
+// for every generation setting, a prompt with history recall and a results
+// pane listing the runs of the session
+type workspaceModel struct {
+	cfg *types.Config
+
+	// options holds the panel's settings; the prompt and output directory
+	// live in their inputs
+	options   *types.IconGenerationOptions
+	preset    string
+	presetSet []string
+	templates []string
+	enhance   bool
+	useBrand  bool
+
+	prompt promptModel
+	output textinput.Model
+	fields []workspaceField
+	focus  int
+
+	// instruction is the edit instruction, typed while instructing is set
+	instruction textinput.Model
+	instructing bool
+	// confirmDiscard is the image waiting for a second press to be deleted
+	confirmDiscard string
+
+	// original is the prompt before enhancement, empty when the prompt was
+	// not enhanced
+	original string
+	// reviewed is set once the prompt was enhanced or the enhancement was
+	// declined, so Enter generates instead of enhancing again
+	reviewed bool
+
+	busy      bool
+	enhancing bool
+	spinner   spinner.Model
+	runs      []*workspaceRun
+	selected  int
+	status    string
+
+	width  int
+	height int
+	// preview is the terminal's preview protocol; inside the workspace it is
+	// drawn with workspacePreview
+	preview preview.Protocol
+
+	// ctx is canceled when the workspace closes, stopping the requests in
+	// flight
+	ctx context.Context
+
+	// generate, estimate and enhancePrompt do the work; tests replace them
+	generate      func(context.Context, *types.IconGenerationOptions, string, *progress.Reporter) (*generator.Outcome, error)
+	estimate      func(*types.IconGenerationOptions) time.Duration
+	enhancePrompt func(context.Context, string) (string, error)
+	gallery       *gallery.Store
+}
+
+// newWorkspace creates the workspace with the configured defaults, the
+// prompt history (newest first) and the preselected choices
+func newWorkspace(cfg *types.Config, previous []string, opts Options, outputDir string) workspaceModel {
+	m := workspaceModel{
+		cfg:         cfg,
+		options:     generator.NewOptions(),
+		enhance:     opts.Enhance || (cfg.Enhance != nil && cfg.Enhance.Enabled),
+		useBrand:    !brand.IsEmpty(cfg.Brand),
+		prompt:      newPromptModel(previous),
+		output:      textinput.New(),
+		instruction: textinput.New(),
+		spinner:     spinner.New(spinner.WithSpinner(spinner.Dot), spinner.WithStyle(recallActiveStyle)),
+		selected:    -1,
+		ctx:         context.Background(),
+		width:       stackedWidth,
+		height:      30,
+		preview:     preview.Blocks,
+		generate:    generator.Run,
+		estimate:    generator.EstimateDuration,
+		gallery:     gallery.DefaultStore(),
+		enhancePrompt: func(ctx context.Context, prompt string) (string, error) {
+			client, err := openai.NewClientFromConfig()
+			if err != nil {
+				return "", err
+			}
+			return client.EnhancePrompt(ctx, prompt, cfg.Enhance)
+		},
+	}
+
+	m.presetSet = []string{""}
+	for _, preset := range presets.List(cfg.Presets) {
+		m.presetSet = append(m.presetSet, preset.Name)
+	}
+	defaultTemplate := cfg.DefaultTemplate
+	if defaultTemplate == "" {
+		defaultTemplate = templates.Default
+	}
+	m.templates = []string{defaultTemplate}
+	for _, name := range templates.Names(cfg.Templates) {
+		if name != defaultTemplate {
+			m.templates = append(m.templates, name)
+		}
+	}
+
+	m.fields = []workspaceField{fieldPrompt, fieldPreset, fieldTemplate, fieldSize, fieldQuality, fieldBackground,
+		fieldFormat, fieldQuantity, fieldModeration, fieldRaw, fieldEnhance}
+	if m.useBrand {
+		m.fields = append(m.fields, fieldBrand)
+	}
+	m.fields = append(m.fields, fieldOutput, fieldResults)
+
+	m.instruction.Prompt = promptTitle(i18n.T("workspace_edit_instruction"))
+	m.output.Prompt = ""
+	m.output.SetValue(outputDir)
+	m.output.Placeholder = types.DefaultValues.OutputPath
+
+	m.selectPreset(opts.Preset, opts.Template)
+	return m
+}
+
+// selectPreset resets the panel to the defaults and applies the named
+// preset; template, when set, overrides the preset's template
+func (m *workspaceModel) selectPreset(name, template string) {
+	m.preset = name
+	m.options.Template = m.templates[0]
+	m.options.Size = types.DefaultValues.Size
+	m.options.Quality = types.DefaultValues.Quality
+	m.options.Background = types.DefaultValues.Background
+	m.options.OutputFormat = types.DefaultValues.OutputFormat
+	m.options.NumImages = types.DefaultValues.NumImages
+	if preset, ok := presets.Lookup(name, m.cfg.Presets); ok {
+		presets.Apply(preset.Preset, m.options)
+	}
+	if template != "" {
+		m.options.Template = template
+	}
+}
+
+// Init starts the cursor blinking
+func (m workspaceModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles key presses, finished work and resizes
+func (m workspaceModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.prompt.input.Width = max(10, msg.Width-runewidth.StringWidth(i18n.T("interactive_prompt_input"))-8)
+		m.output.Width = max(10, m.panelWidth()-labelWidth-6)
+		return m, nil
+	case spinner.TickMsg:
+		if !m.busy {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case enhanceDoneMsg:
+		return m.finishEnhance(msg), nil
+	case generateDoneMsg:
+		return m.finishGenerate(msg), nil
+	case exportDoneMsg:
+		if msg.err != nil {
+			m.status = workspaceWarnStyle.Render(i18n.Tf("workspace_export_failed", msg.err.Error()))
+		} else {
+			m.status = workspaceOkStyle.Render(i18n.Tf("workspace_exported", msg.dir))
+		}
+		return m, nil
+	case tea.KeyMsg:
+		return m.updateKey(msg)
+	}
+
+	var cmd tea.Cmd
+	if m.instructing {
+		m.instruction, cmd = m.instruction.Update(msg)
+		return m, cmd
+	}
+	switch m.fields[m.focus] {
+	case fieldPrompt:
+		var model tea.Model
+		model, cmd = m.prompt.Update(msg)
+		m.prompt = model.(promptModel)
+	case fieldOutput:
+		m.output, cmd = m.output.Update(msg)
+	}
+	return m, cmd
+}
+
+// updateKey dispatches a key press to the focused field or the workspace
+func (m workspaceModel) updateKey(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	field := m.fields[m.focus]
+	if key.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	if m.instructing {
+		return m.updateInstruction(key)
+	}
+	// A pending discard only survives its confirming key press
+	confirm := m.confirmDiscard
+	m.confirmDiscard = ""
+	// The history search handles its own keys until it closes
+	if field == fieldPrompt && m.prompt.searching {
+		return m.updatePrompt(key)
+	}
+
+	switch key.Type {
+	case tea.KeyEsc:
+		return m, tea.Quit
+	case tea.KeyTab:
+		m.moveFocus(1)
+		return m, nil
+	case tea.KeyShiftTab:
+		m.moveFocus(-1)
+		return m, nil
+	case tea.KeyEnter:
+		return m.submit()
+	case tea.KeyCtrlE:
+		return m.startEnhance()
+	case tea.KeyCtrlO:
+		m.restoreOriginal()
+		return m, nil
+	case tea.KeyPgUp:
+		m.selectRun(-1)
+		return m, nil
+	case tea.KeyPgDown:
+		m.selectRun(1)
+		return m, nil
+	}
+
+	switch field {
+	case fieldPrompt:
+		return m.updatePrompt(key)
+	case fieldResults:
+		return m.updateResults(key, confirm)
+	case fieldOutput:
+		switch key.Type {
+		case tea.KeyUp:
+			m.moveFocus(-1)
+			return m, nil
+		case tea.KeyDown:
+			m.moveFocus(1)
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.output, cmd = m.output.Update(key)
+		return m, cmd
+	}
+
+	switch key.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.moveFocus(-1)
+	case "down", "j":
+		m.moveFocus(1)
+	case "left", "h":
+		m.change(field, -1)
+	case "right", "l", " ":
+		m.change(field, 1)
+	case "[":
+		m.selectRun(-1)
+	case "]":
+		m.selectRun(1)
+	}
+	return m, nil
+}
+
+// updatePrompt passes a key to the prompt input. Recalling another prompt
+// or clearing it starts over with enhancement.
+func (m workspaceModel) updatePrompt(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	before := m.prompt.input.Value()
+	recalled := m.prompt.searching || key.Type == tea.KeyUp || key.Type == tea.KeyDown
+
+	model, cmd := m.prompt.Update(key)
+	m.prompt = model.(promptModel)
+
+	if after := m.prompt.input.Value(); after != before && (recalled || strings.TrimSpace(after) == "") {
+		m.original = ""
+		m.reviewed = false
+	}
+	return m, cmd
+}
+
+// moveFocus moves the focus by delta rows, wrapping around
+func (m *workspaceModel) moveFocus(delta int) {
+	m.focusField(m.fields[(m.focus+delta+len(m.fields))%len(m.fields)])
+}
+
+// focusField moves the focus to field and focuses its text input
+func (m *workspaceModel) focusField(field workspaceField) {
+	for i, f := range m.fields {
+		if f == field {
+			m.focus = i
+		}
+	}
+	m.prompt.input.Blur()
+	m.output.Blur()
+	switch m.fields[m.focus] {
+	case fieldPrompt:
+		m.prompt.input.Focus()
+	case fieldOutput:
+		m.output.Focus()
+	}
+}
+
+// change steps the value of a choice field by delta and flips toggles
+func (m *workspaceModel) change(field workspaceField, delta int) {
+	model := m.options.Model
+	switch field {
+	case fieldPreset:
+		m.selectPreset(cycle(m.presetSet, m.preset, delta), "")
+	case fieldTemplate:
+		m.options.Template = cycle(m.templates, m.options.Template, delta)
+	case fieldSize:
+		m.options.Size = cycle(types.SupportedSizes[model], m.options.Size, delta)
+	case fieldQuality:
+		m.options.Quality = cycle(types.SupportedQualities[model], m.options.Quality, delta)
+	case fieldBackground:
+		m.options.Background = cycle(types.SupportedBackgrounds[model], m.options.Background, delta)
+	case fieldFormat:
+		m.options.OutputFormat = cycle(types.SupportedOutputFormats[model], m.options.OutputFormat, delta)
+	case fieldModeration:
+		m.options.Moderation = cycle(types.SupportedModerations[model], m.options.Moderation, delta)
+	case fieldQuantity:
+		m.options.NumImages = min(types.MaxImages, max(types.MinImages, m.options.NumImages+delta))
+	case fieldRaw:
+		m.options.RawPrompt = !m.options.RawPrompt
+	case fieldEnhance:
+		m.enhance = !m.enhance
+	case fieldBrand:
+		m.useBrand = !m.useBrand
+	}
+}
+
+// cycle returns the value delta steps from current in values, wrapping
+// around; an unknown current value starts from the first
+func cycle(values []string, current string, delta int) string {
+	if len(values) == 0 {
+		return current
+	}
+	index := 0
+	for i, value := range values {
+		if value == current {
+			index = i
+			break
+		}
+	}
+	return values[((index+delta)%len(values)+len(values))%len(values)]
+}
+
+// selectRun moves the result selection by delta
+func (m *workspaceModel) selectRun(delta int) {
+	if len(m.runs) > 0 {
+		m.selected = min(len(m.runs)-1, max(0, m.selected+delta))
+	}
+}
+
+// submit enhances the prompt first when enhancement is on and the prompt
+// has not been reviewed yet, and otherwise starts a generation
+func (m workspaceModel) submit() (tea.Model, tea.Cmd) {
+	if m.busy {
+		m.status = i18n.T("workspace_busy")
+		return m, nil
+	}
+	prompt := strings.TrimSpace(m.prompt.input.Value())
+	if err := validatePrompt(prompt); err != nil {
+		m.prompt.err = err
+		m.focusField(fieldPrompt)
+		return m, nil
+	}
+	if m.enhance && !m.reviewed {
+		return m.startEnhance()
+	}
+
+	options := *m.options
+	options.Prompt = prompt
+	options.OriginalPrompt = m.original
+	options.Output = strings.TrimSpace(m.output.Value())
+	if options.Output == "" {
+		options.Output = types.DefaultValues.OutputPath
+	}
+	options.Brand = nil
+	if m.useBrand {
+		options.Brand = m.cfg.Brand
+	}
+	return m.startRun(&options, m.preset, runGenerate)
+}
+
+// startRun starts a generation with the given options in the background
+func (m workspaceModel) startRun(options *types.IconGenerationOptions, preset string, kind runKind) (tea.Model, tea.Cmd) {
+	run := &workspaceRun{options: *options, preset: preset, kind: kind, started: time.Now()}
+	run.tracker = progress.NewTracker([]string{""}, m.estimate(options))
+	m.runs = append(m.runs, run)
+	m.selected = len(m.runs) - 1
+	m.busy = true
+	m.status = ""
+	generate, ctx := m.generate, m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		outcome, err := generate(ctx, &run.options, run.preset, run.tracker.Reporter(0))
+		return generateDoneMsg{run: run, outcome: outcome, err: err}
+	})
+}
+
+// startEnhance expands the current prompt with the chat model
+func (m workspaceModel) startEnhance() (tea.Model, tea.Cmd) {
+	if m.busy {
+		m.status = i18n.T("workspace_busy")
+		return m, nil
+	}
+	prompt := strings.TrimSpace(m.prompt.input.Value())
+	if err := validatePrompt(prompt); err != nil {
+		m.prompt.err = err
+		return m, nil
+	}
+	original := m.original
+	if original == "" {
+		original = prompt
+	}
+
+	m.busy = true
+	m.enhancing = true
+	m.status = ""
+	enhance, ctx := m.enhancePrompt, m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		enhanced, err := enhance(ctx, prompt)
+		return enhanceDoneMsg{original: original, enhanced: enhanced, err: err}
+	})
+}
+
+// finishEnhance puts the enhanced prompt up for review. When enhancement
+// fails the prompt is kept as it is.
+func (m workspaceModel) finishEnhance(msg enhanceDoneMsg) workspaceModel {
+	m.busy = false
+	m.enhancing = false
+	m.reviewed = true
+	if msg.err != nil {
+		m.status = workspaceWarnStyle.Render(i18n.Tf("interactive_enhance_failed", msg.err.Error()))
+		return m
+	}
+	m.original = msg.original
+	m.prompt.input.SetValue(strings.TrimSpace(msg.enhanced))
+	m.prompt.input.CursorEnd()
+	m.prompt.err = nil
+	m.status = i18n.T("workspace_enhance_review")
+	return m
+}
+
+// restoreOriginal swaps an enhanced prompt back to what the user typed
+func (m *workspaceModel) restoreOriginal() {
+	if m.original == "" {
+		return
+	}
+	m.prompt.input.SetValue(m.original)
+	m.prompt.input.CursorEnd()
+	m.original = ""
+	m.reviewed = true
+	m.status = i18n.T("workspace_original_restored")
+}
+
+// finishGenerate records the outcome of a run and loads its preview
+func (m workspaceModel) finishGenerate(msg generateDoneMsg) workspaceModel {
+	m.busy = false
+	run := msg.run
+	run.done = true
+	run.elapsed = time.Since(run.started)
+	run.outcome = msg.outcome
+	run.err = msg.err
+	if msg.err != nil {
+		m.status = workspaceWarnStyle.Render(i18n.Tf("workspace_generate_failed", msg.err.Error()))
+		return m
+	}
+
+	m.status = workspaceOkStyle.Render(i18n.Tf("workspace_generate_done", len(msg.outcome.Files), msg.outcome.OutputDir))
+	for _, path := range msg.outcome.Files {
+		review := &reviewImage{path: path}
+		if m.preview != preview.Off {
+			review.image, _ = utils.LoadImage(path)
+		}
+		run.images = append(run.images, review)
+	}
+
+	// Move on to reviewing the new images
+	if len(run.images) > 0 && m.runs[m.selected] == run {
+		m.focusField(fieldResults)
+	}
+	return m
+}
+
+// updateResults handles the review keys while the results pane is focused
+func (m workspaceModel) updateResults(key tea.KeyMsg, confirm string) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.moveFocus(-1)
+		return m, nil
+	case "down", "j":
+		m.moveFocus(1)
+		return m, nil
+	case "[":
+		m.selectRun(-1)
+		return m, nil
+	case "]":
+		m.selectRun(1)
+		return m, nil
+	}
+
+	review := m.currentImage()
+	if review == nil {
+		return m, nil
+	}
+	run := m.runs[m.selected]
+
+	switch key.String() {
+	case "left", "h":
+		run.current = max(0, run.current-1)
+	case "right", "l":
+		run.current = min(len(run.images)-1, run.current+1)
+	case "s":
+		m.keep(review)
+	case "x":
+		if review.discarded {
+			return m, nil
+		}
+		if confirm != review.path {
+			m.confirmDiscard = review.path
+			m.status = workspaceWarnStyle.Render(i18n.Tf("workspace_discard_confirm", filepath.Base(review.path)))
+			return m, nil
+		}
+		m.discard(review)
+	case "r":
+		if review.discarded {
+			return m, nil
+		}
+		if m.busy {
+			m.status = i18n.T("workspace_busy")
+			return m, nil
+		}
+		return m.startRun(generator.SimilarOptions(run.options, review.path), run.preset, runSimilar)
+	case "e":
+		if review.discarded {
+			return m, nil
+		}
+		m.instructing = true
+		m.instruction.SetValue("")
+		m.instruction.Focus()
+		return m, textinput.Blink
+	case "p":
+		if review.discarded {
+			return m, nil
+		}
+		path := review.path
+		m.status = i18n.T("workspace_exporting")
+		return m, func() tea.Msg {
+			dir := iconset.DefaultDir(path)
+			_, err := iconset.Export(path, dir, iconset.Targets)
+			return exportDoneMsg{dir: dir, err: err}
+		}
+	case "c":
+		if err := utils.CopyToClipboard(review.path); err != nil {
+			m.status = workspaceWarnStyle.Render(i18n.Tf("workspace_copy_failed", err.Error()))
+		} else {
+			m.status = i18n.Tf("workspace_copied", review.path)
+		}
+	}
+	return m, nil
+}
+
+// updateInstruction handles keys while the edit instruction is typed
+func (m workspaceModel) updateInstruction(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.Type {
+	case tea.KeyEsc:
+		m.instructing = false
+		m.instruction.Blur()
+		return m, nil
+	case tea.KeyEnter:
+		instruction := strings.TrimSpace(m.instruction.Value())
+		if instruction == "" {
+			return m, nil
+		}
+		if m.busy {
+			m.status = i18n.T("workspace_busy")
+			return m, nil
+		}
+		m.instructing = false
+		m.instruction.Blur()
+		run := m.runs[m.selected]
+		return m.startRun(generator.EditOptions(run.options, m.currentImage().path, instruction), run.preset, runEdit)
+	}
+	var cmd tea.Cmd
+	m.instruction, cmd = m.instruction.Update(key)
+	return m, cmd
+}
+
+// currentImage returns the image selected in the results pane, nil when
+// the selected run has none
+func (m workspaceModel) currentImage() *reviewImage {
+	if m.selected < 0 || m.selected >= len(m.runs) {
+		return nil
+	}
+	run := m.runs[m.selected]
+	if run.current >= len(run.images) {
+		return nil
+	}
+	return run.images[run.current]
+}
+
+// keep toggles an image as a favorite in the gallery
+func (m *workspaceModel) keep(review *reviewImage) {
+	entry, err := m.galleryEntry(review.path)
+	if err == nil {
+		err = m.gallery.SetFavorite([]string{entry.ID}, !review.kept)
+	}
+	if err != nil {
+		m.status = workspaceWarnStyle.Render(i18n.Tf("gallery_failed_to_read", err.Error()))
+		return
+	}
+	review.kept = !review.kept
+	if review.kept {
+		m.status = workspaceOkStyle.Render(i18n.Tf("workspace_kept", filepath.Base(review.path)))
+	} else {
+		m.status = i18n.Tf("workspace_unkept", filepath.Base(review.path))
+	}
+}
+
+// discard deletes an image, its sidecar and its gallery entry
+func (m *workspaceModel) discard(review *reviewImage) {
+	entry, err := m.galleryEntry(review.path)
+	if err == nil {
+		_, err = m.gallery.Delete([]string{entry.ID})
+	}
+	if err != nil {
+		m.status = workspaceWarnStyle.Render(i18n.Tf("workspace_discard_failed", err.Error()))
+		return
+	}
+	review.discarded = true
+	review.kept = false
+	m.status = i18n.Tf("workspace_discarded", filepath.Base(review.path))
+}
+
+// galleryEntry finds the gallery entry of a saved image, indexing the image
+// when the gallery missed it
+func (m workspaceModel) galleryEntry(path string) (*gallery.Entry, error) {
+	entry, err := m.gallery.Find(path)
+	if !errors.Is(err, gallery.ErrNotFound) {
+		return entry, err
+	}
+	if _, err := m.gallery.Add(gallery.Entry{Path: path}); err != nil {
+		return nil, err
+	}
+	return m.gallery.Find(path)
+}
+
+// activeRun returns the run being generated, nil when there is none
+func (m workspaceModel) activeRun() *workspaceRun {
+	for i := len(m.runs) - 1; i >= 0; i-- {
+		if !m.runs[i].done {
+			return m.runs[i]
+		}
+	}
+	return nil
+}
+
+// panelWidth is the width of the options panel, including its border
+func (m workspaceModel) panelWidth() int {
+	if m.width < stackedWidth {
+		return m.width
+	}
+	return optionsWidth
+}
+
+// View renders the prompt, the options panel, the results and the help line
+func (m workspaceModel) View() string {
+	title := workspaceTitleStyle.Render("just-icon") + workspaceFaintStyle.Render(" · "+m.options.Model)
+	footer := []string{workspaceFaintStyle.Render(i18n.T("workspace_help"))}
+	if m.busy {
+		label := i18n.T("workspace_generating")
+		if m.enhancing {
+			label = i18n.T("interactive_enhancing_spinner")
+		} else if run := m.activeRun(); run != nil {
+			label += " " + run.tracker.Line(0, time.Now())
+		}
+		footer = append(footer, m.spinner.View()+" "+label)
+	} else if m.status != "" {
+		footer = append(footer, m.status)
+	}
+
+	top := lipgloss.JoinVertical(lipgloss.Left, title, m.prompt.View())
+	bottom := strings.Join(footer, "\n")
+	available := max(0, m.height-lipgloss.Height(top)-lipgloss.Height(bottom))
+
+	optionsPanel := workspaceBorderStyle.Width(m.panelWidth() - 2).Render(m.optionsView())
+	var body string
+	if m.width < stackedWidth {
+		resultsHeight := available - lipgloss.Height(optionsPanel)
+		body = lipgloss.JoinVertical(lipgloss.Left, optionsPanel, m.resultsPanel(m.width, resultsHeight))
+	} else {
+		results := m.resultsPanel(m.width-optionsWidth, available)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, optionsPanel, results)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, top, body, bottom)
+}
+
+// optionsView renders one row per setting, marking the focused one
+func (m workspaceModel) optionsView() string {
+	lines := []string{workspaceTitleStyle.Render(i18n.T("workspace_options"))}
+	for i, field := range m.fields {
+		if field == fieldPrompt || field == fieldResults {
+			continue
+		}
+		focused := i == m.focus
+		marker := "  "
+		if focused {
+			marker = recallActiveStyle.Render("› ")
+		}
+		label := runewidth.FillRight(runewidth.Truncate(m.fieldLabel(field), labelWidth-1, "…"), labelWidth)
+		lines = append(lines, marker+label+m.fieldValue(field, focused))
+	}
+	lines = append(lines, "  "+runewidth.FillRight(i18n.T("workspace_field_model"), labelWidth)+workspaceFaintStyle.Render(m.options.Model))
+	return strings.Join(lines, "\n")
+}
+
+// fieldLabel is the localized name of a field
+func (m workspaceModel) fieldLabel(field workspaceField) string {
+	keys := map[workspaceField]string{
+		fieldPreset:     "workspace_field_preset",
+		fieldTemplate:   "workspace_field_template",
+		fieldSize:       "workspace_field_size",
+		fieldQuality:    "workspace_field_quality",
+		fieldBackground: "workspace_field_background",
+		fieldFormat:     "workspace_field_format",
+		fieldQuantity:   "workspace_field_quantity",
+		fieldModeration: "workspace_field_moderation",
+		fieldRaw:        "workspace_field_raw",
+		fieldEnhance:    "workspace_field_enhance",
+		fieldBrand:      "workspace_field_brand",
+		fieldOutput:     "workspace_field_output",
+	}
+	return i18n.T(keys[field])
+}
+
+// fieldValue renders the current value of a field
+func (m workspaceModel) fieldValue(field workspaceField, focused bool) string {
+	var value string
+	switch field {
+	case fieldOutput:
+		return m.output.View()
+	case fieldRaw:
+		return toggleView(m.options.RawPrompt)
+	case fieldEnhance:
+		return toggleView(m.enhance)
+	case fieldBrand:
+		return toggleView(m.useBrand)
+	case fieldPreset:
+		value = m.preset
+		if value == "" {
+			value = i18n.T("interactive_preset_none")
+		}
+	case fieldTemplate:
+		value = m.options.Template
+	case fieldSize:
+		value = m.options.Size
+	case fieldQuality:
+		value = m.options.Quality
+	case fieldBackground:
+		value = m.options.Background
+	case fieldFormat:
+		value = m.options.OutputFormat
+	case fieldQuantity:
+		value = fmt.Sprintf("%d", m.options.NumImages)
+	case fieldModeration:
+		value = m.options.Moderation
+	}
+	if focused {
+		return recallActiveStyle.Render("‹ " + value + " ›")
+	}
+	return value
+}
+
+// toggleView renders an on/off setting
+func toggleView(on bool) string {
+	if on {
+		return recallActiveStyle.Render("[x]")
+	}
+	return "[ ]"
+}
+
+// resultsPanel renders the runs of the session and details of the selected
+// one in a bordered box of the given outer size
+func (m workspaceModel) resultsPanel(width, height int) string {
+	inner := max(10, width-4)
+	focused := m.fields[m.focus] == fieldResults
+	title := workspaceTitleStyle.Render(i18n.T("workspace_results"))
+	if focused {
+		title = recallActiveStyle.Render("› " + i18n.T("workspace_results"))
+	}
+	lines := []string{title}
+	if len(m.runs) == 0 {
+		lines = append(lines, workspaceFaintStyle.Render(i18n.T("workspace_no_results")))
+		return workspaceBorderStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+	}
+
+	start := max(0, min(m.selected-visibleRuns/2, len(m.runs)-visibleRuns))
+	for i := start; i < len(m.runs) && i < start+visibleRuns; i++ {
+		line := runewidth.Truncate(m.runSummary(i), inner-2, "…")
+		if i == m.selected {
+			lines = append(lines, recallActiveStyle.Render("› "+line))
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	run := m.runs[m.selected]
+	lines = append(lines, "")
+	for _, line := range runDetails(run) {
+		lines = append(lines, runewidth.Truncate(line, inner, "…"))
+	}
+
+	// Review actions apply to the selected image
+	if focused && len(run.images) > 0 {
+		if m.instructing {
+			lines = append(lines, m.instruction.View())
+		} else {
+			lines = append(lines, runewidth.Truncate(workspaceFaintStyle.Render(i18n.T("workspace_review_help")), inner, "…"))
+		}
+	}
+
+	// Fill the remaining height with the preview, keeping two rows for the border
+	rows := height - len(lines) - 2
+	if review := m.currentImage(); review != nil && review.image != nil && !review.discarded && rows >= 4 {
+		lines = append(lines, review.render(workspacePreview(m.preview), fitPreview(review.image.Bounds(), min(inner, maxPreviewCols), rows)))
+	}
+	return workspaceBorderStyle.Width(width - 2).Render(strings.Join(lines, "\n"))
+}
+
+// runSummary is the one-line entry of a run in the results list
+func (m workspaceModel) runSummary(index int) string {
+	run := m.runs[index]
+	prompt := run.options.Prompt
+	if run.options.OriginalPrompt != "" {
+		prompt = run.options.OriginalPrompt
+	}
+	switch run.kind {
+	case runSimilar:
+		prompt = "≈ " + prompt
+	case runEdit:
+		prompt = "✎ " + prompt
+	}
+	switch {
+	case !run.done:
+		return fmt.Sprintf("#%d %s %s", index+1, m.spinner.View(), prompt)
+	case run.err != nil:
+		return fmt.Sprintf("#%d ✘ %s", index+1, prompt)
+	default:
+		summary := fmt.Sprintf("#%d ✔ %s · %s", index+1, i18n.Tf("workspace_image_count", len(run.outcome.Files)), run.elapsed.Round(time.Second))
+		if run.outcome.Result != nil && run.outcome.Result.Cost > 0 {
+			summary += " · " + generator.FormatCost(run.outcome.Result.Cost)
+		}
+		return summary + " · " + prompt
+	}
+}
+
+// runDetails lists the settings, images and problems of a run
+func runDetails(run *workspaceRun) []string {
+	options := run.options
+	settings := []string{options.Template, options.Size, options.Quality, options.Background, options.OutputFormat}
+	if options.RawPrompt {
+		settings[0] = i18n.T("workspace_field_raw")
+	}
+	if run.preset != "" {
+		settings = append([]string{run.preset}, settings...)
+	}
+	lines := []string{workspaceFaintStyle.Render(strings.Join(settings, " · "))}
+	switch run.kind {
+	case runSimilar:
+		lines = append(lines, i18n.Tf("workspace_similar_of", filepath.Base(options.Reference)))
+	case runEdit:
+		lines = append(lines, i18n.Tf("workspace_edit_of", filepath.Base(options.Reference), options.Prompt))
+	}
+	if options.OriginalPrompt != "" {
+		lines = append(lines, i18n.T("enhanced_prompt_label")+" "+options.Prompt)
+	}
+	if !run.done {
+		return lines
+	}
+
+	for i, review := range run.images {
+		marker := "  "
+		if i == run.current {
+			marker = recallActiveStyle.Render("› ")
+		}
+		switch {
+		case review.discarded:
+			lines = append(lines, marker+workspaceFaintStyle.Render("✘ "+review.path))
+		case review.kept:
+			lines = append(lines, marker+workspaceOkStyle.Render("★ ")+review.path)
+		default:
+			lines = append(lines, marker+workspaceOkStyle.Render("✔ ")+review.path)
+		}
+	}
+	if run.outcome != nil {
+		if run.outcome.HistoryID != "" {
+			lines = append(lines, workspaceFaintStyle.Render(i18n.Tf("workspace_history_id", run.outcome.HistoryID)))
+		}
+		if run.outcome.ContactSheet != "" {
+			lines = append(lines, workspaceFaintStyle.Render(i18n.Tf("contact_sheet_written", filepath.Base(run.outcome.ContactSheet))))
+		}
+		for _, warning := range run.outcome.Warnings {
+			lines = append(lines, workspaceWarnStyle.Render("⚠ "+warning))
+		}
+	}
+	if run.err != nil {
+		lines = append(lines, workspaceWarnStyle.Render("✘ "+run.err.Error()))
+	}
+	return lines
+}
+
+// fitPreview returns the largest half-block area within maxCols x maxRows
+// that keeps the image's aspect ratio
+func fitPreview(bounds image.Rectangle, maxCols, maxRows int) preview.Cells {
+	width, height := max(1, bounds.Dx()), max(1, bounds.Dy())
+	cols := max(1, maxCols)
+	rows := (cols*height/width + 1) / 2
+	if rows > maxRows {
+		rows = maxRows
+		cols = max(1, rows*2*width/height)
+	}
+	return preview.Cells{Cols: cols, Rows: max(1, rows)}
+}
+
+// workspacePreview returns the protocol previews are drawn with inside the
+// workspace. Bubble Tea redraws the view as lines of text cells, diffing
+// them and cutting them to the window; kitty, iTerm2 and sixel images are
+// not text, so the renderer could neither place nor clear them and they
+// would linger over the layout. Those protocols fall back to half blocks
+// here, and the kept images are shown with them after the workspace closes.
+func workspacePreview(protocol preview.Protocol) preview.Protocol {
+	if protocol == preview.Off {
+		return preview.Off
+	}
+	return preview.Blocks
+}
+
+// render draws the image with the protocol, reusing the last rendering when
+// the size did not change. Only text protocols fit in the view; see
+// workspacePreview.
+func (review *reviewImage) render(protocol preview.Protocol, size preview.Cells) string {
+	if review.rendered != "" && review.renderedSize == size {
+		return review.rendered
+	}
+	var buf strings.Builder
+	if err := preview.Render(&buf, review.image, protocol, size, 1, 2); err != nil {
+		return ""
+	}
+	review.rendered = strings.TrimSuffix(buf.String(), "\n")
+	review.renderedSize = size
+	return review.rendered
+}
+
+// runWorkspace runs the workspace until the user quits and lists the files
+// kept from the session. Quitting cancels the requests still running.
+func runWorkspace(m workspaceModel) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.ctx = ctx
+	result, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("failed to run workspace: %w", err)
+	}
+
+	// Terminals with image support show the kept images properly now that
+	// the view is gone
+	final := result.(workspaceModel)
+	protocol := preview.Off
+	if workspacePreview(final.preview) != final.preview {
+		protocol = final.preview
+	}
+	for _, run := range final.runs {
+		for _, review := range run.images {
+			if !review.discarded {
+				utils.PrintSuccess(i18n.Tf("image_saved", review.path))
+				generator.ShowPreview(review.path, protocol, preview.SmallWidth)
+			}
+		}
+	}
+	return nil
+}
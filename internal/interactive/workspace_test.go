@@ -0,0 +1,270 @@
+package interactive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"just-icon/internal/gallery"
+	"just-icon/internal/generator"
+	"just-icon/internal/preview"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// testWorkspace creates a workspace whose generations and enhancements are
+// recorded instead of calling the API
+func testWorkspace(cfg *types.Config, opts Options) (workspaceModel, *[]types.IconGenerationOptions) {
+	var generated []types.IconGenerationOptions
+	m := newWorkspace(cfg, nil, opts, "icons")
+	m.preview = preview.Off
+	m.generate = func(ctx context.Context, options *types.IconGenerationOptions, preset string, reporter *progress.Reporter) (*generator.Outcome, error) {
+		generated = append(generated, *options)
+		return &generator.Outcome{Result: &types.GenerationResult{}, Files: []string{"icons/a.png"}, OutputDir: options.Output}, nil
+	}
+	m.estimate = func(*types.IconGenerationOptions) time.Duration { return 0 }
+	m.enhancePrompt = func(ctx context.Context, prompt string) (string, error) {
+		return prompt + ", flat vector style", nil
+	}
+	return m, &generated
+}
+
+// send feeds messages to the workspace. Commands that start work are run
+// and the finished work fed back; other commands only blink the cursor.
+func send(m workspaceModel, msgs ...tea.Msg) workspaceModel {
+	for _, msg := range msgs {
+		busy := m.busy
+		next, cmd := m.Update(msg)
+		m = next.(workspaceModel)
+		if busy || !m.busy {
+			continue
+		}
+		for _, result := range runCmd(cmd) {
+			switch result.(type) {
+			case generateDoneMsg, enhanceDoneMsg:
+				m = send(m, result)
+			}
+		}
+	}
+	return m
+}
+
+// runCmd runs a command and the commands of a batch
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	batch, ok := msg.(tea.BatchMsg)
+	if !ok {
+		return []tea.Msg{msg}
+	}
+	var msgs []tea.Msg
+	for _, cmd := range batch {
+		msgs = append(msgs, runCmd(cmd)...)
+	}
+	return msgs
+}
+
+// keys turns text into key presses
+func keys(text string) []tea.Msg {
+	var msgs []tea.Msg
+	for _, r := range text {
+		msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return msgs
+}
+
+var (
+	tab   = tea.KeyMsg{Type: tea.KeyTab}
+	right = tea.KeyMsg{Type: tea.KeyRight}
+	left  = tea.KeyMsg{Type: tea.KeyLeft}
+	enter = tea.KeyMsg{Type: tea.KeyEnter}
+)
+
+func TestWorkspaceOptions(t *testing.T) {
+	cfg := &types.Config{Presets: map[string]*types.Preset{
+		"glass": {Size: types.SizeLarge, Quality: types.QualityHigh, NumImages: 3},
+	}}
+	m, _ := testWorkspace(cfg, Options{})
+
+	// Preset
+	m = send(m, tab, right)
+	if m.preset != "glass" || m.options.Size != types.SizeLarge || m.options.NumImages != 3 {
+		t.Fatalf("preset not applied: %q %+v", m.preset, m.options)
+	}
+	m = send(m, left)
+	if m.preset != "" || m.options.Size != types.DefaultValues.Size || m.options.NumImages != 1 {
+		t.Errorf("choosing no preset kept overrides: %+v", m.options)
+	}
+
+	// Background wraps around, quantity stops at the limit
+	m = send(m, tab, tab, tab, tab, left)
+	if m.options.Background != "opaque" {
+		t.Errorf("background = %q, want opaque", m.options.Background)
+	}
+	m = send(m, tab, tab, left, left)
+	if m.options.NumImages != types.MinImages {
+		t.Errorf("quantity = %d, want %d", m.options.NumImages, types.MinImages)
+	}
+
+	// q only quits outside text fields
+	m = send(m, tab, tab, tab, tab)
+	if field := m.fields[m.focus]; field != fieldOutput {
+		t.Fatalf("focus = %d, want output field", field)
+	}
+	m = send(m, keys("q")...)
+	if got := m.output.Value(); got != "iconsq" {
+		t.Errorf("q in the output field was not typed, output = %q", got)
+	}
+}
+
+func TestWorkspaceGenerate(t *testing.T) {
+	m, generated := testWorkspace(&types.Config{}, Options{Template: "material"})
+
+	m = send(m, enter)
+	if !errors.Is(m.prompt.err, ErrEmptyPrompt) || len(*generated) != 0 {
+		t.Fatalf("empty prompt was not rejected: %v", m.prompt.err)
+	}
+
+	m = send(m, keys("rocket")...)
+	m = send(m, tab, tab, tab, right, enter)
+	if len(*generated) != 1 {
+		t.Fatalf("generated %d times, want 1", len(*generated))
+	}
+	got := (*generated)[0]
+	if got.Prompt != "rocket" || got.Template != "material" || got.Size != types.SizeMedium || got.Output != "icons" {
+		t.Errorf("unexpected options %+v", got)
+	}
+	if m.busy || len(m.runs) != 1 || !m.runs[0].done || m.selected != 0 {
+		t.Errorf("run not recorded: busy=%v runs=%d", m.busy, len(m.runs))
+	}
+
+	// Re-rolling keeps the settings and adds a run
+	m = send(m, enter)
+	if len(*generated) != 2 || (*generated)[1].Size != types.SizeMedium || m.selected != 1 {
+		t.Errorf("re-roll did not reuse the settings")
+	}
+	m = send(m, tea.KeyMsg{Type: tea.KeyPgUp})
+	if m.selected != 0 {
+		t.Errorf("selected = %d after PgUp, want 0", m.selected)
+	}
+}
+
+func TestWorkspaceProgress(t *testing.T) {
+	m, _ := testWorkspace(&types.Config{}, Options{})
+	m.estimate = func(*types.IconGenerationOptions) time.Duration { return time.Minute }
+
+	m = send(m, keys("rocket")...)
+	next, _ := m.Update(enter)
+	m = next.(workspaceModel)
+	m.runs[0].tracker.Reporter(0).Attempt(1)
+	if view := m.View(); !strings.Contains(view, "running") || !strings.Contains(view, "left") {
+		t.Errorf("footer does not show the request progress:\n%s", view)
+	}
+	m.runs[0].tracker.Reporter(0).Download(0, 512<<10, 1<<20)
+	if view := m.View(); !strings.Contains(view, "downloading image 1") || !strings.Contains(view, "512 KB / 1.0 MB") {
+		t.Errorf("footer does not show the download:\n%s", view)
+	}
+}
+
+func TestWorkspaceEnhance(t *testing.T) {
+	m, generated := testWorkspace(&types.Config{}, Options{Enhance: true})
+
+	m = send(m, keys("rocket")...)
+	m = send(m, enter)
+	if got := m.prompt.input.Value(); got != "rocket, flat vector style" || len(*generated) != 0 {
+		t.Fatalf("first Enter should enhance, prompt = %q", got)
+	}
+	m = send(m, enter)
+	if got := (*generated)[0]; got.Prompt != "rocket, flat vector style" || got.OriginalPrompt != "rocket" {
+		t.Errorf("enhanced prompt not used: %+v", got)
+	}
+
+	// Restoring the original generates it as typed
+	m = send(m, tea.KeyMsg{Type: tea.KeyCtrlO}, enter)
+	if got := (*generated)[1]; got.Prompt != "rocket" || got.OriginalPrompt != "" {
+		t.Errorf("original prompt not restored: %+v", got)
+	}
+}
+
+func TestWorkspaceReview(t *testing.T) {
+	dir := t.TempDir()
+	m, generated := testWorkspace(&types.Config{}, Options{})
+	m.gallery = gallery.NewStore(filepath.Join(dir, "gallery.jsonl"))
+	files := []string{filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png")}
+	m.generate = func(ctx context.Context, options *types.IconGenerationOptions, preset string, reporter *progress.Reporter) (*generator.Outcome, error) {
+		*generated = append(*generated, *options)
+		for _, file := range files {
+			os.WriteFile(file, []byte("png"), 0644)
+		}
+		return &generator.Outcome{Result: &types.GenerationResult{}, Files: files}, nil
+	}
+
+	m = send(m, keys("rocket")...)
+	m = send(m, enter)
+	if m.fields[m.focus] != fieldResults {
+		t.Fatalf("focus = %d after generating, want the results", m.fields[m.focus])
+	}
+
+	// Keep marks the image as a favorite
+	m = send(m, keys("s")...)
+	entry, err := m.gallery.Find(files[0])
+	if err != nil || !entry.Favorite || !m.runs[0].images[0].kept {
+		t.Fatalf("kept image is not a favorite: %+v, %v", entry, err)
+	}
+
+	// Discard asks for a second press
+	m = send(m, keys("x")...)
+	if _, err := os.Stat(files[0]); err != nil {
+		t.Fatal("first x deleted the image")
+	}
+	m = send(m, keys("x")...)
+	if _, err := os.Stat(files[0]); !os.IsNotExist(err) || !m.runs[0].images[0].discarded {
+		t.Fatal("second x did not delete the image")
+	}
+	m = send(m, keys("r")...)
+	if len(*generated) != 1 {
+		t.Fatal("re-rolled a discarded image")
+	}
+
+	// Similar and edit use the selected image as the reference
+	m = send(m, right)
+	m = send(m, keys("r")...)
+	if got := (*generated)[1]; got.Reference != files[1] || got.Prompt != "rocket" {
+		t.Errorf("similar options = %+v", got)
+	}
+	// The new run is selected; edit its second image
+	m = send(m, right)
+	m = send(m, keys("e")...)
+	m = send(m, keys("make it blue")...)
+	m = send(m, enter)
+	got := (*generated)[2]
+	if got.Reference != files[1] || got.Prompt != "make it blue" || !got.RawPrompt || got.NumImages != 1 {
+		t.Errorf("edit options = %+v", got)
+	}
+	if len(m.runs) != 3 || m.runs[2].kind != runEdit {
+		t.Errorf("edit run not recorded")
+	}
+}
+
+func TestWorkspacePreview(t *testing.T) {
+	tests := map[preview.Protocol]preview.Protocol{
+		preview.Kitty:  preview.Blocks,
+		preview.ITerm2: preview.Blocks,
+		preview.Sixel:  preview.Blocks,
+		preview.Blocks: preview.Blocks,
+		preview.Off:    preview.Off,
+	}
+	for protocol, want := range tests {
+		if got := workspacePreview(protocol); got != want {
+			t.Errorf("workspacePreview(%s) = %s, want %s", protocol, got, want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package jsonl keeps records in JSON lines files, one record per line. The
+// history, gallery index and job queue use it: changes rewrite the file
+// atomically under a lock next to it, so several processes can share it,
+// and records are referred to by short random hex IDs or their prefixes.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"just-icon/internal/config"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// IDLength is the number of hex characters in a record ID
+	IDLength = 8
+	// maxLineSize bounds a single line; prompts are short but enhanced ones
+	// can run to a few kilobytes
+	maxLineSize = 1 << 20
+)
+
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrAmbiguous = errors.New("reference matches more than one record")
+)
+
+// File is a JSON lines file of records of type T
+type File[T any] struct {
+	path string
+	// name describes the file in errors, e.g. "history file"
+	name string
+}
+
+// New creates a file of records at path; name describes it in errors
+func New[T any](path, name string) *File[T] {
+	return &File[T]{path: path, name: name}
+}
+
+// Path returns the file path
+func (f *File[T]) Path() string {
+	return f.path
+}
+
+// Read returns the records, oldest first. Lines that fail to parse, such as
+// a torn write, are left out so they don't hide the rest of the file.
+func (f *File[T]) Read() ([]T, error) {
+	records, _, err := f.read()
+	return records, err
+}
+
+// Append adds a record at the end of the file
+func (f *File[T]) Append(record T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", f.name, err)
+	}
+	data = append(data, '\n')
+
+	return f.withLock(func() error {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, types.ConfigFilePerm)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", f.name, err)
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		return file.Close()
+	})
+}
+
+// Modify reads the records, applies fn and writes the result back
+// atomically while holding the lock. Lines that fail to parse are written
+// back unchanged after the records, so a rewrite never loses them.
+func (f *File[T]) Modify(fn func([]T) ([]T, error)) error {
+	return f.withLock(func() error {
+		records, unparsed, err := f.read()
+		if err != nil {
+			return err
+		}
+		records, err = fn(records)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s record: %w", f.name, err)
+			}
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+		for _, line := range unparsed {
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+		if err := utils.WriteFileAtomic(f.path, buf.Bytes(), types.ConfigFilePerm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		return nil
+	})
+}
+
+// read returns the records and the lines that failed to parse
+func (f *File[T]) read() ([]T, [][]byte, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read %s: %w", f.name, err)
+	}
+
+	var records []T
+	var unparsed [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record T
+		if err := json.Unmarshal(line, &record); err != nil {
+			unparsed = append(unparsed, bytes.Clone(line))
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", f.name, err)
+	}
+	return records, unparsed, nil
+}
+
+// withLock creates the directory and runs fn while holding the file's lock
+func (f *File[T]) withLock(fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), types.ConfigDirPerm); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", f.name, err)
+	}
+
+	lock, err := utils.LockFile(f.path + config.LockFileSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", f.name, err)
+	}
+	defer lock.Unlock()
+
+	return fn()
+}
+
+// Find returns the index of the record whose ID, given by id, starts with
+// ref. Records sharing an ID count as one match; the last of them is
+// returned.
+func Find[T any](records []T, id func(T) string, ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return -1, ErrNotFound
+	}
+
+	match := -1
+	for i := range records {
+		if !strings.HasPrefix(id(records[i]), ref) {
+			continue
+		}
+		if match >= 0 && id(records[match]) != id(records[i]) {
+			return -1, ErrAmbiguous
+		}
+		match = i
+	}
+	if match < 0 {
+		return -1, ErrNotFound
+	}
+	return match, nil
+}
+
+// NewID returns a random hex record ID
+func NewID() (string, error) {
+	buf := make([]byte, IDLength/2)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,92 @@
+package jsonl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// record is a test record
+type record struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestModifyKeepsUnparsedLines(t *testing.T) {
+	file := New[record](filepath.Join(t.TempDir(), "state", "records.jsonl"), "test file")
+	if err := file.Append(record{ID: "aaaa1111", Name: "rocket"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A torn write and a line from an incompatible version
+	f, err := os.OpenFile(file.Path(), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"bbbb2222","na` + "\n" + `{"id":42}` + "\n")
+	f.Close()
+	if err := file.Append(record{ID: "cccc3333", Name: "plane"}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := file.Read()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("Read() = %v, %v, want the 2 records that parse", records, err)
+	}
+
+	err = file.Modify(func(records []record) ([]record, error) {
+		return records[1:], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file.Path())
+	want := `{"id":"cccc3333","name":"plane"}` + "\n" + `{"id":"bbbb2222","na` + "\n" + `{"id":42}` + "\n"
+	if string(data) != want {
+		t.Errorf("file after Modify() =\n%s\nwant\n%s", data, want)
+	}
+
+	// A failing change leaves the file alone
+	if err := file.Modify(func([]record) ([]record, error) { return nil, errors.New("nope") }); err == nil {
+		t.Error("Modify() ignored the error")
+	}
+	if after, _ := os.ReadFile(file.Path()); string(after) != want {
+		t.Errorf("failed Modify() changed the file:\n%s", after)
+	}
+}
+
+func TestFind(t *testing.T) {
+	records := []record{{ID: "abc12345"}, {ID: "abd67890"}, {ID: "ffff0000", Name: "old"}, {ID: "ffff0000", Name: "new"}}
+	id := func(r record) string { return r.ID }
+
+	tests := []struct {
+		ref  string
+		want int
+		err  error
+	}{
+		{"abc", 0, nil},
+		{" abd67890 ", 1, nil},
+		{"ffff", 3, nil},
+		{"ab", -1, ErrAmbiguous},
+		{"zz", -1, ErrNotFound},
+		{"", -1, ErrNotFound},
+	}
+	for _, tt := range tests {
+		got, err := Find(records, id, tt.ref)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("Find(%q) = %d, %v, want %d, %v", tt.ref, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestNewID(t *testing.T) {
+	id, err := NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(id) != IDLength || strings.Trim(id, "0123456789abcdef") != "" {
+		t.Errorf("NewID() = %q, want %d hex characters", id, IDLength)
+	}
+}
@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"just-icon/internal/types"
+)
+
+// FileName is the request log in the state directory
+const FileName = "just-icon.log"
+
+// Levels lists the accepted level names
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// logger receives the records of the whole program. It discards everything
+// until Setup runs, so packages can log without caring whether anyone reads it.
+var logger = slog.New(discardHandler{})
+
+// Logger returns the program's logger
+func Logger() *slog.Logger {
+	return logger
+}
+
+// Options configure Setup
+type Options struct {
+	// Path is the log file; empty writes no file
+	Path   string
+	Config *types.LogConfig
+	// Console receives records at ConsoleLevel and above, for --verbose and
+	// --debug; nil prints nothing
+	Console      io.Writer
+	ConsoleLevel slog.Level
+	// Secrets are scrubbed from every record, see Secrets
+	Secrets []string
+}
+
+// Setup points the logger at the log file, written as JSON lines and
+// rotated by size, and at the console. A disabled log writes no file; the
+// console still gets records. Both outputs are redacted: secrets and URL
+// queries never appear, and prompts are hashed when configured. Records
+// past the retention period are pruned first.
+func Setup(opts Options) error {
+	cfg := types.LogConfig{}
+	if opts.Config != nil {
+		cfg = *opts.Config
+	}
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	redact := &redactor{hashPrompts: cfg.HashPrompts, secrets: opts.Secrets}
+	var handlers []slog.Handler
+	var setupErr error
+	if opts.Path != "" && cfg.RetentionDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -cfg.RetentionDays)
+		if err := prune(opts.Path, cutoff); err != nil {
+			setupErr = fmt.Errorf("failed to prune log: %w", err)
+		}
+	}
+	if opts.Console != nil {
+		handlers = append(handlers, slog.NewTextHandler(opts.Console, &slog.HandlerOptions{Level: opts.ConsoleLevel, ReplaceAttr: redact.replace}))
+		// Debugging on the console also records debug details in the file
+		level = min(level, opts.ConsoleLevel)
+	}
+	if opts.Path != "" && !cfg.Disabled {
+		maxSize := cfg.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = types.DefaultLogMaxSizeMB
+		}
+		maxFiles := cfg.MaxFiles
+		if maxFiles <= 0 {
+			maxFiles = types.DefaultLogMaxFiles
+		}
+		file, err := openRotating(opts.Path, int64(maxSize)<<20, maxFiles)
+		if err != nil {
+			setupErr = errors.Join(setupErr, fmt.Errorf("failed to open log file: %w", err))
+		} else {
+			handlers = append(handlers, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: level, ReplaceAttr: redact.replace}))
+		}
+	}
+
+	switch len(handlers) {
+	case 0:
+		logger = slog.New(discardHandler{})
+	case 1:
+		logger = slog.New(handlers[0])
+	default:
+		logger = slog.New(multiHandler(handlers))
+	}
+	return setupErr
+}
+
+// ParseLevel parses a level name; empty means info
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, use one of %s", name, strings.Join(Levels, ", "))
+}
+
+// NewRequestID returns a short random ID that ties together the records of
+// one API request
+func NewRequestID() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "000000000000"
+	}
+	return hex.EncodeToString(b)
+}
+
+// discardHandler drops every record
+type discardHandler struct{}
+
+// Enabled reports that no level is logged
+func (discardHandler) Enabled(context.Context, slog.Level) bool { return false }
+
+// Handle drops the record
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+
+// WithAttrs returns the handler itself
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+// WithGroup returns the handler itself
+func (h discardHandler) WithGroup(string) slog.Handler { return h }
+
+// multiHandler sends records to every handler that takes their level
+type multiHandler []slog.Handler
+
+// Enabled reports whether any handler takes the level
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle passes the record to the handlers that take its level
+func (m multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// WithAttrs adds the attributes to every handler
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+// WithGroup opens the group in every handler
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
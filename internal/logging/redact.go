@@ -0,0 +1,141 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"just-icon/internal/types"
+)
+
+// redacted replaces secrets in the log
+const redacted = "[redacted]"
+
+// minSecretLength keeps short values such as "1" or "true" in header values
+// from being scrubbed out of every record
+const minSecretLength = 8
+
+// secretKeys are attribute names whose values are never written
+var secretKeys = map[string]bool{
+	"api_key":       true,
+	"authorization": true,
+	"header":        true,
+	"headers":       true,
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+}
+
+// promptKeys are attribute names holding prompts
+var promptKeys = map[string]bool{
+	"prompt":          true,
+	"original_prompt": true,
+	"enhanced_prompt": true,
+	"revised_prompt":  true,
+}
+
+// urlQuery matches the query and fragment of URLs; image URLs are often
+// pre-signed, which makes the query a credential
+var urlQuery = regexp.MustCompile(`(https?://[^\s?#"']+)[?#][^\s"']*`)
+
+// StripURLQueries removes the query and fragment of every URL in a text
+func StripURLQueries(text string) string {
+	return urlQuery.ReplaceAllString(text, "$1")
+}
+
+// Secrets returns the credentials in the configuration and the environment
+// that must never reach the log: API keys, header values and the proxy
+// password
+func Secrets(cfg *types.Config) []string {
+	candidates := []string{os.Getenv(types.AzureAPIKeyEnv), os.Getenv(types.AzureADTokenEnv)}
+	if cfg != nil {
+		candidates = append(candidates, cfg.OpenAIAPIKey)
+		if cfg.Azure != nil {
+			candidates = append(candidates, cfg.Azure.APIKey)
+		}
+		if cfg.HTTP != nil {
+			for _, value := range cfg.HTTP.Headers {
+				candidates = append(candidates, value)
+				// Authorization headers also leak without their scheme
+				if _, token, ok := strings.Cut(value, " "); ok {
+					candidates = append(candidates, token)
+				}
+			}
+			if proxy, err := url.Parse(cfg.HTTP.ProxyURL); err == nil && proxy.User != nil {
+				if password, ok := proxy.User.Password(); ok {
+					candidates = append(candidates, password)
+				}
+			}
+		}
+	}
+
+	var secrets []string
+	for _, secret := range candidates {
+		if secret = strings.TrimSpace(secret); len(secret) >= minSecretLength {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// HashPrompt returns the short hash written in place of a prompt
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// redactor rewrites attributes before a handler writes them
+type redactor struct {
+	hashPrompts bool
+	secrets     []string
+}
+
+// replace is the handlers' ReplaceAttr. Secret attributes are dropped to a
+// marker, prompts are hashed when configured, and every text loses its URL
+// queries and any secret it contains.
+func (r *redactor) replace(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if secretKeys[key] || (len(groups) > 0 && secretKeys[strings.ToLower(groups[0])]) {
+		return slog.String(a.Key, redacted)
+	}
+
+	value := a.Value.Resolve()
+	var text string
+	switch value.Kind() {
+	case slog.KindString:
+		text = value.String()
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			text = v.Error()
+		case fmt.Stringer:
+			text = v.String()
+		default:
+			text = fmt.Sprint(v)
+		}
+	default:
+		return a
+	}
+
+	if r.hashPrompts && promptKeys[key] && text != "" {
+		return slog.String(a.Key, HashPrompt(text))
+	}
+	if scrubbed := r.scrub(text); scrubbed != text {
+		return slog.String(a.Key, scrubbed)
+	}
+	return a
+}
+
+// scrub removes URL queries and secrets from a text
+func (r *redactor) scrub(text string) string {
+	text = StripURLQueries(text)
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+	return text
+}
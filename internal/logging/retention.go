@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"just-icon/internal/types"
+)
+
+// prune drops the records written before cutoff from the log at path and its
+// rotated logs. Logs last written before cutoff are deleted; the others keep
+// their newer records and any line that is not a record.
+func prune(path string, cutoff time.Time) error {
+	rotated, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return err
+	}
+	for _, name := range append([]string{path}, rotated...) {
+		info, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := pruneRecords(name, cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneRecords rewrites a log without the records written before cutoff. It
+// leaves the file alone when its first record is recent, since records are
+// appended in order.
+func pruneRecords(name string, cutoff time.Time) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	if t, ok := recordTime(first); !ok || !t.Before(cutoff) {
+		return nil
+	}
+
+	var kept bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		if t, ok := recordTime(scanner.Bytes()); ok && t.Before(cutoff) {
+			continue
+		}
+		kept.Write(scanner.Bytes())
+		kept.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, kept.Bytes(), types.ConfigFilePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// recordTime reads the time of a JSON log record
+func recordTime(line []byte) (time.Time, bool) {
+	var record struct {
+		Time time.Time `json:"time"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil || record.Time.IsZero() {
+		return time.Time{}, false
+	}
+	return record.Time, true
+}
@@ -0,0 +1,209 @@
+// Package mcp serves just-icon's tools over the Model Context Protocol so
+// coding assistants can generate and export icons. Messages are JSON-RPC
+// 2.0, one per line on stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"just-icon/internal/generator"
+	"just-icon/internal/logging"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// ProtocolVersions lists the protocol revisions the server speaks, newest
+// first
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// maxMessageSize bounds one message
+const maxMessageSize = 16 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// message is a JSON-RPC request or notification; notifications have no ID
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response answers a request
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC error
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests
+type Server struct {
+	version string
+
+	mu  sync.Mutex
+	out *json.Encoder
+
+	// generate and validate do the work; tests replace them
+	generate func(context.Context, *types.IconGenerationOptions, string, *progress.Reporter) (*generator.Outcome, error)
+	validate func(*types.IconGenerationOptions) error
+}
+
+// New creates a server that reports the given program version
+func New(version string) *Server {
+	return &Server{
+		version:  version,
+		generate: generator.Run,
+		validate: generator.Validate,
+	}
+}
+
+// Serve reads requests from in and writes responses to out until in ends
+// or ctx is done. Tool calls run concurrently, so a long generation does not
+// hold up other requests; they are canceled when ctx is done.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.out = json.NewEncoder(out)
+	s.out.SetEscapeHTML(false)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxMessageSize)
+		for scanner.Scan() {
+			lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+
+	var calls sync.WaitGroup
+	defer calls.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case line, ok := <-lines:
+			if !ok {
+				return <-readErr
+			}
+			if len(line) == 0 {
+				continue
+			}
+			var msg message
+			if err := json.Unmarshal(line, &msg); err != nil {
+				s.reply(nil, nil, &rpcError{Code: codeParseError, Message: "parse error: " + err.Error()})
+				continue
+			}
+			if msg.Method == "tools/call" && msg.ID != nil {
+				calls.Add(1)
+				go func() {
+					defer calls.Done()
+					s.handle(ctx, msg)
+				}()
+				continue
+			}
+			s.handle(ctx, msg)
+		}
+	}
+}
+
+// handle answers one message; notifications get no answer
+func (s *Server) handle(ctx context.Context, msg message) {
+	if msg.ID == nil {
+		return
+	}
+	if msg.JSONRPC != "2.0" || msg.Method == "" {
+		s.reply(msg.ID, nil, &rpcError{Code: codeInvalidRequest, Message: "invalid request"})
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(msg.Params, &params)
+		version := ProtocolVersions[0]
+		if slices.Contains(ProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		s.reply(msg.ID, map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "just-icon", "version": s.version},
+			"instructions": "Generate app icons with generate_icon, then turn one into platform icon sets with export_icon_set. " +
+				"list_presets shows the configured style presets and templates.",
+		}, nil)
+	case "ping":
+		s.reply(msg.ID, map[string]any{}, nil)
+	case "tools/list":
+		s.reply(msg.ID, map[string]any{"tools": toolList()}, nil)
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			s.reply(msg.ID, nil, &rpcError{Code: codeInvalidParams, Message: err.Error()})
+			return
+		}
+		tool, ok := tools[params.Name]
+		if !ok {
+			s.reply(msg.ID, nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)})
+			return
+		}
+		logging.Logger().Info("mcp tool call", "tool", params.Name)
+		result, err := tool.call(s, ctx, params.Arguments)
+		s.reply(msg.ID, toolResult(result, err), nil)
+	default:
+		s.reply(msg.ID, nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method})
+	}
+}
+
+// reply writes a response
+func (s *Server) reply(id json.RawMessage, result any, rpcErr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.out.Encode(response{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}); err != nil {
+		logging.Logger().Warn("mcp reply failed", "error", err.Error())
+	}
+}
+
+// toolResult wraps a tool's outcome. Failures are reported in the result,
+// as the protocol asks, so the assistant can read them and try again.
+func toolResult(result any, err error) map[string]any {
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]string{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+	text, _ := json.MarshalIndent(result, "", "  ")
+	return map[string]any{
+		"content":           []map[string]string{{"type": "text", "text": string(text)}},
+		"structuredContent": result,
+		"isError":           false,
+	}
+}
@@ -0,0 +1,222 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/metadata"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// reply is a decoded response
+type reply struct {
+	ID     json.RawMessage `json:"id"`
+	Result struct {
+		ProtocolVersion   string            `json:"protocolVersion"`
+		Tools             []map[string]any  `json:"tools"`
+		Content           []map[string]any  `json:"content"`
+		StructuredContent json.RawMessage   `json:"structuredContent"`
+		IsError           bool              `json:"isError"`
+		ServerInfo        map[string]string `json:"serverInfo"`
+	} `json:"result"`
+	Error *rpcError `json:"error"`
+}
+
+// testServer returns a server using a fresh config whose generations write
+// a small PNG with metadata, and the options they got
+func testServer(t *testing.T) (*Server, *[]types.IconGenerationOptions) {
+	t.Helper()
+	dir := t.TempDir()
+	service := config.DefaultService
+	config.DefaultService = config.NewServiceWithPath(filepath.Join(dir, config.ConfigFileName))
+	t.Cleanup(func() { config.DefaultService = service })
+
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 16, 16)))
+
+	var mu sync.Mutex
+	var calls []types.IconGenerationOptions
+	s := New("1.2.3")
+	s.validate = func(options *types.IconGenerationOptions) error {
+		if options.Size == "huge" {
+			return errors.New("invalid size: huge")
+		}
+		return nil
+	}
+	s.generate = func(ctx context.Context, options *types.IconGenerationOptions, preset string, reporter *progress.Reporter) (*generator.Outcome, error) {
+		mu.Lock()
+		calls = append(calls, *options)
+		mu.Unlock()
+		if err := os.MkdirAll(options.Output, 0755); err != nil {
+			return nil, err
+		}
+		result := &types.GenerationResult{Cost: 0.04, Size: options.Size, Images: []types.GeneratedImage{{}}}
+		data, err := metadata.Embed(encoded.Bytes(), metadata.New(options, result, 0, preset))
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(options.Output, "icon-1.png")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, err
+		}
+		return &generator.Outcome{Result: result, Files: []string{path}, OutputDir: options.Output, HistoryID: "abc12345"}, nil
+	}
+	return s, &calls
+}
+
+// exchange sends requests, one per line, and returns the replies by ID
+func exchange(t *testing.T, s *Server, requests ...string) map[string]reply {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	replies := make(map[string]reply)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var r reply
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("reply %q is not JSON: %v", scanner.Text(), err)
+		}
+		replies[string(r.ID)] = r
+	}
+	return replies
+}
+
+func TestProtocol(t *testing.T) {
+	s, _ := testServer(t)
+	replies := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"draw","arguments":{}}}`,
+		`not json`,
+	)
+	if len(replies) != 6 {
+		t.Fatalf("got %d replies, want 6 (the notification has none): %+v", len(replies), replies)
+	}
+	if r := replies["1"]; r.Result.ProtocolVersion != "2025-03-26" || r.Result.ServerInfo["version"] != "1.2.3" {
+		t.Errorf("initialize = %+v", r.Result)
+	}
+	var names []string
+	for _, tool := range replies["2"].Result.Tools {
+		names = append(names, tool["name"].(string))
+	}
+	if strings.Join(names, ",") != "generate_icon,export_icon_set,list_presets" {
+		t.Errorf("tools = %v", names)
+	}
+	if replies["3"].Error != nil {
+		t.Errorf("ping failed: %+v", replies["3"].Error)
+	}
+	for id, code := range map[string]int{"4": codeMethodNotFound, "5": codeInvalidParams, "null": codeParseError} {
+		if r := replies[id]; r.Error == nil || r.Error.Code != code {
+			t.Errorf("reply %s error = %+v, want code %d", id, r.Error, code)
+		}
+	}
+}
+
+func TestGenerateIcon(t *testing.T) {
+	s, calls := testServer(t)
+	output := t.TempDir()
+	replies := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"generate_icon","arguments":{"prompt":"rocket","quality":"high","n":2,"output":"`+filepath.ToSlash(output)+`"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"generate_icon","arguments":{"prompt":"rocket","size":"huge"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"generate_icon","arguments":{"prompt":"rocket","colour":"red"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"generate_icon","arguments":{"prompt":"rocket","preset":"nope"}}}`,
+	)
+
+	r := replies["1"]
+	if r.Result.IsError {
+		t.Fatalf("generate_icon failed: %v", r.Result.Content)
+	}
+	var result struct {
+		Files []struct {
+			Path     string
+			Metadata metadata.Metadata
+		}
+		HistoryID string  `json:"history_id"`
+		CostUSD   float64 `json:"cost_usd"`
+	}
+	if err := json.Unmarshal(r.Result.StructuredContent, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != filepath.Join(output, "icon-1.png") ||
+		result.Files[0].Metadata.Prompt != "rocket" || result.HistoryID != "abc12345" || result.CostUSD != 0.04 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := (*calls)[0]; got.Quality != "high" || got.NumImages != 2 || got.Template == "" {
+		t.Errorf("generation options = %+v", got)
+	}
+
+	// Invalid settings, unknown arguments and presets are tool errors, and
+	// nothing is generated
+	for _, id := range []string{"2", "3", "4"} {
+		if r := replies[id]; !r.Result.IsError || r.Error != nil {
+			t.Errorf("call %s = %+v, want a tool error", id, r)
+		}
+	}
+	if len(*calls) != 1 {
+		t.Errorf("generated %d times, want 1", len(*calls))
+	}
+}
+
+func TestExportIconSetAndListPresets(t *testing.T) {
+	s, _ := testServer(t)
+	dir := t.TempDir()
+	source := filepath.Join(dir, "rocket.png")
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewNRGBA(image.Rect(0, 0, 8, 8)))
+	if err := os.WriteFile(source, encoded.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.DefaultService.SavePreset("flat", &types.Preset{Description: "Flat web icons", Quality: "low"}); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := json.Marshal(map[string]any{"image": source, "targets": []string{"ios"}})
+	replies := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"export_icon_set","arguments":`+string(args)+`}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_presets"}}`,
+	)
+
+	var exported struct {
+		OutputDir string `json:"output_dir"`
+		Files     []string
+	}
+	json.Unmarshal(replies["1"].Result.StructuredContent, &exported)
+	if exported.OutputDir != filepath.Join(dir, "rocket-icons") || len(exported.Files) != 2 {
+		t.Errorf("export = %+v (%v)", exported, replies["1"].Result.Content)
+	}
+	for _, file := range exported.Files {
+		if _, err := os.Stat(file); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var listed struct {
+		Presets []struct {
+			Name    string
+			Quality string
+		}
+		Templates []struct{ Name string }
+	}
+	json.Unmarshal(replies["2"].Result.StructuredContent, &listed)
+	if len(listed.Presets) != 1 || listed.Presets[0].Name != "flat" || listed.Presets[0].Quality != "low" || len(listed.Templates) == 0 {
+		t.Errorf("list_presets = %+v", listed)
+	}
+}
@@ -0,0 +1,309 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"just-icon/internal/config"
+	"just-icon/internal/gallery"
+	"just-icon/internal/generator"
+	"just-icon/internal/iconset"
+	"just-icon/internal/metadata"
+	"just-icon/internal/presets"
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+// tool is one tool the server offers
+type tool struct {
+	description string
+	// schema is the JSON schema of the arguments
+	schema map[string]any
+	call   func(s *Server, ctx context.Context, args json.RawMessage) (any, error)
+}
+
+// toolOrder lists the tools as tools/list shows them
+var toolOrder = []string{"generate_icon", "export_icon_set", "list_presets"}
+
+// tools maps tool names to tools
+var tools = map[string]tool{
+	"generate_icon": {
+		description: "Generate app icons from a description and save them. Unset settings come from the preset, " +
+			"then from the user's configured defaults. Returns the saved file paths with their generation metadata.",
+		schema: object(map[string]any{
+			"prompt":        stringProp("What the icon shows, e.g. \"a rocket made of leaves\""),
+			"preset":        stringProp("Name of a configured style preset (see list_presets)"),
+			"template":      stringProp("Prompt template (see list_presets)"),
+			"size":          enumProp("Image size", types.SupportedSizes[types.ModelGPTImage1]),
+			"quality":       enumProp("Image quality", types.SupportedQualities[types.ModelGPTImage1]),
+			"background":    enumProp("Background", types.SupportedBackgrounds[types.ModelGPTImage1]),
+			"output_format": enumProp("Image file format", types.SupportedOutputFormats[types.ModelGPTImage1]),
+			"n":             map[string]any{"type": "integer", "minimum": 1, "maximum": 10, "description": "Number of images"},
+			"raw":           boolProp("Send the prompt as is, without a template"),
+			"brand":         boolProp("Apply the configured brand kit"),
+			"output":        stringProp("Directory to save into; defaults to the configured output directory"),
+		}, "prompt"),
+		call: (*Server).generateIcon,
+	},
+	"export_icon_set": {
+		description: "Export an image as platform icon sets: an Xcode app icon set, Android launcher icons, " +
+			"web favicons with a manifest, and a macOS iconset. Returns the written directories and files.",
+		schema: object(map[string]any{
+			"image": stringProp("Path of the image, or the ID of a gallery entry"),
+			"targets": map[string]any{
+				"type":        "array",
+				"items":       enumProp("", targetNames()),
+				"description": "Platforms to export; all when empty",
+			},
+			"output": stringProp("Directory to write into; defaults to a new directory next to the image"),
+		}, "image"),
+		call: (*Server).exportIconSet,
+	},
+	"list_presets": {
+		description: "List the configured style presets and the prompt templates generate_icon accepts.",
+		schema:      object(map[string]any{}),
+		call:        (*Server).listPresets,
+	},
+}
+
+// generateArgs are the arguments of generate_icon
+type generateArgs struct {
+	Prompt       string `json:"prompt"`
+	Preset       string `json:"preset"`
+	Template     string `json:"template"`
+	Size         string `json:"size"`
+	Quality      string `json:"quality"`
+	Background   string `json:"background"`
+	OutputFormat string `json:"output_format"`
+	N            int    `json:"n"`
+	Raw          bool   `json:"raw"`
+	Brand        bool   `json:"brand"`
+	Output       string `json:"output"`
+}
+
+// savedFile is a generated image and what produced it
+type savedFile struct {
+	Path     string             `json:"path"`
+	Metadata *metadata.Metadata `json:"metadata,omitempty"`
+}
+
+// generateIcon runs generate_icon
+func (s *Server) generateIcon(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args generateArgs
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	options, err := generator.OptionsFor(cfg, generator.Settings{
+		Prompt:       args.Prompt,
+		Preset:       args.Preset,
+		Template:     args.Template,
+		Size:         args.Size,
+		Quality:      args.Quality,
+		Background:   args.Background,
+		OutputFormat: args.OutputFormat,
+		NumImages:    args.N,
+		Raw:          args.Raw,
+		Brand:        args.Brand,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Assistants run the server from anywhere, so resolve the output
+	// directory to an absolute path they can use
+	output := utils.ExpandHome(args.Output)
+	if output == "" {
+		if output, err = config.DefaultService.GetDefaultOutputPath(); err != nil {
+			return nil, err
+		}
+		output = utils.ExpandHome(output)
+	}
+	if options.Output, err = filepath.Abs(output); err != nil {
+		return nil, err
+	}
+	if err := s.validate(options); err != nil {
+		return nil, err
+	}
+
+	outcome, err := s.generate(ctx, options, args.Preset, nil)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]savedFile, 0, len(outcome.Files))
+	for _, path := range outcome.Files {
+		file := savedFile{Path: path}
+		if md, _, err := metadata.Read(path); err == nil {
+			file.Metadata = md
+		}
+		files = append(files, file)
+	}
+	return map[string]any{
+		"files":      files,
+		"output_dir": outcome.OutputDir,
+		"history_id": outcome.HistoryID,
+		"cost_usd":   outcome.Result.Cost,
+		"warnings":   outcome.Warnings,
+	}, nil
+}
+
+// exportIconSet runs export_icon_set
+func (s *Server) exportIconSet(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Image   string   `json:"image"`
+		Targets []string `json:"targets"`
+		Output  string   `json:"output"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if args.Image == "" {
+		return nil, errors.New("image is required")
+	}
+	targets, err := iconset.ParseTargets(args.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	// The image is a file or a gallery reference
+	path := utils.ExpandHome(args.Image)
+	if _, err := os.Stat(path); err != nil {
+		entry, findErr := gallery.DefaultStore().Find(args.Image)
+		if findErr != nil {
+			return nil, fmt.Errorf("image %q is neither a file nor a gallery entry: %w", args.Image, findErr)
+		}
+		path = entry.Path
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	dir := utils.ExpandHome(args.Output)
+	if dir == "" {
+		dir = iconset.DefaultDir(path)
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+
+	dirs, err := iconset.Export(path, dir, targets)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	err = filepath.WalkDir(dir, func(file string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, file)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"output_dir": dir, "directories": dirs, "files": files}, nil
+}
+
+// listPresets runs list_presets
+func (s *Server) listPresets(ctx context.Context, raw json.RawMessage) (any, error) {
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	type presetInfo struct {
+		Name    string `json:"name"`
+		Summary string `json:"summary,omitempty"`
+		types.Preset
+	}
+	type templateInfo struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		Platform    string `json:"platform,omitempty"`
+		BuiltIn     bool   `json:"built_in"`
+	}
+	presetList := make([]presetInfo, 0, len(cfg.Presets))
+	for _, preset := range presets.List(cfg.Presets) {
+		presetList = append(presetList, presetInfo{Name: preset.Name, Summary: presets.Summary(preset.Preset), Preset: preset.Preset})
+	}
+	var templateList []templateInfo
+	for _, template := range templates.List(cfg.Templates) {
+		templateList = append(templateList, templateInfo{
+			Name:        template.Name,
+			Description: template.Description,
+			Platform:    template.Platform,
+			BuiltIn:     template.BuiltIn,
+		})
+	}
+	return map[string]any{"presets": presetList, "templates": templateList}, nil
+}
+
+// toolList describes the tools for tools/list
+func toolList() []map[string]any {
+	list := make([]map[string]any, 0, len(toolOrder))
+	for _, name := range toolOrder {
+		list = append(list, map[string]any{
+			"name":        name,
+			"description": tools[name].description,
+			"inputSchema": tools[name].schema,
+		})
+	}
+	return list
+}
+
+// decodeArgs reads tool arguments, rejecting unknown ones so typos are
+// reported instead of ignored
+func decodeArgs(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = json.RawMessage("{}")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// targetNames lists the export targets
+func targetNames() []string {
+	names := make([]string, len(iconset.Targets))
+	for i, target := range iconset.Targets {
+		names[i] = string(target)
+	}
+	return names
+}
+
+// object is the schema of an object with the given properties
+func object(properties map[string]any, required ...string) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stringProp is the schema of a string
+func stringProp(description string) map[string]any {
+	return map[string]any{"type": "string", "description": description}
+}
+
+// boolProp is the schema of a boolean
+func boolProp(description string) map[string]any {
+	return map[string]any{"type": "boolean", "description": description}
+}
+
+// enumProp is the schema of a string with the given values
+func enumProp(description string, values []string) map[string]any {
+	schema := map[string]any{"type": "string", "enum": values}
+	if description != "" {
+		schema["description"] = description
+	}
+	return schema
+}
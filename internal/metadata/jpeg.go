@@ -0,0 +1,264 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// EXIF tags written by Embed
+const (
+	tagImageDescription = 0x010E
+	tagSoftware         = 0x0131
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagUserComment      = 0x9286
+
+	tiffASCII     = 2
+	tiffLong      = 4
+	tiffUndefined = 7
+
+	// maxSegmentPayload is the largest APP1 payload a JPEG segment can hold
+	maxSegmentPayload = 0xFFFF - 2
+	exifDateFormat    = "2006:01:02 15:04:05"
+)
+
+var (
+	exifHeader         = []byte("Exif\x00\x00")
+	asciiCharacterCode = []byte("ASCII\x00\x00\x00")
+
+	errInvalidJPEG        = errors.New("invalid JPEG data")
+	errMetadataTooLarge   = errors.New("metadata is too large for a JPEG EXIF segment")
+	errUnsupportedComment = errors.New("unsupported EXIF user comment encoding")
+)
+
+// ifdEntry is one TIFF directory entry before layout
+type ifdEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// embedJPEG writes an EXIF APP1 segment after SOI and any APP0 segments,
+// replacing existing EXIF. The metadata JSON goes in UserComment.
+func embedJPEG(data []byte, md Metadata) ([]byte, error) {
+	raw, err := asciiJSON(md)
+	if err != nil {
+		return nil, err
+	}
+
+	exifEntries := []ifdEntry{
+		{tag: tagUserComment, typ: tiffUndefined, value: append(append([]byte(nil), asciiCharacterCode...), raw...)},
+	}
+	mainEntries := []ifdEntry{
+		asciiEntry(tagImageDescription, md.Prompt),
+		asciiEntry(tagSoftware, Generator),
+	}
+	if !md.Timestamp.IsZero() {
+		mainEntries = append(mainEntries, asciiEntry(tagDateTime, md.Timestamp.Format(exifDateFormat)))
+	}
+
+	payload := append([]byte(nil), exifHeader...)
+	payload = append(payload, buildTIFF(mainEntries, exifEntries)...)
+	if len(payload) > maxSegmentPayload {
+		return nil, errMetadataTooLarge
+	}
+
+	segments, rest, err := readJPEGHeaderSegments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:2]) // SOI
+	inserted := false
+	for _, segment := range segments {
+		marker := segment[1]
+		if marker == 0xE1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			continue
+		}
+		if !inserted && marker != 0xE0 {
+			writeAPP1(&out, payload)
+			inserted = true
+		}
+		out.Write(segment)
+	}
+	if !inserted {
+		writeAPP1(&out, payload)
+	}
+	out.Write(rest)
+	return out.Bytes(), nil
+}
+
+// extractJPEG returns the metadata JSON from the EXIF UserComment
+func extractJPEG(data []byte) ([]byte, error) {
+	segments, _, err := readJPEGHeaderSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, segment := range segments {
+		if segment[1] != 0xE1 || !bytes.HasPrefix(segment[4:], exifHeader) {
+			continue
+		}
+		comment, err := readUserComment(segment[4+len(exifHeader):])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(comment, asciiCharacterCode) {
+			return nil, errUnsupportedComment
+		}
+		return bytes.TrimRight(comment[len(asciiCharacterCode):], "\x00"), nil
+	}
+	return nil, ErrNoMetadata
+}
+
+// readJPEGHeaderSegments returns the marker segments between SOI and the
+// start of scan, and the remaining data from the start of scan on
+func readJPEGHeaderSegments(data []byte) ([][]byte, []byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, nil, errInvalidJPEG
+	}
+
+	var segments [][]byte
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, nil, errInvalidJPEG
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return segments, data[pos:], nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, nil, errInvalidJPEG
+		}
+		segments = append(segments, data[pos:end])
+		pos = end
+	}
+	return nil, nil, errInvalidJPEG
+}
+
+// writeAPP1 writes an APP1 segment with the given payload
+func writeAPP1(w *bytes.Buffer, payload []byte) {
+	w.Write([]byte{0xFF, 0xE1})
+	binary.Write(w, binary.BigEndian, uint16(len(payload)+2))
+	w.Write(payload)
+}
+
+// asciiEntry builds a NUL-terminated ASCII entry
+func asciiEntry(tag uint16, text string) ifdEntry {
+	return ifdEntry{tag: tag, typ: tiffASCII, value: append([]byte(text), 0)}
+}
+
+// buildTIFF lays out a big-endian TIFF structure with IFD0 holding
+// mainEntries and a pointer to an EXIF IFD holding exifEntries
+func buildTIFF(mainEntries, exifEntries []ifdEntry) []byte {
+	order := binary.BigEndian
+	ifdSize := func(entries []ifdEntry) int { return 2 + 12*len(entries) + 4 }
+
+	mainEntries = append(mainEntries, ifdEntry{tag: tagExifIFD, typ: tiffLong, count: 1})
+	mainOffset := 8
+	exifOffset := mainOffset + ifdSize(mainEntries)
+	dataOffset := exifOffset + ifdSize(exifEntries)
+
+	mainEntries[len(mainEntries)-1].value = order.AppendUint32(nil, uint32(exifOffset))
+
+	var values []byte
+	writeIFD := func(entries []ifdEntry) []byte {
+		ifd := order.AppendUint16(nil, uint16(len(entries)))
+		for _, entry := range entries {
+			count := entry.count
+			if count == 0 {
+				count = uint32(len(entry.value))
+			}
+			ifd = order.AppendUint16(ifd, entry.tag)
+			ifd = order.AppendUint16(ifd, entry.typ)
+			ifd = order.AppendUint32(ifd, count)
+			if len(entry.value) <= 4 {
+				field := make([]byte, 4)
+				copy(field, entry.value)
+				ifd = append(ifd, field...)
+				continue
+			}
+			ifd = order.AppendUint32(ifd, uint32(dataOffset+len(values)))
+			values = append(values, entry.value...)
+			if len(values)%2 == 1 {
+				values = append(values, 0) // keep offsets word aligned
+			}
+		}
+		return order.AppendUint32(ifd, 0) // no next IFD
+	}
+
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8}
+	tiff = append(tiff, writeIFD(mainEntries)...)
+	tiff = append(tiff, writeIFD(exifEntries)...)
+	return append(tiff, values...)
+}
+
+// readUserComment finds the UserComment value in a TIFF structure of either
+// byte order
+func readUserComment(tiff []byte) ([]byte, error) {
+	if len(tiff) < 8 {
+		return nil, errInvalidJPEG
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "MM":
+		order = binary.BigEndian
+	case "II":
+		order = binary.LittleEndian
+	default:
+		return nil, errInvalidJPEG
+	}
+
+	exifOffset, err := findIFDValue(tiff, order, int(order.Uint32(tiff[4:])), tagExifIFD)
+	if err != nil {
+		return nil, err
+	}
+	return findIFDValue(tiff, order, int(order.Uint32(exifOffset)), tagUserComment)
+}
+
+// findIFDValue returns the raw value of tag in the IFD at offset; values of
+// four bytes or less are returned from the entry itself
+func findIFDValue(tiff []byte, order binary.ByteOrder, offset int, tag uint16) ([]byte, error) {
+	if offset < 8 || offset+2 > len(tiff) {
+		return nil, errInvalidJPEG
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + 12*i
+		if entry+12 > len(tiff) {
+			return nil, errInvalidJPEG
+		}
+		if order.Uint16(tiff[entry:]) != tag {
+			continue
+		}
+		size := int(order.Uint32(tiff[entry+4:])) * typeSize(order.Uint16(tiff[entry+2:]))
+		if size <= 4 {
+			return tiff[entry+8 : entry+8+size], nil
+		}
+		start := int(order.Uint32(tiff[entry+8:]))
+		if start < 0 || start+size > len(tiff) {
+			return nil, errInvalidJPEG
+		}
+		return tiff[start : start+size], nil
+	}
+	return nil, ErrNoMetadata
+}
+
+// typeSize returns the byte size of a TIFF field type
+func typeSize(typ uint16) int {
+	switch typ {
+	case 3: // SHORT
+		return 2
+	case tiffLong, 9: // LONG, SLONG
+		return 4
+	case 5, 10, 12: // RATIONAL, SRATIONAL, DOUBLE
+		return 8
+	default: // BYTE, ASCII, UNDEFINED and other single-byte types
+		return 1
+	}
+}
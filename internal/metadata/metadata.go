@@ -0,0 +1,230 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"just-icon/internal/types"
+)
+
+const (
+	// Generator identifies images written by this tool
+	Generator = "just-icon"
+	// Version is the metadata format version
+	Version = 1
+	// SidecarExt replaces the image extension for the JSON sidecar
+	SidecarExt = ".json"
+)
+
+// Source tells where metadata was read from
+type Source string
+
+const (
+	SourceEmbedded Source = "embedded"
+	SourceSidecar  Source = "sidecar"
+)
+
+var (
+	ErrNoMetadata        = errors.New("no just-icon metadata found")
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+)
+
+// Metadata describes how an image was generated. It is written to a JSON
+// sidecar next to the image and embedded in the image file itself.
+type Metadata struct {
+	Generator string `json:"generator"`
+	Version   int    `json:"version"`
+	// Prompt is the user's prompt, after enhancement when it was enhanced
+	Prompt         string `json:"prompt"`
+	OriginalPrompt string `json:"original_prompt,omitempty"`
+	// FinalPrompt is the templated prompt sent to the API
+	FinalPrompt string `json:"final_prompt,omitempty"`
+	// RevisedPrompt is the prompt the model reports having used
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+	Template      string `json:"template,omitempty"`
+	Preset        string `json:"preset,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	Model         string `json:"model"`
+	Size          string `json:"size,omitempty"`
+	Quality       string `json:"quality,omitempty"`
+	Background    string `json:"background,omitempty"`
+	OutputFormat  string `json:"output_format,omitempty"`
+	Provider      string `json:"provider,omitempty"`
+	// CostUSD is this image's share of the estimated request cost
+	CostUSD   float64   `json:"cost_usd"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// New builds the metadata for the index-th image of a generation
+func New(options *types.IconGenerationOptions, result *types.GenerationResult, index int, preset string) Metadata {
+	md := Metadata{
+		Generator:      Generator,
+		Version:        Version,
+		Prompt:         options.Prompt,
+		OriginalPrompt: options.OriginalPrompt,
+		FinalPrompt:    result.Prompt,
+		Template:       result.Template,
+		Preset:         preset,
+		Reference:      options.Reference,
+		Model:          result.Model,
+		Size:           result.Size,
+		Quality:        result.Quality,
+		Background:     result.Background,
+		OutputFormat:   result.OutputFormat,
+		Provider:       result.Provider,
+		Timestamp:      result.Created,
+	}
+	if index >= 0 && index < len(result.Images) {
+		md.RevisedPrompt = result.Images[index].RevisedPrompt
+	}
+	if len(result.Images) > 0 {
+		md.CostUSD = result.Cost / float64(len(result.Images))
+	}
+	return md
+}
+
+// SidecarPath returns the JSON sidecar path for an image
+func SidecarPath(imagePath string) string {
+	return strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + SidecarExt
+}
+
+// WriteSidecar writes the metadata as indented JSON next to the image. An
+// existing sidecar is never replaced.
+func WriteSidecar(imagePath string, md Metadata) error {
+	data, err := json.MarshalIndent(md, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	file, err := os.OpenFile(SidecarPath(imagePath), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata sidecar: %w", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write metadata sidecar: %w", err)
+	}
+	return nil
+}
+
+// Embed returns a copy of the image with the metadata embedded: PNG text
+// chunks, a WebP XMP chunk or JPEG EXIF, depending on the image format
+func Embed(data []byte, md Metadata) ([]byte, error) {
+	switch detectFormat(data) {
+	case "png":
+		return embedPNG(data, md)
+	case "webp":
+		return embedWebP(data, md)
+	case "jpeg":
+		return embedJPEG(data, md)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Extract reads metadata embedded in an image
+func Extract(data []byte) (*Metadata, error) {
+	var (
+		raw []byte
+		err error
+	)
+	switch detectFormat(data) {
+	case "png":
+		raw, err = extractPNG(data)
+	case "webp":
+		raw, err = extractWebP(data)
+	case "jpeg":
+		raw, err = extractJPEG(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decode(raw)
+}
+
+// Read returns the metadata for an image, preferring what is embedded in the
+// file and falling back to the sidecar
+func Read(imagePath string) (*Metadata, Source, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, "", err
+	}
+	if md, err := Extract(data); err == nil {
+		return md, SourceEmbedded, nil
+	}
+
+	raw, err := os.ReadFile(SidecarPath(imagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", ErrNoMetadata
+		}
+		return nil, "", err
+	}
+	md, err := decode(raw)
+	if err != nil {
+		return nil, "", err
+	}
+	return md, SourceSidecar, nil
+}
+
+// decode parses metadata JSON, rejecting JSON written by other tools
+func decode(raw []byte) (*Metadata, error) {
+	var md Metadata
+	if err := json.Unmarshal(raw, &md); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	if md.Generator != Generator {
+		return nil, ErrNoMetadata
+	}
+	return &md, nil
+}
+
+// detectFormat identifies the image format from its magic bytes
+func detectFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, pngSignature):
+		return "png"
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "webp"
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return "jpeg"
+	default:
+		return ""
+	}
+}
+
+// asciiJSON encodes v as JSON with every non-ASCII character escaped, for
+// containers that only carry 7-bit text
+func asciiJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, r := range string(data) {
+		if r < 0x80 {
+			buf.WriteRune(r)
+			continue
+		}
+		if r > 0xFFFF {
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, `\u%04x\u%04x`, r1, r2)
+		} else {
+			fmt.Fprintf(&buf, `\u%04x`, r)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,219 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/webp"
+
+	"just-icon/internal/types"
+)
+
+// 1x1 simple-format WebP images
+const (
+	losslessWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+	lossyWebP    = "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"
+)
+
+func testMetadata() Metadata {
+	return Metadata{
+		Generator:      Generator,
+		Version:        Version,
+		Prompt:         "玻璃质感的火箭 🚀 with <angle> & \"quotes\"",
+		OriginalPrompt: "rocket",
+		FinalPrompt:    "Create a 1024x1024 px iOS app icon: rocket",
+		RevisedPrompt:  "a glass rocket",
+		Template:       "ios",
+		Model:          types.ModelGPTImage1,
+		Size:           types.SizeSmall,
+		Quality:        types.QualityHigh,
+		Background:     "transparent",
+		OutputFormat:   "png",
+		Provider:       types.ProviderOpenAI,
+		CostUSD:        0.167,
+		Timestamp:      time.Date(2025, 7, 6, 22, 1, 23, 0, time.UTC),
+	}
+}
+
+func testImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	img.Set(1, 1, color.RGBA{R: 0xff, A: 0xff})
+	return img
+}
+
+func encodePNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodeWebP(t *testing.T, encoded string) []byte {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEmbedRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		decode func([]byte) error
+	}{
+		{"png", encodePNG(t), func(b []byte) error { _, err := png.Decode(bytes.NewReader(b)); return err }},
+		{"jpeg", encodeJPEG(t), func(b []byte) error { _, err := jpeg.Decode(bytes.NewReader(b)); return err }},
+		{"webp lossless", decodeWebP(t, losslessWebP), func(b []byte) error { _, err := webp.Decode(bytes.NewReader(b)); return err }},
+		{"webp lossy", decodeWebP(t, lossyWebP), func(b []byte) error { _, err := webp.Decode(bytes.NewReader(b)); return err }},
+	}
+
+	want := testMetadata()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Extract(tt.data); !errors.Is(err, ErrNoMetadata) {
+				t.Fatalf("Extract() on a plain image error = %v, want ErrNoMetadata", err)
+			}
+
+			first, err := Embed(tt.data, want)
+			if err != nil {
+				t.Fatalf("Embed() error = %v", err)
+			}
+			// Embedding again must replace, not duplicate, the metadata
+			embedded, err := Embed(first, want)
+			if err != nil {
+				t.Fatalf("second Embed() error = %v", err)
+			}
+			if len(embedded) != len(first) {
+				t.Errorf("second Embed() grew the image from %d to %d bytes", len(first), len(embedded))
+			}
+			if err := tt.decode(embedded); err != nil {
+				t.Fatalf("image no longer decodes: %v", err)
+			}
+
+			got, err := Extract(embedded)
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if *got != want {
+				t.Errorf("Extract() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestEmbedWebPAddsExtendedHeader(t *testing.T) {
+	embedded, err := Embed(decodeWebP(t, losslessWebP), testMetadata())
+	if err != nil {
+		t.Fatalf("Embed() error = %v", err)
+	}
+
+	chunks, err := readRIFFChunks(embedded)
+	if err != nil {
+		t.Fatalf("readRIFFChunks() error = %v", err)
+	}
+	if chunks[0].fourcc != "VP8X" || chunks[0].data[0]&vp8xXMPFlag == 0 {
+		t.Errorf("expected a VP8X header with the XMP flag, got %q %x", chunks[0].fourcc, chunks[0].data)
+	}
+	config, err := webp.DecodeConfig(bytes.NewReader(embedded))
+	if err != nil || config.Width != 1 || config.Height != 1 {
+		t.Errorf("DecodeConfig() = %+v, %v; want 1x1", config, err)
+	}
+}
+
+func TestEmbedUnsupportedFormat(t *testing.T) {
+	if _, err := Embed([]byte("GIF89a"), testMetadata()); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Embed() error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestEmbedJPEGTooLarge(t *testing.T) {
+	md := testMetadata()
+	md.FinalPrompt = strings.Repeat("x", maxSegmentPayload)
+	if _, err := Embed(encodeJPEG(t), md); !errors.Is(err, errMetadataTooLarge) {
+		t.Errorf("Embed() error = %v, want errMetadataTooLarge", err)
+	}
+}
+
+func TestReadFallsBackToSidecar(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "icon.png")
+	if err := os.WriteFile(path, encodePNG(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := Read(path); !errors.Is(err, ErrNoMetadata) {
+		t.Fatalf("Read() without metadata error = %v, want ErrNoMetadata", err)
+	}
+
+	want := testMetadata()
+	if err := WriteSidecar(path, want); err != nil {
+		t.Fatalf("WriteSidecar() error = %v", err)
+	}
+	if SidecarPath(path) != filepath.Join(dir, "icon.json") {
+		t.Errorf("SidecarPath() = %q", SidecarPath(path))
+	}
+
+	got, source, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if source != SourceSidecar || *got != want {
+		t.Errorf("Read() = %+v from %s, want %+v from sidecar", *got, source, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	options := &types.IconGenerationOptions{Prompt: "a glass rocket", OriginalPrompt: "rocket"}
+	result := &types.GenerationResult{
+		Images:   []types.GeneratedImage{{RevisedPrompt: "first"}, {RevisedPrompt: "second"}},
+		Prompt:   "final",
+		Template: "ios",
+		Model:    types.ModelGPTImage1,
+		Provider: types.ProviderAzure,
+		Cost:     0.5,
+	}
+
+	md := New(options, result, 1, "brand")
+	if md.Generator != Generator || md.Prompt != "a glass rocket" || md.OriginalPrompt != "rocket" ||
+		md.FinalPrompt != "final" || md.RevisedPrompt != "second" || md.Preset != "brand" ||
+		md.Provider != types.ProviderAzure || md.CostUSD != 0.25 {
+		t.Errorf("New() = %+v", md)
+	}
+}
+
+func TestWriteSidecarKeepsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(SidecarPath(path), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteSidecar(path, testMetadata()); err == nil {
+		t.Fatal("WriteSidecar() should not replace an existing file")
+	}
+	if data, _ := os.ReadFile(SidecarPath(path)); string(data) != "notes" {
+		t.Errorf("existing sidecar changed to %q", data)
+	}
+}
@@ -0,0 +1,180 @@
+package metadata
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// PNG text keywords written by Embed
+const (
+	pngKeySoftware    = "Software"
+	pngKeyDescription = "Description"
+	pngKeyMetadata    = "just-icon"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var errInvalidPNG = errors.New("invalid PNG data")
+
+// pngChunk is one length-type-data-crc chunk
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// embedPNG inserts a tEXt Software chunk and iTXt Description and metadata
+// chunks after the header, replacing chunks a previous Embed wrote
+func embedPNG(data []byte, md Metadata) ([]byte, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+
+	added := []pngChunk{
+		{typ: "tEXt", data: textChunk(pngKeySoftware, Generator)},
+		{typ: "iTXt", data: itxtChunk(pngKeyDescription, md.Prompt)},
+		{typ: "iTXt", data: itxtChunk(pngKeyMetadata, string(raw))},
+	}
+
+	var out bytes.Buffer
+	out.Write(pngSignature)
+	for _, chunk := range chunks {
+		if keyword, _, ok := parseTextChunk(chunk); ok && isOwnPNGKeyword(keyword) {
+			continue
+		}
+		writePNGChunk(&out, chunk)
+		if chunk.typ == "IHDR" {
+			for _, text := range added {
+				writePNGChunk(&out, text)
+			}
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// extractPNG returns the metadata JSON from the iTXt chunk
+func extractPNG(data []byte) ([]byte, error) {
+	chunks, err := readPNGChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		if keyword, text, ok := parseTextChunk(chunk); ok && keyword == pngKeyMetadata {
+			return text, nil
+		}
+	}
+	return nil, ErrNoMetadata
+}
+
+// isOwnPNGKeyword reports whether a text chunk keyword is one Embed writes
+func isOwnPNGKeyword(keyword string) bool {
+	return keyword == pngKeySoftware || keyword == pngKeyDescription || keyword == pngKeyMetadata
+}
+
+// readPNGChunks splits PNG data into chunks, stopping after IEND
+func readPNGChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errInvalidPNG
+	}
+
+	var chunks []pngChunk
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errInvalidPNG
+		}
+		chunk := pngChunk{typ: string(data[pos+4 : pos+8]), data: data[pos+8 : pos+8+length]}
+		chunks = append(chunks, chunk)
+		pos = end
+		if chunk.typ == "IEND" {
+			return chunks, nil
+		}
+	}
+	return nil, errInvalidPNG
+}
+
+// writePNGChunk writes a chunk with its length and CRC
+func writePNGChunk(w *bytes.Buffer, chunk pngChunk) {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(chunk.data)))
+	copy(header[4:], chunk.typ)
+	w.Write(header[:])
+	w.Write(chunk.data)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(chunk.data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	w.Write(sum[:])
+}
+
+// textChunk builds tEXt data; keyword and text must be Latin-1
+func textChunk(keyword, text string) []byte {
+	return append(append([]byte(keyword), 0), text...)
+}
+
+// itxtChunk builds uncompressed iTXt data holding UTF-8 text
+func itxtChunk(keyword, text string) []byte {
+	data := append([]byte(keyword), 0, 0, 0) // no compression
+	data = append(data, 0, 0)                // empty language tag and translated keyword
+	return append(data, text...)
+}
+
+// parseTextChunk returns the keyword and text of a tEXt or iTXt chunk
+func parseTextChunk(chunk pngChunk) (string, []byte, bool) {
+	if chunk.typ != "tEXt" && chunk.typ != "iTXt" {
+		return "", nil, false
+	}
+	keyword, rest, ok := bytes.Cut(chunk.data, []byte{0})
+	if !ok {
+		return "", nil, false
+	}
+	if chunk.typ == "tEXt" {
+		return string(keyword), rest, true
+	}
+
+	// iTXt: compression flag, compression method, language tag, translated keyword
+	if len(rest) < 2 {
+		return "", nil, false
+	}
+	compressed := rest[0] == 1
+	_, rest, ok = bytes.Cut(rest[2:], []byte{0})
+	if !ok {
+		return "", nil, false
+	}
+	_, text, ok := bytes.Cut(rest, []byte{0})
+	if !ok {
+		return "", nil, false
+	}
+	if compressed {
+		inflated, err := inflate(text)
+		if err != nil {
+			return "", nil, false
+		}
+		text = inflated
+	}
+	return string(keyword), text, true
+}
+
+// inflate decompresses zlib data from a compressed iTXt chunk
+func inflate(data []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress text chunk: %w", err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,196 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	// xmpNamespace holds the just-icon properties in XMP packets
+	xmpNamespace = "https://github.com/hellokaton/just-icon/ns/1.0/"
+	// vp8xXMPFlag marks a VP8X file that carries XMP
+	vp8xXMPFlag = 0x04
+)
+
+var errInvalidWebP = errors.New("invalid WebP data")
+
+// riffChunk is one fourcc-size-payload chunk of a RIFF file
+type riffChunk struct {
+	fourcc string
+	data   []byte
+}
+
+// embedWebP stores the metadata in an XMP chunk, converting simple VP8/VP8L
+// files to the extended format that can carry one
+func embedWebP(data []byte, md Metadata) ([]byte, error) {
+	chunks, err := readRIFFChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	packet, err := xmpPacket(md)
+	if err != nil {
+		return nil, err
+	}
+
+	if chunks[0].fourcc != "VP8X" {
+		header, err := vp8xHeader(data)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append([]riffChunk{{fourcc: "VP8X", data: header}}, chunks...)
+	}
+	header := append([]byte(nil), chunks[0].data...)
+	header[0] |= vp8xXMPFlag
+	chunks[0].data = header
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, chunk := range chunks {
+		if chunk.fourcc != "XMP " {
+			writeRIFFChunk(&body, chunk)
+		}
+	}
+	writeRIFFChunk(&body, riffChunk{fourcc: "XMP ", data: packet})
+
+	var out bytes.Buffer
+	out.WriteString("RIFF")
+	binary.Write(&out, binary.LittleEndian, uint32(body.Len()))
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// extractWebP returns the metadata JSON from the XMP chunk
+func extractWebP(data []byte) ([]byte, error) {
+	chunks, err := readRIFFChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range chunks {
+		if chunk.fourcc == "XMP " {
+			return xmpMetadata(chunk.data)
+		}
+	}
+	return nil, ErrNoMetadata
+}
+
+// readRIFFChunks splits a WebP file into its chunks
+func readRIFFChunks(data []byte) ([]riffChunk, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errInvalidWebP
+	}
+	size := int(binary.LittleEndian.Uint32(data[4:8]))
+	if size+8 < len(data) {
+		data = data[:size+8]
+	}
+
+	var chunks []riffChunk
+	pos := 12
+	for pos+8 <= len(data) {
+		length := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			return nil, errInvalidWebP
+		}
+		chunks = append(chunks, riffChunk{fourcc: string(data[pos : pos+4]), data: data[pos+8 : end]})
+		pos = end + length%2 // chunks are padded to an even size
+	}
+	if len(chunks) == 0 {
+		return nil, errInvalidWebP
+	}
+	return chunks, nil
+}
+
+// writeRIFFChunk writes a chunk with its size and padding
+func writeRIFFChunk(w *bytes.Buffer, chunk riffChunk) {
+	w.WriteString(chunk.fourcc)
+	binary.Write(w, binary.LittleEndian, uint32(len(chunk.data)))
+	w.Write(chunk.data)
+	if len(chunk.data)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+// vp8xHeader builds a VP8X header for a simple WebP file from its canvas
+// size. The alpha flag stays clear: a lossless bitstream carries its own
+// alpha, and decoders such as x/image reject the flag in front of one.
+func vp8xHeader(data []byte) ([]byte, error) {
+	config, err := webp.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 10)
+	putUint24(header[4:7], uint32(config.Width-1))
+	putUint24(header[7:10], uint32(config.Height-1))
+	return header, nil
+}
+
+// putUint24 writes a little-endian 24-bit value
+func putUint24(b []byte, v uint32) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
+
+// xmpPacket renders an XMP packet with the prompt as dc:description and the
+// full metadata as JSON in the just-icon namespace
+func xmpPacket(md Metadata) ([]byte, error) {
+	raw, err := json.Marshal(md)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">` + "\n")
+	b.WriteString(` <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	b.WriteString(`  <rdf:Description rdf:about=""` + "\n")
+	b.WriteString(`    xmlns:xmp="http://ns.adobe.com/xap/1.0/"` + "\n")
+	b.WriteString(`    xmlns:dc="http://purl.org/dc/elements/1.1/"` + "\n")
+	b.WriteString(`    xmlns:justicon="` + xmpNamespace + `"` + "\n")
+	b.WriteString(`    xmp:CreatorTool="` + Generator + `"`)
+	if !md.Timestamp.IsZero() {
+		b.WriteString("\n" + `    xmp:CreateDate="` + md.Timestamp.Format(time.RFC3339) + `"`)
+	}
+	b.WriteString(">\n")
+	b.WriteString(`   <dc:description><rdf:Alt><rdf:li xml:lang="x-default">`)
+	xml.EscapeText(&b, []byte(md.Prompt))
+	b.WriteString("</rdf:li></rdf:Alt></dc:description>\n")
+	b.WriteString("   <justicon:metadata>")
+	xml.EscapeText(&b, raw)
+	b.WriteString("</justicon:metadata>\n")
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return []byte(b.String()), nil
+}
+
+// xmpMetadata returns the text of the just-icon metadata element
+func xmpMetadata(packet []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, ErrNoMetadata
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Space != xmpNamespace || start.Name.Local != "metadata" {
+			continue
+		}
+		var text string
+		if err := decoder.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		return []byte(text), nil
+	}
+}
@@ -0,0 +1,134 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"just-icon/internal/types"
+)
+
+// tokenCommandTimeout bounds the external command that fetches an Entra ID token
+const tokenCommandTimeout = 30 * time.Second
+
+// NewAzureClient creates a client for an Azure OpenAI resource. The
+// credential is an API key or an Entra ID access token depending on azure.Auth.
+func NewAzureClient(azure *types.AzureConfig, credential string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	config := openai.DefaultAzureConfig(credential, strings.TrimRight(azure.Endpoint, "/"))
+	if azure.Auth == types.AzureAuthAAD {
+		config.APIType = openai.APITypeAzureAD
+	}
+	config.APIVersion = azure.APIVersion
+	if config.APIVersion == "" {
+		config.APIVersion = types.DefaultAzureAPIVersion
+	}
+	config.HTTPClient = httpClient
+
+	deployments := azure.Deployments
+	config.AzureModelMapperFunc = func(model string) string {
+		if deployment, ok := deploymentForModel(deployments, model); ok {
+			return deployment
+		}
+		return model
+	}
+
+	return &Client{
+		client:      openai.NewClientWithConfig(config),
+		httpClient:  httpClient,
+		provider:    types.ProviderAzure,
+		deployments: deployments,
+		baseURL:     strings.TrimRight(azure.Endpoint, "/"),
+		credential:  credential,
+		apiVersion:  config.APIVersion,
+		azureAuth:   azure.Auth,
+	}
+}
+
+// AzureCredential resolves the API key or Entra ID token for the configured
+// auth mode, falling back to the standard Azure environment variables
+func AzureCredential(ctx context.Context, azure *types.AzureConfig) (string, error) {
+	if azure.Auth != types.AzureAuthAAD {
+		if azure.APIKey != "" {
+			return azure.APIKey, nil
+		}
+		if key := os.Getenv(types.AzureAPIKeyEnv); key != "" {
+			return key, nil
+		}
+		return "", fmt.Errorf("Azure API key not configured. Run: just-icon config --azure-api-key YOUR_KEY or set %s", types.AzureAPIKeyEnv)
+	}
+
+	if token := os.Getenv(types.AzureADTokenEnv); token != "" {
+		return token, nil
+	}
+	if azure.TokenCommand == "" {
+		return "", fmt.Errorf("Entra ID token not available. Set %s or configure a token command with: just-icon config --azure-token-command \"az account get-access-token --resource https://cognitiveservices.azure.com --query accessToken -o tsv\"", types.AzureADTokenEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", azure.TokenCommand)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", azure.TokenCommand)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("token command returned an empty token")
+	}
+	return token, nil
+}
+
+// ValidateAzureConfig checks that the Azure settings are complete and that at
+// least one deployment serves a supported image model
+func ValidateAzureConfig(azure *types.AzureConfig) error {
+	if azure == nil || azure.Endpoint == "" {
+		return fmt.Errorf("Azure endpoint not configured. Run: just-icon config --azure-endpoint https://YOUR-RESOURCE.openai.azure.com")
+	}
+	if azure.Auth != "" && azure.Auth != types.AzureAuthKey && azure.Auth != types.AzureAuthAAD {
+		return fmt.Errorf("unsupported Azure auth %q (use %s or %s)", azure.Auth, types.AzureAuthKey, types.AzureAuthAAD)
+	}
+
+	for _, model := range types.SupportedModels {
+		if _, ok := deploymentForModel(azure.Deployments, model); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("no Azure deployment serves a supported image model (%s). Run: just-icon config --azure-deployment NAME=%s",
+		strings.Join(types.SupportedModels, ", "), types.ModelGPTImage1)
+}
+
+// deploymentForModel returns the deployment that serves the given model. When
+// several do, the alphabetically first one is used so the choice is stable.
+func deploymentForModel(deployments map[string]string, model string) (string, bool) {
+	names := make([]string, 0, len(deployments))
+	for name, deploymentModel := range deployments {
+		if deploymentModel == model {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+
+	sort.Strings(names)
+	return names[0], true
+}
@@ -0,0 +1,83 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestAzureClientRoutesToDeployment(t *testing.T) {
+	var gotPath, gotVersion, gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		gotKey = r.Header.Get("api-key")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"created":1,"data":[{"b64_json":"aWNvbg=="}]}`))
+	}))
+	defer server.Close()
+
+	client := NewAzureClient(&types.AzureConfig{
+		Endpoint:    server.URL,
+		APIVersion:  "2025-04-01-preview",
+		Deployments: map[string]string{"icons-prod": types.ModelGPTImage1},
+	}, "azure-key", nil)
+
+	result, err := client.GenerateIcon(context.Background(), &types.IconGenerationOptions{Prompt: "settings", Output: t.TempDir()})
+	if err != nil {
+		t.Fatalf("GenerateIcon() failed: %v", err)
+	}
+	if len(result.Images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(result.Images))
+	}
+	if result.Provider != types.ProviderAzure {
+		t.Errorf("expected azure provider, got %q", result.Provider)
+	}
+
+	if gotPath != "/openai/deployments/icons-prod/images/generations" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotVersion != "2025-04-01-preview" {
+		t.Errorf("unexpected api-version %q", gotVersion)
+	}
+	if gotKey != "azure-key" {
+		t.Errorf("unexpected api-key header %q", gotKey)
+	}
+}
+
+func TestAzureClientRequiresDeployment(t *testing.T) {
+	client := NewAzureClient(&types.AzureConfig{
+		Endpoint:    "https://example.openai.azure.com",
+		Deployments: map[string]string{"chat": "gpt-4o-mini"},
+	}, "azure-key", nil)
+
+	if _, err := client.GenerateIcon(context.Background(), &types.IconGenerationOptions{Prompt: "settings"}); err == nil {
+		t.Fatal("expected error when no deployment serves the model")
+	}
+}
+
+func TestAzureCredential(t *testing.T) {
+	t.Setenv(types.AzureADTokenEnv, "")
+
+	key, err := AzureCredential(context.Background(), &types.AzureConfig{APIKey: "from-config"})
+	if err != nil || key != "from-config" {
+		t.Errorf("expected configured key, got %q, %v", key, err)
+	}
+
+	token, err := AzureCredential(context.Background(), &types.AzureConfig{
+		Auth:         types.AzureAuthAAD,
+		TokenCommand: "echo token-from-command",
+	})
+	if err != nil || token != "token-from-command" {
+		t.Errorf("expected token from command, got %q, %v", token, err)
+	}
+
+	t.Setenv(types.AzureADTokenEnv, "token-from-env")
+	token, err = AzureCredential(context.Background(), &types.AzureConfig{Auth: types.AzureAuthAAD})
+	if err != nil || token != "token-from-env" {
+		t.Errorf("expected token from environment, got %q, %v", token, err)
+	}
+}
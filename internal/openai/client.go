@@ -3,114 +3,310 @@ package openai
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
 
+	"just-icon/internal/brand"
 	"just-icon/internal/config"
+	"just-icon/internal/httpclient"
+	"just-icon/internal/logging"
+	"just-icon/internal/templates"
 	"just-icon/internal/types"
+	"just-icon/pkg/utils"
 )
 
 // Client handles OpenAI API interactions
 type Client struct {
-	client *openai.Client
+	client      *openai.Client
+	httpClient  *http.Client
+	provider    string
+	deployments map[string]string
+	// baseURL, credential, apiVersion and azureAuth are used for requests
+	// go-openai cannot build, such as image edits
+	baseURL    string
+	credential string
+	apiVersion string
+	azureAuth  string
+	// templates and defaultTemplate come from the user's config
+	templates       map[string]*types.PromptTemplate
+	defaultTemplate string
 }
 
 // NewClient creates a new OpenAI client with custom base URL
 func NewClient(apiKey, baseURL string) *Client {
+	return NewClientWithHTTPClient(apiKey, baseURL, nil)
+}
+
+// NewClientWithHTTPClient creates a new OpenAI client that sends API calls
+// and image downloads through the given HTTP client
+func NewClientWithHTTPClient(apiKey, baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
 	config := openai.DefaultConfig(apiKey)
 	if baseURL != "" {
 		config.BaseURL = baseURL + types.APIVersion
 	} else {
 		config.BaseURL = types.DefaultBaseURL + types.APIVersion
 	}
+	config.HTTPClient = httpClient
 
 	return &Client{
-		client: openai.NewClientWithConfig(config),
+		client:     openai.NewClientWithConfig(config),
+		httpClient: httpClient,
+		provider:   types.ProviderOpenAI,
+		baseURL:    config.BaseURL,
+		credential: apiKey,
 	}
 }
 
 // NewClientFromConfig creates a new OpenAI client using configuration
 func NewClientFromConfig() (*Client, error) {
-	configService := config.DefaultService
-	apiKey, err := configService.GetAPIKey()
+	cfg, err := config.DefaultService.GetConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get API key from config: %w", err)
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	if apiKey == "" {
-		return nil, fmt.Errorf("API key not configured. Get your key at %s and run: just-icon config --api-key YOUR_KEY", types.DefaultBaseURL)
+	httpClient, err := httpclient.New(cfg.HTTP, APIURL(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP settings: %w", err)
 	}
 
-	baseURL, err := configService.GetBaseURL()
+	return NewClientForConfig(context.Background(), cfg, httpClient)
+}
+
+// APIURL returns the endpoint the provider selected in cfg is reached at
+func APIURL(cfg *types.Config) string {
+	if cfg.Provider == types.ProviderAzure {
+		if cfg.Azure == nil {
+			return ""
+		}
+		return cfg.Azure.Endpoint
+	}
+	if cfg.BaseURL == "" {
+		return types.DefaultValues.BaseURL
+	}
+	return cfg.BaseURL
+}
+
+// NewClientForConfig creates a client for the provider selected in cfg
+func NewClientForConfig(ctx context.Context, cfg *types.Config, httpClient *http.Client) (*Client, error) {
+	client, err := newProviderClient(ctx, cfg, httpClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get base URL from config: %w", err)
+		return nil, err
+	}
+	client.SetTemplates(cfg.Templates, cfg.DefaultTemplate)
+	return client, nil
+}
+
+// newProviderClient creates the provider-specific client for cfg
+func newProviderClient(ctx context.Context, cfg *types.Config, httpClient *http.Client) (*Client, error) {
+	if cfg.Provider == types.ProviderAzure {
+		if err := ValidateAzureConfig(cfg.Azure); err != nil {
+			return nil, err
+		}
+		credential, err := AzureCredential(ctx, cfg.Azure)
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureClient(cfg.Azure, credential, httpClient), nil
 	}
 
-	return NewClient(apiKey, baseURL), nil
+	if cfg.OpenAIAPIKey == "" {
+		return nil, fmt.Errorf("API key not configured. Get your key at %s and run: just-icon config --api-key YOUR_KEY", types.DefaultBaseURL)
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = types.DefaultValues.BaseURL
+	}
+
+	return NewClientWithHTTPClient(cfg.OpenAIAPIKey, baseURL, httpClient), nil
+}
+
+// SetTemplates sets the custom prompt templates and the template used when
+// options do not name one
+func (c *Client) SetTemplates(custom map[string]*types.PromptTemplate, defaultTemplate string) {
+	c.templates = custom
+	c.defaultTemplate = defaultTemplate
+}
+
+// Provider returns the provider this client talks to
+func (c *Client) Provider() string {
+	return c.provider
+}
+
+// GenerateIcon generates icons using OpenAI API and returns the base64
+// encoded images together with the request details and estimated cost
+func (c *Client) GenerateIcon(ctx context.Context, options *types.IconGenerationOptions) (*types.GenerationResult, error) {
+	return c.GenerateIconWithProgress(ctx, options, nil)
 }
 
-// GenerateIcon generates icons using OpenAI API and returns base64 encoded image data
-func (c *Client) GenerateIcon(options *types.IconGenerationOptions) ([]string, error) {
+// GenerateIconWithProgress generates icons like GenerateIcon, retrying rate
+// limits and server errors and telling the observer about every attempt and
+// image download. The observer may be nil. Canceling ctx stops the request,
+// the wait before a retry and the downloads.
+func (c *Client) GenerateIconWithProgress(ctx context.Context, options *types.IconGenerationOptions, observer Observer) (*types.GenerationResult, error) {
+	if observer == nil {
+		observer = noObserver{}
+	}
+
 	// Validate parameters
 	if err := c.validateParameters(options); err != nil {
 		return nil, err
 	}
 
 	// Build request
-	request := c.buildRequest(options)
-
-	// Log request details
-	if err := c.logRequest(request); err != nil {
-		fmt.Printf("Warning: Failed to log request: %v\n", err)
+	request, err := c.buildRequest(options)
+	if err != nil {
+		return nil, err
 	}
 
-	// Make API call
-	ctx := context.Background()
-	response, err := c.client.CreateImage(ctx, request)
+	// Log request details; the request ID ties the records of this call together
+	log := logging.Logger().With("request_id", logging.NewRequestID(), "provider", c.provider)
+	c.logRequest(log, request, options)
+
+	// Make API call, attaching the reference image or brand logo when one is set
+	var response openai.ImageResponse
+	reference := attachment(options)
+	for attempt := 1; ; attempt++ {
+		observer.Attempt(attempt)
+		sent := time.Now()
+		if reference != "" {
+			response, err = c.createEditImage(ctx, request, reference)
+		} else {
+			response, err = c.client.CreateImage(ctx, request)
+		}
+
+		// Log response details (including errors)
+		c.logResponse(log, response, err, attempt, time.Since(sent))
 
-	// Log response details (including errors)
-	if err := c.logResponse(response, err); err != nil {
-		fmt.Printf("Warning: Failed to log response: %v\n", err)
+		if err == nil || attempt == MaxAttempts || !retryable(err) {
+			break
+		}
+		wait := retryDelay << (attempt - 1)
+		log.Info("retrying image request", "attempt", attempt+1, "wait_ms", wait.Milliseconds())
+		observer.Retry(wait, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to generate image: %w", ctx.Err())
+		}
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate image: %w", err)
 	}
 
+	result := &types.GenerationResult{
+		Prompt:       request.Prompt,
+		Model:        request.Model,
+		Size:         request.Size,
+		Quality:      request.Quality,
+		Background:   request.Background,
+		OutputFormat: request.OutputFormat,
+		Provider:     c.provider,
+		Usage: types.ImageUsage{
+			InputTokens:      response.Usage.InputTokens,
+			InputImageTokens: response.Usage.InputTokensDetails.ImageTokens,
+			OutputTokens:     response.Usage.OutputTokens,
+		},
+		Created: time.Now(),
+	}
+	if response.Created > 0 {
+		result.Created = time.Unix(response.Created, 0)
+	}
+	if !options.RawPrompt {
+		if template, err := c.promptTemplate(options); err == nil {
+			result.Template = template.Name
+		}
+	}
+
 	// Extract base64 image data
-	var imageBase64Data []string
-	for _, data := range response.Data {
-		if data.B64JSON != "" {
-			imageBase64Data = append(imageBase64Data, data.B64JSON)
-		} else if data.URL != "" {
+	for i, data := range response.Data {
+		image := types.GeneratedImage{Base64: data.B64JSON, RevisedPrompt: data.RevisedPrompt}
+		if image.Base64 == "" && data.URL != "" {
 			// Download image from URL and convert to base64
-			base64Data, err := c.downloadImageAsBase64(data.URL)
+			base64Data, err := c.downloadImageAsBase64(ctx, data.URL, func(done, total int64) {
+				observer.Download(i, done, total)
+			})
+			log.Debug("image downloaded", "index", i, "url", data.URL, "base64_length", len(base64Data), "error", errorText(err))
 			if err != nil {
-				return nil, fmt.Errorf("failed to download image from URL %s: %w", data.URL, err)
+				// Image URLs are often pre-signed, so the query stays out of the error
+				return nil, fmt.Errorf("failed to download image from URL %s: %w", logging.StripURLQueries(data.URL), err)
 			}
-			imageBase64Data = append(imageBase64Data, base64Data)
+			image.Base64 = base64Data
+		}
+		if image.Base64 != "" {
+			result.Images = append(result.Images, image)
 		}
 	}
 
-	if len(imageBase64Data) == 0 {
+	if len(result.Images) == 0 {
 		return nil, fmt.Errorf("no images generated")
 	}
 
-	return imageBase64Data, nil
+	result.Cost = EstimateCost(result.Quality, result.Size, len(result.Images), result.Usage)
+	return result, nil
 }
 
-// downloadImageAsBase64 downloads an image from URL and returns it as base64 encoded string
-func (c *Client) downloadImageAsBase64(url string) (string, error) {
-	// Create HTTP request
-	resp, err := http.Get(url)
+// ListModels returns the IDs of the models available to the configured API key
+func (c *Client) ListModels(ctx context.Context) ([]string, error) {
+	models, err := c.client.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var ids []string
+	for _, model := range models.Models {
+		ids = append(ids, model.ID)
+	}
+	return ids, nil
+}
+
+// StatusCode returns the HTTP status code carried by an API error, or 0 if
+// the error did not come from an HTTP response
+func StatusCode(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+
+	return 0
+}
+
+// downloadImageAsBase64 downloads an image from URL and returns it as base64
+// encoded string, reporting the bytes read so far and the total size (-1
+// when the server does not send it)
+func (c *Client) downloadImageAsBase64(ctx context.Context, imageURL string, progress func(done, total int64)) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to download image: %w", err)
+	}
+
+	// Download through the configured HTTP client (proxy, CA)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		// The caller names the URL without its query; drop the full one
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
 		return "", fmt.Errorf("failed to download image: %w", err)
 	}
 	defer resp.Body.Close()
@@ -121,7 +317,8 @@ func (c *Client) downloadImageAsBase64(url string) (string, error) {
 	}
 
 	// Read image data
-	imageData, err := io.ReadAll(resp.Body)
+	progress(0, resp.ContentLength)
+	imageData, err := io.ReadAll(&progressReader{reader: resp.Body, total: resp.ContentLength, progress: progress})
 	if err != nil {
 		return "", fmt.Errorf("failed to read image data: %w", err)
 	}
@@ -131,6 +328,12 @@ func (c *Client) downloadImageAsBase64(url string) (string, error) {
 	return base64Data, nil
 }
 
+// Validate checks the options the way GenerateIcon does before sending a
+// request, so callers can reject them without spending anything
+func (c *Client) Validate(options *types.IconGenerationOptions) error {
+	return c.validateParameters(options)
+}
+
 // validateParameters validates the generation options
 func (c *Client) validateParameters(options *types.IconGenerationOptions) error {
 	model := options.Model
@@ -143,6 +346,13 @@ func (c *Client) validateParameters(options *types.IconGenerationOptions) error
 		return err
 	}
 
+	// On Azure the model must be served by a configured deployment
+	if c.provider == types.ProviderAzure {
+		if _, ok := deploymentForModel(c.deployments, model); !ok {
+			return fmt.Errorf("no Azure deployment configured for model %s. Run: just-icon config --azure-deployment NAME=%s", model, model)
+		}
+	}
+
 	// Validate size
 	size := options.Size
 	if size == "" {
@@ -163,6 +373,22 @@ func (c *Client) validateParameters(options *types.IconGenerationOptions) error
 		return err
 	}
 
+	// Validate background and output format
+	background := options.Background
+	if background == "" {
+		background = types.DefaultValues.Background
+	}
+	if err := c.validateInModelMap(background, types.SupportedBackgrounds, model, "background"); err != nil {
+		return err
+	}
+	outputFormat := options.OutputFormat
+	if outputFormat == "" {
+		outputFormat = types.DefaultValues.OutputFormat
+	}
+	if err := c.validateInModelMap(outputFormat, types.SupportedOutputFormats, model, "output format"); err != nil {
+		return err
+	}
+
 	// Validate number of images
 	numImages := options.NumImages
 	if numImages == 0 {
@@ -173,9 +399,34 @@ func (c *Client) validateParameters(options *types.IconGenerationOptions) error
 		return fmt.Errorf("number of images must be between %d and %d", types.MinImages, types.MaxImages)
 	}
 
+	// Validate template
+	if !options.RawPrompt {
+		if _, err := c.promptTemplate(options); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// promptTemplate resolves the template selected by options, falling back to
+// the configured default and then the built-in default
+func (c *Client) promptTemplate(options *types.IconGenerationOptions) (templates.Template, error) {
+	name := options.Template
+	if name == "" {
+		name = c.defaultTemplate
+	}
+	if name == "" {
+		name = templates.Default
+	}
+
+	template, ok := templates.Lookup(name, c.templates)
+	if !ok {
+		return templates.Template{}, fmt.Errorf("unknown template: %s. Available templates: %v", name, templates.Names(c.templates))
+	}
+	return template, nil
+}
+
 // validateInSlice validates if a value exists in a slice
 func (c *Client) validateInSlice(value string, validValues []string, paramName string) error {
 	for _, validValue := range validValues {
@@ -201,8 +452,17 @@ func (c *Client) validateInModelMap(value string, validMap map[string][]string,
 	return fmt.Errorf("invalid %s %s for model %s. Valid %ss: %v", paramName, value, model, paramName, validValues)
 }
 
+// attachment returns the image sent along with the prompt: the reference
+// image, else the brand logo, or "" when there is none
+func attachment(options *types.IconGenerationOptions) string {
+	if options.Reference != "" {
+		return utils.ExpandHome(options.Reference)
+	}
+	return brand.LogoPath(options.Brand)
+}
+
 // buildRequest builds the API request
-func (c *Client) buildRequest(options *types.IconGenerationOptions) openai.ImageRequest {
+func (c *Client) buildRequest(options *types.IconGenerationOptions) (openai.ImageRequest, error) {
 	model := options.Model
 	if model == "" {
 		model = types.DefaultValues.Model
@@ -233,13 +493,22 @@ func (c *Client) buildRequest(options *types.IconGenerationOptions) openai.Image
 		numImages = types.DefaultValues.NumImages
 	}
 
-	// Build enhanced prompt for iOS app icons (unless raw prompt is requested)
+	// Wrap the prompt in the selected template (unless raw prompt is requested)
 	prompt := options.Prompt
 	if !options.RawPrompt {
-		prompt = fmt.Sprintf(
-			"Create a full-bleed %s px iOS app icon: %s. Use crisp, minimal design with vibrant colors. Add a subtle inner bevel for gentle depth; no hard shadows or outlines. Center the design with comfortable breathing room from the edges. Solid, light-neutral background. IMPORTANT: Fill the entire canvas edge-to-edge with the design, no padding, no margins. Design elements should be centered with appropriate spacing from edges but the background must cover 100%% of the canvas. Add subtle depth with inner highlights, avoid hard shadows. Clean, minimal, Apple-style design. No borders, frames, or rounded corners.",
-			size, prompt,
-		)
+		template, err := c.promptTemplate(options)
+		if err != nil {
+			return openai.ImageRequest{}, err
+		}
+		vars := templates.Vars{Prompt: prompt, Size: size}
+		brand.ApplyVars(options.Brand, &vars)
+		// A reference image replaces the logo as the attachment, so the
+		// template must not ask to match a logo that was not sent
+		vars.Reference = vars.Reference && attachment(options) == brand.LogoPath(options.Brand)
+		prompt, err = templates.Render(template, vars)
+		if err != nil {
+			return openai.ImageRequest{}, err
+		}
 	}
 
 	// Map background values to OpenAI constants
@@ -280,88 +549,70 @@ func (c *Client) buildRequest(options *types.IconGenerationOptions) openai.Image
 	request := openai.ImageRequest{
 		Prompt:       prompt,
 		Background:   backgroundValue,
-		Model:        "gpt-image-1",
+		Model:        model,
 		Size:         size,
 		N:            numImages,
 		Quality:      qualityValue,
 		OutputFormat: outputFormatValue,
 	}
 
-	return request
-}
-
-// logRequest logs the API request details to a file
-func (c *Client) logRequest(request openai.ImageRequest) error {
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, types.ConfigDirPerm); err != nil {
-		return err
-	}
-
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := filepath.Join(logDir, fmt.Sprintf("openai_request_%s.json", timestamp))
-
-	// Create a sanitized version of the request for logging (remove sensitive data)
-	logRequest := map[string]interface{}{
-		"model":         request.Model,
-		"prompt":        request.Prompt,
-		"size":          request.Size,
-		"quality":       request.Quality,
-		"n":             request.N,
-		"background":    request.Background,
-		"output_format": request.OutputFormat,
-	}
-
-	data, err := json.MarshalIndent(logRequest, "", "  ")
-	if err != nil {
-		return err
+	// Auto moderation is the API default, so only the relaxed level is sent
+	if options.Moderation == openai.CreateImageModerationLow {
+		request.Moderation = openai.CreateImageModerationLow
 	}
 
-	return os.WriteFile(filename, data, 0644)
+	return request, nil
 }
 
-// logResponse logs the API response details to a file
-func (c *Client) logResponse(response openai.ImageResponse, apiErr error) error {
-	logDir := "logs"
-	if err := os.MkdirAll(logDir, types.ConfigDirPerm); err != nil {
-		return err
-	}
-
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := filepath.Join(logDir, fmt.Sprintf("openai_response_%s.json", timestamp))
-
-	logResponse := map[string]interface{}{}
+// logRequest records the request details, without the API key
+func (c *Client) logRequest(log *slog.Logger, request openai.ImageRequest, options *types.IconGenerationOptions) {
+	attrs := []any{
+		"model", request.Model,
+		"prompt", request.Prompt,
+		"size", request.Size,
+		"quality", request.Quality,
+		"n", request.N,
+		"background", request.Background,
+		"output_format", request.OutputFormat,
+	}
+	if options.Reference != "" {
+		attrs = append(attrs, "reference", options.Reference)
+	}
+	// Record both versions of an enhanced prompt
+	if options.OriginalPrompt != "" {
+		attrs = append(attrs, "original_prompt", options.OriginalPrompt, "enhanced_prompt", options.Prompt)
+	}
+	log.Info("image request", attrs...)
+}
 
+// logResponse records the outcome of one attempt; the image data is
+// summarized by its size since it is too large to log
+func (c *Client) logResponse(log *slog.Logger, response openai.ImageResponse, apiErr error, attempt int, duration time.Duration) {
 	if apiErr != nil {
-		logResponse["error"] = apiErr.Error()
-		logResponse["success"] = false
-	} else {
-		logResponse["success"] = true
-		logResponse["created"] = response.Created
-
-		// Log data info without the actual base64 content (too large)
-		var dataInfo []map[string]interface{}
-		for i, data := range response.Data {
-			info := map[string]interface{}{
-				"index": i,
-				"has_b64_json": data.B64JSON != "",
-				"has_url": data.URL != "",
-			}
-			if data.B64JSON != "" {
-				info["b64_json_length"] = len(data.B64JSON)
-			}
-			if data.URL != "" {
-				info["url"] = data.URL
-			}
-			dataInfo = append(dataInfo, info)
-		}
-		logResponse["data"] = dataInfo
-		logResponse["data_count"] = len(response.Data)
+		log.Warn("image request failed",
+			"attempt", attempt,
+			"duration_ms", duration.Milliseconds(),
+			"status", StatusCode(apiErr),
+			"error", apiErr.Error())
+		return
+	}
+
+	log.Info("image response",
+		"attempt", attempt,
+		"duration_ms", duration.Milliseconds(),
+		"created", response.Created,
+		"data_count", len(response.Data),
+		"input_tokens", response.Usage.InputTokens,
+		"output_tokens", response.Usage.OutputTokens)
+	for i, data := range response.Data {
+		log.Debug("image data", "index", i, "b64_json_length", len(data.B64JSON), "url", data.URL)
 	}
+}
 
-	data, err := json.MarshalIndent(logResponse, "", "  ")
-	if err != nil {
-		return err
+// errorText returns the error message, or an empty string without an error
+func errorText(err error) string {
+	if err == nil {
+		return ""
 	}
-
-	return os.WriteFile(filename, data, 0644)
-}
\ No newline at end of file
+	return err.Error()
+}
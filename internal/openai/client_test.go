@@ -0,0 +1,354 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"just-icon/internal/logging"
+	"just-icon/internal/types"
+)
+
+func TestBuildRequestTemplates(t *testing.T) {
+	client := NewClient("sk-test", "https://example.com")
+	client.SetTemplates(map[string]*types.PromptTemplate{
+		"team": {Text: "Team icon: {{.Prompt}} ({{.Palette}})"},
+	}, "team")
+
+	tests := []struct {
+		name     string
+		options  types.IconGenerationOptions
+		expected string
+		logo     bool
+	}{
+		{
+			name: "configured default",
+			options: types.IconGenerationOptions{Prompt: "rocket", Brand: &types.BrandKit{
+				Colors: []types.BrandColor{{Name: "primary", Hex: "#111111"}, {Name: "accent", Hex: "#EEEEEE"}},
+			}},
+			expected: "Team icon: rocket (primary #111111, accent #EEEEEE)",
+		},
+		{
+			name:     "explicit template",
+			options:  types.IconGenerationOptions{Prompt: "rocket", Template: "line-icon"},
+			expected: "Create a 1024x1024 px line icon: rocket.",
+		},
+		{
+			name:     "brand logo",
+			options:  types.IconGenerationOptions{Prompt: "rocket", Template: "line-icon", Brand: &types.BrandKit{ReferenceLogo: "logo.png"}},
+			expected: "Create a 1024x1024 px line icon: rocket.",
+			logo:     true,
+		},
+		{
+			name:     "reference replaces brand logo",
+			options:  types.IconGenerationOptions{Prompt: "rocket", Template: "line-icon", Brand: &types.BrandKit{ReferenceLogo: "logo.png"}, Reference: "rocket.png"},
+			expected: "Create a 1024x1024 px line icon: rocket.",
+		},
+		{
+			name:     "raw prompt",
+			options:  types.IconGenerationOptions{Prompt: "rocket", RawPrompt: true},
+			expected: "rocket",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := client.buildRequest(&tt.options)
+			if err != nil {
+				t.Fatalf("buildRequest() failed: %v", err)
+			}
+			if !strings.HasPrefix(request.Prompt, tt.expected) {
+				t.Errorf("unexpected prompt %q", request.Prompt)
+			}
+			if strings.Contains(request.Prompt, "attached brand logo") != tt.logo {
+				t.Errorf("prompt %q, want the brand logo mentioned: %v", request.Prompt, tt.logo)
+			}
+		})
+	}
+}
+
+func TestValidateParametersUnknownTemplate(t *testing.T) {
+	client := NewClient("sk-test", "https://example.com")
+	err := client.validateParameters(&types.IconGenerationOptions{Prompt: "rocket", Template: "nope"})
+	if err == nil || !strings.Contains(err.Error(), "unknown template") {
+		t.Errorf("expected unknown template error, got %v", err)
+	}
+}
+
+func TestGenerateIconWithReferenceLogo(t *testing.T) {
+	var gotPath, gotModel, gotQuality, gotFile, gotContentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("expected multipart request: %v", err)
+		}
+		gotModel = r.FormValue("model")
+		gotQuality = r.FormValue("quality")
+		if file, header, err := r.FormFile("image"); err == nil {
+			file.Close()
+			gotFile = header.Filename
+			gotContentType = header.Header.Get("Content-Type")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"created":1,"data":[{"b64_json":"aWNvbg=="}]}`))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	logo := filepath.Join(dir, "logo.png")
+	if err := os.WriteFile(logo, []byte("\x89PNG\r\n\x1a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClient("sk-test", server.URL)
+	_, err := client.GenerateIcon(context.Background(), &types.IconGenerationOptions{
+		Prompt:  "rocket",
+		Quality: types.QualityHigh,
+		Brand:   &types.BrandKit{ReferenceLogo: logo},
+	})
+	if err != nil {
+		t.Fatalf("GenerateIcon() failed: %v", err)
+	}
+
+	if gotPath != "/v1/images/edits" {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotModel != types.ModelGPTImage1 || gotQuality != types.QualityHigh {
+		t.Errorf("unexpected form fields model=%q quality=%q", gotModel, gotQuality)
+	}
+	if gotFile != "logo.png" || gotContentType != "image/png" {
+		t.Errorf("unexpected image part %q (%s)", gotFile, gotContentType)
+	}
+
+	// A reference image takes precedence over the logo
+	reference := filepath.Join(dir, "rocket.png")
+	if err := os.WriteFile(reference, []byte("\x89PNG\r\n\x1a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GenerateIcon(context.Background(), &types.IconGenerationOptions{
+		Prompt:    "make it blue",
+		RawPrompt: true,
+		Brand:     &types.BrandKit{ReferenceLogo: logo},
+		Reference: reference,
+	})
+	if err != nil {
+		t.Fatalf("GenerateIcon() with a reference failed: %v", err)
+	}
+	if gotFile != "rocket.png" {
+		t.Errorf("unexpected image part %q, want the reference", gotFile)
+	}
+}
+
+func TestGenerateIconResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"created":1750000000,"data":[{"b64_json":"aWNvbg==","revised_prompt":"a red rocket"}],` +
+			`"usage":{"input_tokens":50,"output_tokens":1000,"input_tokens_details":{"text_tokens":50}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("sk-test", server.URL)
+	result, err := client.GenerateIcon(context.Background(), &types.IconGenerationOptions{
+		Prompt:   "rocket",
+		Template: "line-icon",
+		Quality:  types.QualityHigh,
+	})
+	if err != nil {
+		t.Fatalf("GenerateIcon() failed: %v", err)
+	}
+
+	if len(result.Images) != 1 || result.Images[0].Base64 != "aWNvbg==" || result.Images[0].RevisedPrompt != "a red rocket" {
+		t.Errorf("unexpected images %+v", result.Images)
+	}
+	if !strings.HasPrefix(result.Prompt, "Create a 1024x1024 px line icon: rocket.") || result.Template != "line-icon" {
+		t.Errorf("unexpected prompt %q from template %q", result.Prompt, result.Template)
+	}
+	if result.Quality != types.QualityHigh || result.Provider != types.ProviderOpenAI || result.Created.Unix() != 1750000000 {
+		t.Errorf("unexpected request details %+v", result)
+	}
+	if want := 0.04025; result.Cost < want-1e-9 || result.Cost > want+1e-9 {
+		t.Errorf("Cost = %v, want %v", result.Cost, want)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		name      string
+		quality   string
+		size      string
+		numImages int
+		usage     types.ImageUsage
+		want      float64
+	}{
+		{"per image price", types.QualityMedium, types.SizeSmall, 2, types.ImageUsage{}, 0.084},
+		{"token usage", types.QualityLow, types.SizeSmall, 1, types.ImageUsage{InputTokens: 1100, InputImageTokens: 1000, OutputTokens: 272}, 0.02138},
+		{"unknown quality", "ultra", types.SizeSmall, 1, types.ImageUsage{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateCost(tt.quality, tt.size, tt.numImages, tt.usage)
+			if got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("EstimateCost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingObserver records the progress of a generation
+type recordingObserver struct {
+	attempts  []int
+	retries   int
+	downloads []int64
+}
+
+func (o *recordingObserver) Attempt(n int)                       { o.attempts = append(o.attempts, n) }
+func (o *recordingObserver) Retry(wait time.Duration, err error) { o.retries++ }
+func (o *recordingObserver) Download(image int, done, total int64) {
+	o.downloads = append(o.downloads, done)
+}
+
+func TestGenerateIconWithProgressRetries(t *testing.T) {
+	retryDelay = time.Millisecond
+	defer func() { retryDelay = 2 * time.Second }()
+
+	calls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image.png" {
+			w.Write([]byte("icon"))
+			return
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded","type":"server_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"url":"` + server.URL + `/image.png"}]}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(t.TempDir(), logging.FileName)
+	if err := logging.Setup(logging.Options{Path: logPath}); err != nil {
+		t.Fatal(err)
+	}
+	defer logging.Setup(logging.Options{})
+
+	observer := &recordingObserver{}
+	result, err := NewClient("sk-test", server.URL).GenerateIconWithProgress(context.Background(), &types.IconGenerationOptions{Prompt: "rocket", Quality: types.QualityLow}, observer)
+	if err != nil {
+		t.Fatalf("GenerateIconWithProgress() failed: %v", err)
+	}
+	if result.Images[0].Base64 != "aWNvbg==" {
+		t.Errorf("unexpected image %q", result.Images[0].Base64)
+	}
+	if len(observer.attempts) != 2 || observer.retries != 1 {
+		t.Errorf("attempts = %v, retries = %d, want 2 attempts and 1 retry", observer.attempts, observer.retries)
+	}
+	if n := len(observer.downloads); n < 2 || observer.downloads[0] != 0 || observer.downloads[n-1] != 4 {
+		t.Errorf("downloads = %v, want 0 up to 4 bytes", observer.downloads)
+	}
+
+	// Every record of the call carries the same request ID
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	ids := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var record struct {
+			Msg       string `json:"msg"`
+			RequestID string `json:"request_id"`
+		}
+		json.Unmarshal([]byte(line), &record)
+		messages = append(messages, record.Msg)
+		ids[record.RequestID] = true
+	}
+	want := []string{"image request", "image request failed", "retrying image request", "image response"}
+	if strings.Join(messages, ",") != strings.Join(want, ",") || len(ids) != 1 || ids[""] {
+		t.Errorf("log records %v with request IDs %v, want %v with one ID", messages, ids, want)
+	}
+	if strings.Contains(string(data), "sk-test") {
+		t.Error("log contains the API key")
+	}
+}
+
+func TestGenerateIconWithProgressStopsWaiting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":{"message":"overloaded","type":"server_error"}}`))
+	}))
+	defer server.Close()
+
+	// The retry waits the default two seconds unless the context ends it
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := NewClient("sk-test", server.URL).GenerateIconWithProgress(ctx, &types.IconGenerationOptions{Prompt: "rocket", Quality: types.QualityLow}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GenerateIconWithProgress() error = %v, want the context error", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("returned after %v, want the wait interrupted", elapsed)
+	}
+}
+
+func TestDownloadErrorHidesURLQuery(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image.png" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"url":"` + server.URL + `/image.png?sig=s3cr3t"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := NewClient("sk-test", server.URL).GenerateIcon(context.Background(), &types.IconGenerationOptions{Prompt: "rocket", Quality: types.QualityLow})
+	if err == nil || strings.Contains(err.Error(), "s3cr3t") || !strings.Contains(err.Error(), server.URL+"/image.png") {
+		t.Errorf("GenerateIcon() error = %v, want the image URL without its query", err)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, true},
+		{"quota", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Code: "insufficient_quota"}, false},
+		{"server error", &openai.RequestError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: http.StatusBadRequest}, false},
+		{"gateway timeout", &openai.RequestError{HTTPStatusCode: http.StatusGatewayTimeout}, false},
+		{"refused", &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, true},
+		{"reset after sending", &url.Error{Op: "Post", URL: "https://example.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}}, false},
+		{"timeout", &url.Error{Op: "Post", URL: "https://example.com", Err: &net.DNSError{Err: "timeout", IsTimeout: true}}, false},
+		{"canceled", &url.Error{Op: "Post", URL: "https://example.com", Err: context.Canceled}, false},
+		{"validation", errors.New("invalid size"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(fmt.Errorf("wrapped: %w", tt.err)); got != tt.want {
+				t.Errorf("retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
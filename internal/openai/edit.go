@@ -0,0 +1,154 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/sashabaranov/go-openai"
+
+	"just-icon/internal/types"
+)
+
+// createEditImage sends the request to the image edits endpoint with the
+// reference image attached. go-openai's CreateEditImage does not send the
+// model, quality, background or output format fields gpt-image-1 needs.
+func (c *Client) createEditImage(ctx context.Context, request openai.ImageRequest, imagePath string) (openai.ImageResponse, error) {
+	var response openai.ImageResponse
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writeImagePart(writer, "image", imagePath); err != nil {
+		return response, err
+	}
+
+	fields := []struct{ name, value string }{
+		{"model", request.Model},
+		{"prompt", request.Prompt},
+		{"n", strconv.Itoa(request.N)},
+		{"size", request.Size},
+		{"quality", request.Quality},
+		{"background", request.Background},
+		{"output_format", request.OutputFormat},
+	}
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := writer.WriteField(field.name, field.value); err != nil {
+			return response, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return response, err
+	}
+
+	endpoint, err := c.endpointURL("/images/edits", request.Model)
+	if err != nil {
+		return response, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return response, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	c.authorize(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return response, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return response, apiError(resp, data)
+	}
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return response, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return response, nil
+}
+
+// endpointURL returns the URL of an API path for the client's provider. On
+// Azure the path is routed to the deployment serving model.
+func (c *Client) endpointURL(path, model string) (string, error) {
+	if c.provider != types.ProviderAzure {
+		return c.baseURL + path, nil
+	}
+
+	deployment, ok := deploymentForModel(c.deployments, model)
+	if !ok {
+		return "", fmt.Errorf("no Azure deployment configured for model %s", model)
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s%s?api-version=%s",
+		c.baseURL, url.PathEscape(deployment), path, url.QueryEscape(c.apiVersion)), nil
+}
+
+// authorize sets the credential header expected by the client's provider
+func (c *Client) authorize(req *http.Request) {
+	if c.provider == types.ProviderAzure && c.azureAuth != types.AzureAuthAAD {
+		req.Header.Set("api-key", c.credential)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+c.credential)
+}
+
+// writeImagePart attaches an image file with a content type derived from its
+// extension, which the edits endpoint requires
+func writeImagePart(writer *multipart.Writer, field, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read reference image: %w", err)
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(path))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, field, filepath.Base(path)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(data)
+	return err
+}
+
+// apiError converts an error response into the same error types go-openai
+// returns, so StatusCode keeps working
+func apiError(resp *http.Response, body []byte) error {
+	var errResp openai.ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == nil {
+		return &openai.RequestError{
+			HTTPStatus:     resp.Status,
+			HTTPStatusCode: resp.StatusCode,
+			Err:            fmt.Errorf("%s", bytes.TrimSpace(body)),
+			Body:           body,
+		}
+	}
+
+	errResp.Error.HTTPStatus = resp.Status
+	errResp.Error.HTTPStatusCode = resp.StatusCode
+	return errResp.Error
+}
@@ -0,0 +1,59 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"just-icon/internal/logging"
+	"just-icon/internal/types"
+)
+
+// DefaultEnhanceSystemPrompt turns a terse idea into a detailed icon brief
+const DefaultEnhanceSystemPrompt = `You are an icon designer writing briefs for an image generation model.
+Expand the user's short icon idea into a single detailed paragraph describing one app icon: the central subject and metaphor, composition, shapes, materials, lighting and color mood.
+Keep the user's intent, do not add text or letters to the icon, and do not mention sizes, file formats, backgrounds or platforms; those are added later.
+Reply with the brief only, without quotes, headings or explanations.`
+
+// EnhancePrompt expands a short prompt into a detailed icon brief using a
+// chat model on the same API endpoint
+func (c *Client) EnhancePrompt(ctx context.Context, prompt string, enhance *types.EnhanceConfig) (string, error) {
+	model := types.DefaultEnhanceModel
+	systemPrompt := DefaultEnhanceSystemPrompt
+	if enhance != nil {
+		if enhance.Model != "" {
+			model = enhance.Model
+		}
+		if enhance.SystemPrompt != "" {
+			systemPrompt = enhance.SystemPrompt
+		}
+	}
+
+	log := logging.Logger().With("request_id", logging.NewRequestID(), "provider", c.provider)
+	log.Info("enhance request", "model", model, "prompt", prompt)
+	sent := time.Now()
+	response, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: systemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+	})
+	if err != nil {
+		log.Warn("enhance request failed", "duration_ms", time.Since(sent).Milliseconds(), "status", StatusCode(err), "error", err.Error())
+		return "", fmt.Errorf("failed to enhance prompt: %w", err)
+	}
+	log.Info("enhance response", "duration_ms", time.Since(sent).Milliseconds(), "choices", len(response.Choices))
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("failed to enhance prompt: empty response")
+	}
+
+	enhanced := strings.Trim(strings.TrimSpace(response.Choices[0].Message.Content), "\"“”")
+	if enhanced == "" {
+		return "", fmt.Errorf("failed to enhance prompt: empty response")
+	}
+	return enhanced, nil
+}
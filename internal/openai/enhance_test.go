@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestEnhancePrompt(t *testing.T) {
+	var request struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  \"A gear made of brushed aluminium\"\n"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("sk-test", server.URL)
+
+	enhanced, err := client.EnhancePrompt(context.Background(), "settings", nil)
+	if err != nil {
+		t.Fatalf("EnhancePrompt() failed: %v", err)
+	}
+	if enhanced != "A gear made of brushed aluminium" {
+		t.Errorf("unexpected enhanced prompt %q", enhanced)
+	}
+	if request.Model != types.DefaultEnhanceModel || len(request.Messages) != 2 ||
+		request.Messages[0].Content != DefaultEnhanceSystemPrompt || request.Messages[1].Content != "settings" {
+		t.Errorf("unexpected chat request %+v", request)
+	}
+
+	_, err = client.EnhancePrompt(context.Background(), "settings", &types.EnhanceConfig{
+		Model:        "gpt-4o",
+		SystemPrompt: "Be brief.",
+	})
+	if err != nil {
+		t.Fatalf("EnhancePrompt() failed: %v", err)
+	}
+	if request.Model != "gpt-4o" || request.Messages[0].Content != "Be brief." {
+		t.Errorf("configured model and system prompt not used: %+v", request)
+	}
+}
+
+func TestEnhancePromptEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  "}}]}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewClient("sk-test", server.URL).EnhancePrompt(context.Background(), "settings", nil); err == nil {
+		t.Error("expected error for empty enhancement")
+	}
+}
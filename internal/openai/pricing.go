@@ -0,0 +1,31 @@
+package openai
+
+import "just-icon/internal/types"
+
+// gpt-image-1 token prices in US dollars per million tokens
+const (
+	textInputPrice  = 5.0
+	imageInputPrice = 10.0
+	outputPrice     = 40.0
+)
+
+// imagePrices are the gpt-image-1 prices per image by quality and size,
+// used when the API does not report token usage
+var imagePrices = map[string]map[string]float64{
+	types.QualityLow:    {types.SizeSmall: 0.011, types.SizeMedium: 0.016, types.SizeLarge: 0.016},
+	types.QualityMedium: {types.SizeSmall: 0.042, types.SizeMedium: 0.063, types.SizeLarge: 0.063},
+	types.QualityHigh:   {types.SizeSmall: 0.167, types.SizeMedium: 0.25, types.SizeLarge: 0.25},
+}
+
+// EstimateCost returns the estimated price of a request in US dollars. Token
+// usage is priced when the API reports it; otherwise the per-image price for
+// the quality and size is used. Unknown combinations cost 0.
+func EstimateCost(quality, size string, numImages int, usage types.ImageUsage) float64 {
+	if usage.InputTokens > 0 || usage.OutputTokens > 0 {
+		textTokens := usage.InputTokens - usage.InputImageTokens
+		return (float64(textTokens)*textInputPrice +
+			float64(usage.InputImageTokens)*imageInputPrice +
+			float64(usage.OutputTokens)*outputPrice) / 1e6
+	}
+	return imagePrices[quality][size] * float64(numImages)
+}
@@ -0,0 +1,96 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// MaxAttempts is how often a request is sent before a rate limit or server
+// error is returned
+const MaxAttempts = 3
+
+// retryDelay is the wait before the first retry; it doubles for every
+// further retry
+var retryDelay = 2 * time.Second
+
+// Observer follows a generation: every attempt at the API call, the wait
+// before a retry and the download of images returned as URLs
+type Observer interface {
+	// Attempt is called before the API call is sent, counting from 1
+	Attempt(n int)
+	// Retry is called when a failed call is sent again after wait
+	Retry(wait time.Duration, err error)
+	// Download reports the bytes read of an image; total is -1 when unknown
+	Download(image int, done, total int64)
+}
+
+// noObserver ignores all progress
+type noObserver struct{}
+
+// Attempt does nothing
+func (noObserver) Attempt(int) {}
+
+// Retry does nothing
+func (noObserver) Retry(time.Duration, error) {}
+
+// Download does nothing
+func (noObserver) Download(int, int64, int64) {}
+
+// retryable reports whether a failed API call may succeed when sent again
+// without being billed twice: rate limits (but not an exhausted quota),
+// server errors other than gateway timeouts, and network errors from before
+// the request was sent. After a timeout or a connection lost mid-request the
+// server may still be generating, so those are not retried.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == "insufficient_quota" {
+		return false
+	}
+	switch status := StatusCode(err); {
+	case status == http.StatusGatewayTimeout:
+		return false
+	case status == http.StatusTooManyRequests || status >= http.StatusInternalServerError:
+		return true
+	case status != 0:
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	// Only a failed connection proves the request never left
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect") {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// progressReader reports the bytes read through it
+type progressReader struct {
+	reader   io.Reader
+	done     int64
+	total    int64
+	progress func(done, total int64)
+}
+
+// Read reads from the underlying reader and reports the progress
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.done += int64(n)
+		r.progress(r.done, r.total)
+	}
+	return n, err
+}
@@ -0,0 +1,125 @@
+package presets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"just-icon/internal/templates"
+	"just-icon/internal/types"
+)
+
+// Preset is a named style preset
+type Preset struct {
+	Name string
+	types.Preset
+}
+
+// Lookup finds a preset by name
+func Lookup(name string, presets map[string]*types.Preset) (Preset, bool) {
+	preset, ok := presets[name]
+	if !ok || preset == nil {
+		return Preset{}, false
+	}
+	return Preset{Name: name, Preset: *preset}, true
+}
+
+// List returns the configured presets sorted by name
+func List(presets map[string]*types.Preset) []Preset {
+	var names []string
+	for name, preset := range presets {
+		if preset != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	result := make([]Preset, 0, len(names))
+	for _, name := range names {
+		result = append(result, Preset{Name: name, Preset: *presets[name]})
+	}
+	return result
+}
+
+// Apply overrides the options set by the preset
+func Apply(preset types.Preset, options *types.IconGenerationOptions) {
+	if preset.Template != "" {
+		options.Template = preset.Template
+	}
+	if preset.Size != "" {
+		options.Size = preset.Size
+	}
+	if preset.Quality != "" {
+		options.Quality = preset.Quality
+	}
+	if preset.Background != "" {
+		options.Background = preset.Background
+	}
+	if preset.OutputFormat != "" {
+		options.OutputFormat = preset.OutputFormat
+	}
+	if preset.NumImages != 0 {
+		options.NumImages = preset.NumImages
+	}
+}
+
+// Summary joins the preset's overrides into one line, e.g.
+// "ios · high · transparent · webp · x2"
+func Summary(preset types.Preset) string {
+	var parts []string
+	for _, value := range []string{preset.Template, preset.Size, preset.Quality, preset.Background, preset.OutputFormat} {
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	if preset.NumImages != 0 {
+		parts = append(parts, fmt.Sprintf("x%d", preset.NumImages))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ValidateName checks that a preset name is usable on the command line
+func ValidateName(name string) error {
+	return templates.CheckName("preset", name)
+}
+
+// Validate checks the preset's overrides against the values supported by the
+// default model and the available templates
+func Validate(preset types.Preset, customTemplates map[string]*types.PromptTemplate) error {
+	model := types.DefaultValues.Model
+
+	if preset.Template != "" {
+		if _, ok := templates.Lookup(preset.Template, customTemplates); !ok {
+			return fmt.Errorf("unknown template: %s. Available templates: %v", preset.Template, templates.Names(customTemplates))
+		}
+	}
+	if err := validateIn(preset.Size, types.SupportedSizes[model], "size"); err != nil {
+		return err
+	}
+	if err := validateIn(preset.Quality, types.SupportedQualities[model], "quality"); err != nil {
+		return err
+	}
+	if err := validateIn(preset.Background, types.SupportedBackgrounds[model], "background"); err != nil {
+		return err
+	}
+	if err := validateIn(preset.OutputFormat, types.SupportedOutputFormats[model], "output format"); err != nil {
+		return err
+	}
+	if preset.NumImages != 0 && (preset.NumImages < types.MinImages || preset.NumImages > types.MaxImages) {
+		return fmt.Errorf("number of images must be between %d and %d", types.MinImages, types.MaxImages)
+	}
+	return nil
+}
+
+// validateIn accepts an empty value or one of validValues
+func validateIn(value string, validValues []string, paramName string) error {
+	if value == "" {
+		return nil
+	}
+	for _, validValue := range validValues {
+		if value == validValue {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported %s: %s. Supported values: %v", paramName, value, validValues)
+}
@@ -0,0 +1,76 @@
+package presets
+
+import (
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestApply(t *testing.T) {
+	options := &types.IconGenerationOptions{
+		Size:         types.SizeSmall,
+		Quality:      types.QualityAuto,
+		Background:   "auto",
+		OutputFormat: "png",
+		NumImages:    1,
+	}
+
+	Apply(types.Preset{
+		Template:     "flat-web",
+		Quality:      types.QualityHigh,
+		Background:   "transparent",
+		OutputFormat: "webp",
+	}, options)
+
+	if options.Template != "flat-web" || options.Quality != types.QualityHigh ||
+		options.Background != "transparent" || options.OutputFormat != "webp" {
+		t.Errorf("preset overrides not applied: %+v", options)
+	}
+	if options.Size != types.SizeSmall || options.NumImages != 1 {
+		t.Errorf("unset preset fields changed options: %+v", options)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	custom := map[string]*types.PromptTemplate{"team": {Text: "{{.Prompt}}"}}
+
+	tests := []struct {
+		name    string
+		preset  types.Preset
+		wantErr bool
+	}{
+		{"empty", types.Preset{}, false},
+		{"full", types.Preset{Template: "team", Size: types.SizeLarge, Quality: types.QualityHigh, Background: "transparent", OutputFormat: "webp", NumImages: 4}, false},
+		{"unknown template", types.Preset{Template: "nope"}, true},
+		{"bad size", types.Preset{Size: "512x512"}, true},
+		{"bad quality", types.Preset{Quality: "ultra"}, true},
+		{"bad background", types.Preset{Background: "blue"}, true},
+		{"bad format", types.Preset{OutputFormat: "gif"}, true},
+		{"too many images", types.Preset{NumImages: types.MaxImages + 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.preset, custom); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListAndSummary(t *testing.T) {
+	list := List(map[string]*types.Preset{
+		"zeta":  {},
+		"brand": {Template: "ios", Quality: types.QualityHigh, OutputFormat: "webp", NumImages: 2},
+	})
+
+	if len(list) != 2 || list[0].Name != "brand" || list[1].Name != "zeta" {
+		t.Fatalf("unexpected preset order: %+v", list)
+	}
+	if summary := Summary(list[0].Preset); summary != "ios · high · webp · x2" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+	if summary := Summary(list[1].Preset); summary != "" {
+		t.Errorf("expected empty summary, got %q", summary)
+	}
+}
@@ -0,0 +1,39 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// renderBlocks draws img with upper half block characters, using the
+// foreground color for the top pixel and the background for the bottom one
+func renderBlocks(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			bottom := color.NRGBA{}
+			if y+1 < bounds.Max.Y {
+				bottom = color.NRGBAModel.Convert(img.At(x, y+1)).(color.NRGBA)
+			}
+			topOpaque, bottomOpaque := top.A >= opaqueThreshold, bottom.A >= opaqueThreshold
+
+			switch {
+			case topOpaque && bottomOpaque:
+				fmt.Fprintf(w, "\x1b[38;2;%d;%d;%d;48;2;%d;%d;%dm▀", top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+			case topOpaque:
+				fmt.Fprintf(w, "\x1b[0;38;2;%d;%d;%dm▀", top.R, top.G, top.B)
+			case bottomOpaque:
+				fmt.Fprintf(w, "\x1b[0;38;2;%d;%d;%dm▄", bottom.R, bottom.G, bottom.B)
+			default:
+				io.WriteString(w, "\x1b[0m ")
+			}
+		}
+		if _, err := io.WriteString(w, "\x1b[0m\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/term"
+
+	"just-icon/pkg/utils"
+)
+
+// Protocol is a way of drawing images in a terminal
+type Protocol string
+
+const (
+	// Auto detects the best protocol the terminal supports
+	Auto   Protocol = "auto"
+	Kitty  Protocol = "kitty"
+	ITerm2 Protocol = "iterm2"
+	Sixel  Protocol = "sixel"
+	// Blocks draws two pixels per cell with half-block characters and works
+	// in any terminal with true color
+	Blocks Protocol = "blocks"
+	// Off disables previews
+	Off Protocol = "off"
+)
+
+// Protocols lists the accepted preview settings
+var Protocols = []Protocol{Auto, Kitty, ITerm2, Sixel, Blocks, Off}
+
+const (
+	// DefaultWidth is the preview width in cells after generation
+	DefaultWidth = 32
+	// SmallWidth is the preview width in cells for list views
+	SmallWidth = 16
+	// fallbackCellWidth and fallbackCellHeight approximate a terminal cell
+	// in pixels when the terminal does not report its pixel size
+	fallbackCellWidth  = 10
+	fallbackCellHeight = 20
+	// kittyChunkSize is the largest base64 payload per kitty escape
+	kittyChunkSize = 4096
+)
+
+// Cells is a size in terminal cells
+type Cells struct {
+	Cols int
+	Rows int
+}
+
+// IsValid reports whether name is a known preview setting
+func IsValid(name string) bool {
+	for _, protocol := range Protocols {
+		if Protocol(name) == protocol {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve turns a configured setting into the protocol to draw with. Auto
+// and empty settings detect the terminal; output that is not a terminal
+// gets no preview.
+func Resolve(setting string) Protocol {
+	protocol := Protocol(strings.ToLower(strings.TrimSpace(setting)))
+	if protocol == Off || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return Off
+	}
+	if protocol == "" || protocol == Auto || !IsValid(string(protocol)) {
+		return Detect(os.Getenv)
+	}
+	return protocol
+}
+
+// Detect picks a protocol from the terminal's environment variables
+func Detect(getenv func(string) string) Protocol {
+	termName := getenv("TERM")
+	program := getenv("TERM_PROGRAM")
+
+	switch {
+	case termName == "dumb":
+		return Off
+	// Multiplexers swallow graphics escapes unless passthrough is configured
+	case getenv("TMUX") != "" || strings.HasPrefix(termName, "screen"):
+		return Blocks
+	case getenv("KITTY_WINDOW_ID") != "" || termName == "xterm-kitty" ||
+		termName == "xterm-ghostty" || program == "ghostty":
+		return Kitty
+	case program == "iTerm.app" || program == "WezTerm" || getenv("LC_TERMINAL") == "iTerm2":
+		return ITerm2
+	case strings.Contains(termName, "sixel") || termName == "foot" || strings.HasPrefix(termName, "mlterm") ||
+		program == "mintty":
+		return Sixel
+	default:
+		return Blocks
+	}
+}
+
+// Show draws the image at path on stdout, at most width cells wide and
+// leaving room for surrounding output. It does nothing when protocol is Off.
+func Show(path string, protocol Protocol, width int) error {
+	if protocol == Off {
+		return nil
+	}
+	img, err := utils.LoadImage(path)
+	if err != nil {
+		return err
+	}
+
+	cols, rows, cellWidth, cellHeight := terminalSize()
+	width = min(width, cols-2)
+	height := max(1, rows-4)
+	out := bufio.NewWriter(os.Stdout)
+	if err := Render(out, img, protocol, fitCells(img.Bounds(), width, height, cellWidth, cellHeight), cellWidth, cellHeight); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// fitCells returns the largest cell area within maxCols x maxRows that keeps
+// the image's aspect ratio
+func fitCells(bounds image.Rectangle, maxCols, maxRows, cellWidth, cellHeight int) Cells {
+	maxCols, maxRows = max(1, maxCols), max(1, maxRows)
+	width, height := max(1, bounds.Dx()), max(1, bounds.Dy())
+
+	cols := maxCols
+	rows := (cols*cellWidth*height/width + cellHeight - 1) / cellHeight
+	if rows > maxRows {
+		rows = maxRows
+		cols = max(1, rows*cellHeight*width/height/cellWidth)
+	}
+	return Cells{Cols: cols, Rows: max(1, rows)}
+}
+
+// Render writes escape sequences drawing img into an area of size cells,
+// followed by a newline
+func Render(w io.Writer, img image.Image, protocol Protocol, size Cells, cellWidth, cellHeight int) error {
+	switch protocol {
+	case Kitty:
+		return renderKitty(w, scale(img, size.Cols*cellWidth, size.Rows*cellHeight), size)
+	case ITerm2:
+		return renderITerm2(w, scale(img, size.Cols*cellWidth, size.Rows*cellHeight), size)
+	case Sixel:
+		return renderSixel(w, scale(img, size.Cols*cellWidth, size.Rows*cellHeight))
+	case Blocks:
+		return renderBlocks(w, scale(img, size.Cols, size.Rows*2))
+	case Off:
+		return nil
+	default:
+		return fmt.Errorf("unknown preview protocol %q", protocol)
+	}
+}
+
+// renderKitty sends the image as PNG with the kitty graphics protocol,
+// split into chunks and placed over the given cells
+func renderKitty(w io.Writer, img image.Image, size Cells) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	payload := base64.StdEncoding.EncodeToString(data)
+
+	for first := true; first || payload != ""; first = false {
+		chunk := payload[:min(kittyChunkSize, len(payload))]
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		if first {
+			// a=T transmits and displays; q=2 suppresses the terminal's replies
+			fmt.Fprintf(w, "\x1b_Ga=T,f=100,q=2,c=%d,r=%d,m=%d;%s\x1b\\", size.Cols, size.Rows, more, chunk)
+		} else {
+			fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+// renderITerm2 sends the image as an iTerm2 inline file
+func renderITerm2(w io.Writer, img image.Image, size Cells) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a\n",
+		len(data), size.Cols, size.Rows, base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// scale resizes img to fit within width x height pixels, keeping its aspect
+// ratio; smaller images are enlarged
+func scale(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := max(1, bounds.Dx()), max(1, bounds.Dy())
+	if srcWidth*height > srcHeight*width {
+		height = max(1, width*srcHeight/srcWidth)
+	} else {
+		width = max(1, height*srcWidth/srcHeight)
+	}
+	if width == srcWidth && height == srcHeight {
+		return img
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// encodePNG encodes img as PNG
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func testImage(width, height int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+	// Leave the top left pixel transparent
+	img.Set(0, 0, color.NRGBA{})
+	return img
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Protocol
+	}{
+		{"kitty", map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{"kitty window", map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, Kitty},
+		{"ghostty", map[string]string{"TERM_PROGRAM": "ghostty"}, Kitty},
+		{"iterm2", map[string]string{"TERM_PROGRAM": "iTerm.app"}, ITerm2},
+		{"wezterm", map[string]string{"TERM_PROGRAM": "WezTerm"}, ITerm2},
+		{"foot", map[string]string{"TERM": "foot"}, Sixel},
+		{"tmux", map[string]string{"TERM": "tmux-256color", "TERM_PROGRAM": "iTerm.app", "TMUX": "/tmp/tmux"}, Blocks},
+		{"dumb", map[string]string{"TERM": "dumb"}, Off},
+		{"plain", map[string]string{"TERM": "xterm-256color"}, Blocks},
+	}
+	for _, tt := range tests {
+		if got := Detect(func(key string) string { return tt.env[key] }); got != tt.want {
+			t.Errorf("%s: Detect() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFitCells(t *testing.T) {
+	tests := []struct {
+		name             string
+		width, height    int
+		maxCols, maxRows int
+		want             Cells
+	}{
+		{"square", 1024, 1024, 32, 40, Cells{32, 16}},
+		{"wide", 1536, 1024, 30, 40, Cells{30, 10}},
+		{"limited by rows", 1024, 1024, 32, 8, Cells{16, 8}},
+	}
+	for _, tt := range tests {
+		got := fitCells(image.Rect(0, 0, tt.width, tt.height), tt.maxCols, tt.maxRows, 10, 20)
+		if got != tt.want {
+			t.Errorf("%s: fitCells() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRenderKitty(t *testing.T) {
+	var buf bytes.Buffer
+	// A noisy image makes the payload span several chunks
+	img := image.NewNRGBA(image.Rect(0, 0, 200, 200))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7919 % 251)
+	}
+	if err := Render(&buf, img, Kitty, Cells{Cols: 20, Rows: 10}, 10, 20); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	chunks := regexp.MustCompile("\x1b_G([^;]*);([^\x1b]*)\x1b\\\\").FindAllStringSubmatch(buf.String(), -1)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[0][1], "a=T,f=100,q=2,c=20,r=10,m=1") {
+		t.Errorf("first chunk control = %q", chunks[0][1])
+	}
+	if last := chunks[len(chunks)-1][1]; last != "m=0" {
+		t.Errorf("last chunk control = %q, want m=0", last)
+	}
+
+	var payload strings.Builder
+	for _, chunk := range chunks {
+		if len(chunk[2]) > kittyChunkSize {
+			t.Errorf("chunk of %d bytes exceeds %d", len(chunk[2]), kittyChunkSize)
+		}
+		payload.WriteString(chunk[2])
+	}
+	data, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("payload is not a PNG: %v", err)
+	}
+}
+
+func TestRenderITerm2(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, testImage(4, 4), ITerm2, Cells{Cols: 8, Rows: 4}, 10, 20); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !regexp.MustCompile("^\x1b]1337;File=inline=1;size=\\d+;width=8;height=4;preserveAspectRatio=1:[A-Za-z0-9+/=]+\a\n$").Match(buf.Bytes()) {
+		t.Errorf("unexpected iTerm2 sequence %q", buf.String())
+	}
+}
+
+func TestRenderSixel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderSixel(&buf, testImage(3, 8)); err != nil {
+		t.Fatalf("renderSixel() error = %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "\x1bP0;1;0q\"1;1;3;8") || !strings.HasSuffix(out, "\x1b\\\n") {
+		t.Fatalf("unexpected sixel framing %q", out)
+	}
+	red := fmt.Sprintf("#%d", color.Palette(palette.Plan9).Index(color.RGBA{R: 0xff, A: 0xff}))
+	if !strings.Contains(out, red+";2;100;0;0") {
+		t.Errorf("missing red color register in %q", out)
+	}
+	// Two bands: the first misses the transparent top left pixel, the
+	// second covers the remaining two rows
+	body := strings.TrimSuffix(out[strings.LastIndex(out, ";0;0")+4:], "\x1b\\\n")
+	want := red + "}~~-" + red + "BBB-"
+	if body != want {
+		t.Errorf("sixel data = %q, want %q", body, want)
+	}
+}
+
+func TestWriteSixelRow(t *testing.T) {
+	var buf bytes.Buffer
+	writeSixelRow(&buf, []byte{1, 1, 1, 1, 1, 0, 63})
+	if got := buf.String(); got != "!5@?~" {
+		t.Errorf("writeSixelRow() = %q, want !5@?~", got)
+	}
+}
+
+func TestRenderBlocks(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderBlocks(&buf, testImage(2, 3)); err != nil {
+		t.Fatalf("renderBlocks() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("renderBlocks() wrote %d lines, want 2", len(lines))
+	}
+	want := "\x1b[0;38;2;255;0;0m▄\x1b[38;2;255;0;0;48;2;255;0;0m▀\x1b[0m"
+	if lines[0] != want {
+		t.Errorf("first line = %q, want %q", lines[0], want)
+	}
+	if lines[1] != "\x1b[0;38;2;255;0;0m▀\x1b[0;38;2;255;0;0m▀\x1b[0m" {
+		t.Errorf("second line = %q", lines[1])
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	if !IsValid("sixel") || IsValid("png") || IsValid("") {
+		t.Error("IsValid() accepted or rejected the wrong settings")
+	}
+}
@@ -0,0 +1,104 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"io"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// opaqueThreshold is the alpha at or above which a pixel is drawn
+const opaqueThreshold = 0x80
+
+// renderSixel quantizes img to a 256 color palette and writes it as a sixel
+// image. Transparent pixels are left as the terminal background.
+func renderSixel(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	paletted := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	// P2=1 keeps pixels that are not drawn transparent
+	fmt.Fprintf(w, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+
+	used := make(map[uint8]bool)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if opaque(img, bounds.Min.X+x, bounds.Min.Y+y) {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+	}
+	for index := range palette.Plan9 {
+		if !used[uint8(index)] {
+			continue
+		}
+		r, g, b, _ := palette.Plan9[index].RGBA()
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", index, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	for top := 0; top < height; top += 6 {
+		// Collect one row of sixels per color used in this band
+		rows := make(map[uint8][]byte)
+		for bit := 0; bit < 6 && top+bit < height; bit++ {
+			y := top + bit
+			for x := 0; x < width; x++ {
+				if !opaque(img, bounds.Min.X+x, bounds.Min.Y+y) {
+					continue
+				}
+				index := paletted.ColorIndexAt(x, y)
+				row, ok := rows[index]
+				if !ok {
+					row = make([]byte, width)
+					rows[index] = row
+				}
+				row[x] |= 1 << bit
+			}
+		}
+
+		indexes := make([]int, 0, len(rows))
+		for index := range rows {
+			indexes = append(indexes, int(index))
+		}
+		sort.Ints(indexes)
+		for i, index := range indexes {
+			if i > 0 {
+				io.WriteString(w, "$") // back to the start of the band
+			}
+			fmt.Fprintf(w, "#%d", index)
+			writeSixelRow(w, rows[uint8(index)])
+		}
+		io.WriteString(w, "-") // next band
+	}
+
+	_, err := io.WriteString(w, "\x1b\\\n")
+	return err
+}
+
+// writeSixelRow writes a band row with run-length encoding
+func writeSixelRow(w io.Writer, row []byte) {
+	for x := 0; x < len(row); {
+		run := 1
+		for x+run < len(row) && row[x+run] == row[x] {
+			run++
+		}
+		char := row[x] + '?'
+		if run > 3 {
+			fmt.Fprintf(w, "!%d%c", run, char)
+		} else {
+			for i := 0; i < run; i++ {
+				w.Write([]byte{char})
+			}
+		}
+		x += run
+	}
+}
+
+// opaque reports whether the pixel at x, y should be drawn
+func opaque(img image.Image, x, y int) bool {
+	return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA).A >= opaqueThreshold
+}
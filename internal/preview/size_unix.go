@@ -0,0 +1,24 @@
+//go:build !windows
+
+package preview
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalSize returns stdout's size in cells and the size of a cell in
+// pixels, falling back to a typical terminal when it cannot be queried
+func terminalSize() (cols, rows, cellWidth, cellHeight int) {
+	cols, rows, cellWidth, cellHeight = 80, 24, fallbackCellWidth, fallbackCellHeight
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil || ws.Col == 0 || ws.Row == 0 {
+		return
+	}
+	cols, rows = int(ws.Col), int(ws.Row)
+	if ws.Xpixel > 0 && ws.Ypixel > 0 {
+		cellWidth, cellHeight = max(1, int(ws.Xpixel)/cols), max(1, int(ws.Ypixel)/rows)
+	}
+	return
+}
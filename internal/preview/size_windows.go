@@ -0,0 +1,19 @@
+//go:build windows
+
+package preview
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// terminalSize returns stdout's size in cells and an estimated cell size in
+// pixels; the Windows console does not report pixel sizes
+func terminalSize() (cols, rows, cellWidth, cellHeight int) {
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || cols == 0 || rows == 0 {
+		cols, rows = 80, 24
+	}
+	return cols, rows, fallbackCellWidth, fallbackCellHeight
+}
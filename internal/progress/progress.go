@@ -0,0 +1,278 @@
+package progress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"just-icon/internal/i18n"
+)
+
+// State is the stage a request is in
+type State int
+
+const (
+	// Queued requests wait for their turn
+	Queued State = iota
+	// Running requests wait for the API to answer
+	Running
+	// Retrying requests failed and are sent again after a pause
+	Retrying
+	// Downloading requests fetch the images the API returned as URLs
+	Downloading
+	// Done requests succeeded
+	Done
+	// Failed requests gave up
+	Failed
+)
+
+// barWidth is the width of the download bar in cells
+const barWidth = 16
+
+// Download is the transfer of one image returned as a URL
+type Download struct {
+	Done int64
+	// Total is -1 when the server does not send the size
+	Total int64
+}
+
+// Request is the progress of one API request
+type Request struct {
+	Label   string
+	State   State
+	Attempt int
+	// Started is when the first attempt was sent, Finished when the request
+	// succeeded or gave up
+	Started  time.Time
+	Finished time.Time
+	// RetryAt is when a retrying request is sent again
+	RetryAt time.Time
+	// Err is the error of the last failed attempt
+	Err       error
+	Downloads []Download
+}
+
+// Tracker follows the requests of a run. It is safe for concurrent use.
+type Tracker struct {
+	mu       sync.Mutex
+	requests []Request
+	// estimate is the typical duration of a request, zero when unknown
+	estimate  time.Duration
+	listeners []func(index int, request Request)
+}
+
+// NewTracker tracks one queued request per label. The estimate is the
+// typical request duration used for the time left; zero leaves it out.
+func NewTracker(labels []string, estimate time.Duration) *Tracker {
+	requests := make([]Request, len(labels))
+	for i, label := range labels {
+		requests[i].Label = label
+	}
+	return &Tracker{requests: requests, estimate: estimate}
+}
+
+// OnChange registers fn to be called with every update of a request. It is
+// called while the tracker is locked, so it must not call the tracker.
+func (t *Tracker) OnChange(fn func(index int, request Request)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listeners = append(t.listeners, fn)
+}
+
+// Requests returns a copy of the requests' progress
+func (t *Tracker) Requests() []Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	requests := make([]Request, len(t.requests))
+	for i, request := range t.requests {
+		requests[i] = request
+		requests[i].Downloads = append([]Download(nil), request.Downloads...)
+	}
+	return requests
+}
+
+// Line describes a request's progress at now
+func (t *Tracker) Line(index int, now time.Time) string {
+	t.mu.Lock()
+	request := t.requests[index]
+	t.mu.Unlock()
+	return t.format(index, request, now)
+}
+
+// Reporter returns the reporter that updates the request at index
+func (t *Tracker) Reporter(index int) *Reporter {
+	return &Reporter{tracker: t, index: index}
+}
+
+// update changes the request at index and tells the listeners
+func (t *Tracker) update(index int, change func(*Request)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change(&t.requests[index])
+	for _, fn := range t.listeners {
+		fn(index, t.requests[index])
+	}
+}
+
+// format describes a request, numbering it when the run has several
+func (t *Tracker) format(index int, request Request, now time.Time) string {
+	line := Describe(request, t.estimate, now)
+	if request.Label != "" {
+		line = request.Label + " · " + line
+	}
+	if len(t.requests) > 1 {
+		line = fmt.Sprintf("[%d/%d] %s", index+1, len(t.requests), line)
+	}
+	return line
+}
+
+// Describe says what a request is doing at now, with the time it has taken
+// and, while it runs, the time left according to the estimate
+func Describe(request Request, estimate time.Duration, now time.Time) string {
+	elapsed := formatDuration(now.Sub(request.Started))
+	switch request.State {
+	case Running:
+		line := i18n.Tf("progress_running", elapsed)
+		if request.Attempt > 1 {
+			line += " " + i18n.Tf("progress_attempt", request.Attempt)
+		}
+		if estimate > 0 {
+			if left := estimate - now.Sub(request.Started); left > 0 {
+				line += " · " + i18n.Tf("progress_left", formatDuration(left))
+			} else {
+				line += " · " + i18n.T("progress_overdue")
+			}
+		}
+		return line
+	case Retrying:
+		message := ""
+		if request.Err != nil {
+			message = firstLine(request.Err.Error())
+		}
+		return i18n.Tf("progress_retrying", request.Attempt+1, formatDuration(request.RetryAt.Sub(now)), message)
+	case Downloading:
+		image := len(request.Downloads)
+		download := request.Downloads[image-1]
+		return i18n.Tf("progress_downloading", image) + " " + downloadBar(download)
+	case Done:
+		return i18n.Tf("progress_done", formatDuration(request.Finished.Sub(request.Started)))
+	case Failed:
+		return i18n.Tf("progress_failed", formatDuration(request.Finished.Sub(request.Started)))
+	default:
+		return i18n.T("progress_queued")
+	}
+}
+
+// Median returns the middle of the durations, or zero without any
+func Median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
+
+// Reporter updates one request of a tracker. Its methods do nothing on a
+// nil reporter, so code can report progress whether or not anyone follows it.
+type Reporter struct {
+	tracker *Tracker
+	index   int
+}
+
+// Attempt marks the request as running its nth attempt
+func (r *Reporter) Attempt(n int) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	r.tracker.update(r.index, func(request *Request) {
+		if request.Started.IsZero() {
+			request.Started = now
+		}
+		request.State = Running
+		request.Attempt = n
+	})
+}
+
+// Retry marks the request as waiting to be sent again
+func (r *Reporter) Retry(wait time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	retryAt := time.Now().Add(wait)
+	r.tracker.update(r.index, func(request *Request) {
+		request.State = Retrying
+		request.RetryAt = retryAt
+		request.Err = err
+	})
+}
+
+// Download records the bytes read of an image; total is -1 when unknown
+func (r *Reporter) Download(image int, done, total int64) {
+	if r == nil {
+		return
+	}
+	r.tracker.update(r.index, func(request *Request) {
+		for len(request.Downloads) <= image {
+			request.Downloads = append(request.Downloads, Download{Total: -1})
+		}
+		request.State = Downloading
+		request.Downloads[image] = Download{Done: done, Total: total}
+	})
+}
+
+// Finish marks the request as done, or failed when err is not nil
+func (r *Reporter) Finish(err error) {
+	if r == nil {
+		return
+	}
+	now := time.Now()
+	r.tracker.update(r.index, func(request *Request) {
+		if request.Started.IsZero() {
+			request.Started = now
+		}
+		request.Finished = now
+		request.State = Done
+		if err != nil {
+			request.State = Failed
+			request.Err = err
+		}
+	})
+}
+
+// downloadBar draws the progress of a download, or only the bytes read when
+// the size is unknown
+func downloadBar(download Download) string {
+	if download.Total <= 0 {
+		return formatSize(download.Done)
+	}
+	filled := int(int64(barWidth) * min(download.Done, download.Total) / download.Total)
+	return fmt.Sprintf("▕%s%s▏ %s / %s",
+		strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled),
+		formatSize(download.Done), formatSize(download.Total))
+}
+
+// formatDuration rounds a duration to whole seconds
+func formatDuration(d time.Duration) string {
+	return max(0, d).Round(time.Second).String()
+}
+
+// formatSize formats a byte count in B, KB or MB
+func formatSize(bytes int64) string {
+	if bytes < 1<<10 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	if bytes < 1<<20 {
+		return fmt.Sprintf("%.0f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
+
+// firstLine returns the first line of a message
+func firstLine(message string) string {
+	line, _, _ := strings.Cut(message, "\n")
+	return line
+}
@@ -0,0 +1,109 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start.Add(12 * time.Second)
+
+	tests := []struct {
+		name     string
+		request  Request
+		estimate time.Duration
+		want     string
+	}{
+		{"queued", Request{}, 0, "queued"},
+		{"running", Request{State: Running, Attempt: 1, Started: start}, 0, "running 12s"},
+		{"eta", Request{State: Running, Attempt: 1, Started: start}, 45 * time.Second, "running 12s · ~33s left"},
+		{"overdue", Request{State: Running, Attempt: 2, Started: start}, 10 * time.Second, "running 12s (attempt 2) · taking longer than usual"},
+		{"retrying", Request{State: Retrying, Attempt: 1, Started: start, RetryAt: now.Add(4 * time.Second), Err: errors.New("429 slow down\ndetails")}, 0, "retry 2 in 4s: 429 slow down"},
+		{"download", Request{State: Downloading, Started: start, Downloads: []Download{{Done: 1 << 20, Total: 1 << 20}, {Done: 256 << 10, Total: 1 << 20}}}, 0, "downloading image 2 ▕████░░░░░░░░░░░░▏ 256 KB / 1.0 MB"},
+		{"unknown size", Request{State: Downloading, Started: start, Downloads: []Download{{Done: 3 << 20, Total: -1}}}, 0, "downloading image 1 3.0 MB"},
+		{"done", Request{State: Done, Started: start, Finished: now}, time.Minute, "done in 12s"},
+		{"failed", Request{State: Failed, Started: start, Finished: now, Err: errors.New("boom")}, 0, "failed after 12s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Describe(tt.request, tt.estimate, now); got != tt.want {
+				t.Errorf("Describe() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReporter(t *testing.T) {
+	tracker := NewTracker([]string{"rocket", "moon"}, 0)
+	var states []State
+	tracker.OnChange(func(index int, request Request) {
+		if index == 1 {
+			states = append(states, request.State)
+		}
+	})
+
+	reporter := tracker.Reporter(1)
+	reporter.Attempt(1)
+	reporter.Retry(time.Second, errors.New("503"))
+	reporter.Attempt(2)
+	reporter.Download(1, 10, 100)
+	reporter.Finish(nil)
+
+	want := []State{Running, Retrying, Running, Downloading, Done}
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %v, want %v", states, want)
+		}
+	}
+
+	requests := tracker.Requests()
+	if requests[0].State != Queued || requests[1].Attempt != 2 || len(requests[1].Downloads) != 2 || requests[1].Downloads[0].Total != -1 {
+		t.Errorf("unexpected requests %+v", requests)
+	}
+	if line := tracker.Line(1, time.Now()); !strings.HasPrefix(line, "[2/2] moon · done in") {
+		t.Errorf("Line() = %q", line)
+	}
+
+	// A nil reporter ignores updates
+	var none *Reporter
+	none.Attempt(1)
+	none.Finish(errors.New("ignored"))
+}
+
+func TestRenderLog(t *testing.T) {
+	var out bytes.Buffer
+	tracker := NewTracker([]string{"rocket"}, 0)
+	stop := Render(&out, tracker, false)
+
+	reporter := tracker.Reporter(0)
+	reporter.Attempt(1)
+	for done := int64(0); done <= 100; done += 10 {
+		reporter.Download(0, done, 100)
+	}
+	reporter.Finish(nil)
+	stop()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("logged %d lines, want running, download start and end, done:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[0], "rocket · running") || !strings.Contains(lines[3], "rocket · done in") {
+		t.Errorf("unexpected log:\n%s", out.String())
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := Median(nil); got != 0 {
+		t.Errorf("Median(nil) = %v", got)
+	}
+	if got := Median([]time.Duration{30 * time.Second, 10 * time.Second, 20 * time.Second}); got != 20*time.Second {
+		t.Errorf("Median() = %v, want 20s", got)
+	}
+}
@@ -0,0 +1,132 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// frameInterval is how often live progress is redrawn
+const frameInterval = 120 * time.Millisecond
+
+// spinnerFrames animate running requests
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Render shows the tracker's progress on w until the returned function is
+// called. Live progress redraws one line per request in place; otherwise a
+// timestamped line is written whenever a request changes state, which suits
+// pipes and CI logs.
+func Render(w io.Writer, t *Tracker, live bool) (stop func()) {
+	if !live {
+		logChanges(w, t)
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(frameInterval)
+		defer ticker.Stop()
+		lines := 0
+		for frame := 0; ; frame++ {
+			select {
+			case <-done:
+				draw(w, t, lines, -1)
+				return
+			case <-ticker.C:
+				lines = draw(w, t, lines, frame)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-finished
+		})
+	}
+}
+
+// draw replaces the previously drawn lines with the current progress and
+// returns the number of lines drawn. A negative frame draws the final state
+// without animation.
+func draw(w io.Writer, t *Tracker, previous, frame int) int {
+	if previous > 0 {
+		fmt.Fprintf(w, "\x1b[%dA", previous)
+	}
+	width := terminalWidth(w)
+	now := time.Now()
+	requests := t.Requests()
+	for i, request := range requests {
+		line := symbol(request.State, frame) + " " + t.format(i, request, now)
+		if width > 0 {
+			line = runewidth.Truncate(line, width-1, "…")
+		}
+		fmt.Fprintf(w, "\r\x1b[2K%s\n", line)
+	}
+	return len(requests)
+}
+
+// symbol marks a request's state, animating the active ones
+func symbol(state State, frame int) string {
+	switch state {
+	case Queued:
+		return "·"
+	case Retrying:
+		return "↻"
+	case Done:
+		return "✔"
+	case Failed:
+		return "✘"
+	}
+	if frame < 0 {
+		return "…"
+	}
+	return spinnerFrames[frame%len(spinnerFrames)]
+}
+
+// logChanges writes a line for every state change of a request and for the
+// start and end of every download
+func logChanges(w io.Writer, t *Tracker) {
+	type seen struct {
+		state     State
+		attempt   int
+		downloads int
+		complete  int
+	}
+	last := make(map[int]seen)
+	t.OnChange(func(index int, request Request) {
+		current := seen{state: request.State, attempt: request.Attempt, downloads: len(request.Downloads)}
+		for _, download := range request.Downloads {
+			if download.Total > 0 && download.Done >= download.Total {
+				current.complete++
+			}
+		}
+		if previous, ok := last[index]; ok && previous == current {
+			return
+		}
+		last[index] = current
+		now := time.Now()
+		fmt.Fprintf(w, "%s %s\n", now.Format("15:04:05"), t.format(index, request, now))
+	})
+}
+
+// terminalWidth returns the width of the terminal w writes to, or zero
+func terminalWidth(w io.Writer) int {
+	file, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+	width, _, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,225 @@
+// Package queue keeps generation jobs in the state directory so batches
+// survive interruptions. Each job records its status, attempts and outputs;
+// a Worker processes queued jobs and picks up the ones an interrupted run
+// left behind.
+package queue
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/jsonl"
+	"just-icon/internal/types"
+)
+
+// FileName is the queue file inside the state directory
+const FileName = "jobs.jsonl"
+
+var (
+	ErrNotFound  = jsonl.ErrNotFound
+	ErrAmbiguous = jsonl.ErrAmbiguous
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	// Queued jobs wait for a worker
+	Queued Status = "queued"
+	// Running jobs are being generated
+	Running Status = "running"
+	// Succeeded jobs saved their images
+	Succeeded Status = "succeeded"
+	// Failed jobs stopped with an error; they can be retried
+	Failed Status = "failed"
+	// Canceled jobs are skipped; they can be retried
+	Canceled Status = "canceled"
+)
+
+// Job is one queued generation
+type Job struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	Status  Status    `json:"status"`
+	// Preset names the style preset the generation started from
+	Preset string `json:"preset,omitempty"`
+	// Branded records that the brand kit is applied; the kit itself is not
+	// stored so the run uses the current one
+	Branded bool                        `json:"branded,omitempty"`
+	Options types.IconGenerationOptions `json:"options"`
+	// Attempts counts the runs started, including interrupted ones
+	Attempts  int      `json:"attempts,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	HistoryID string   `json:"history_id,omitempty"`
+	CostUSD   float64  `json:"cost_usd,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+// Pending reports whether the job still has to run
+func (j Job) Pending() bool {
+	return j.Status == Queued || j.Status == Running
+}
+
+// UserPrompt returns the prompt as the user typed it, before enhancement
+func (j Job) UserPrompt() string {
+	if j.Options.OriginalPrompt != "" {
+		return j.Options.OriginalPrompt
+	}
+	return j.Options.Prompt
+}
+
+// Store is the JSON lines queue file. Every change rewrites it atomically
+// under a lock, so several processes can share it.
+type Store struct {
+	file *jsonl.File[Job]
+}
+
+// NewStore creates a store backed by the queue file at path
+func NewStore(path string) *Store {
+	return &Store{file: jsonl.New[Job](path, "job queue")}
+}
+
+// DefaultStore returns the store in the default config service's state directory
+func DefaultStore() *Store {
+	return NewStore(filepath.Join(config.DefaultService.StateDir(), FileName))
+}
+
+// Path returns the queue file path
+func (s *Store) Path() string {
+	return s.file.Path()
+}
+
+// Add queues generations and returns the new jobs. The brand kit is
+// replaced by the Branded flag.
+func (s *Store) Add(preset string, options ...types.IconGenerationOptions) ([]Job, error) {
+	now := time.Now()
+	jobs := make([]Job, 0, len(options))
+	for _, opts := range options {
+		id, err := jsonl.NewID()
+		if err != nil {
+			return nil, err
+		}
+		job := Job{ID: id, Created: now, Updated: now, Status: Queued, Preset: preset, Options: opts}
+		if job.Options.Brand != nil {
+			job.Branded = true
+			job.Options.Brand = nil
+		}
+		jobs = append(jobs, job)
+	}
+	err := s.file.Modify(func(existing []Job) ([]Job, error) {
+		return append(existing, jobs...), nil
+	})
+	return jobs, err
+}
+
+// List returns all jobs, oldest first. Lines that fail to parse are skipped
+// so a torn write doesn't hide the rest of the queue.
+func (s *Store) List() ([]Job, error) {
+	return s.file.Read()
+}
+
+// Find resolves a reference to a job by ID or unique ID prefix
+func (s *Store) Find(ref string) (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return find(jobs, ref)
+}
+
+// Cancel marks the referenced jobs canceled and returns them. Only pending
+// jobs can be canceled; the worker stops a running job's generation, and
+// images it had already saved are recorded on the job.
+func (s *Store) Cancel(refs []string) ([]Job, error) {
+	return s.transition(refs, Job.Pending, func(job *Job) {
+		job.Status = Canceled
+	})
+}
+
+// Retry queues the referenced failed or canceled jobs again and returns them
+func (s *Store) Retry(refs []string) ([]Job, error) {
+	return s.transition(refs, func(job Job) bool {
+		return job.Status == Failed || job.Status == Canceled
+	}, func(job *Job) {
+		job.Status = Queued
+		job.Error = ""
+	})
+}
+
+// Remove deletes the jobs that are no longer pending and returns how many
+// were removed
+func (s *Store) Remove() (int, error) {
+	removed := 0
+	err := s.file.Modify(func(jobs []Job) ([]Job, error) {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if job.Pending() {
+				kept = append(kept, job)
+			}
+		}
+		removed = len(jobs) - len(kept)
+		return kept, nil
+	})
+	return removed, err
+}
+
+// transition applies change to the referenced jobs, or to every job when
+// refs is empty. It fails when a referenced job is not eligible.
+func (s *Store) transition(refs []string, eligible func(Job) bool, change func(*Job)) ([]Job, error) {
+	var changed []Job
+	err := s.file.Modify(func(jobs []Job) ([]Job, error) {
+		var ids []string
+		for _, ref := range refs {
+			job, err := find(jobs, ref)
+			if err != nil {
+				return nil, fmt.Errorf("job %s: %w", ref, err)
+			}
+			if !eligible(*job) {
+				return nil, fmt.Errorf("job %s is %s", job.ID, job.Status)
+			}
+			ids = append(ids, job.ID)
+		}
+		now := time.Now()
+		for i := range jobs {
+			if len(refs) == 0 && !eligible(jobs[i]) || len(refs) > 0 && !slices.Contains(ids, jobs[i].ID) {
+				continue
+			}
+			change(&jobs[i])
+			jobs[i].Updated = now
+			changed = append(changed, jobs[i])
+		}
+		return jobs, nil
+	})
+	return changed, err
+}
+
+// update applies fn to the job with the given ID. fn reports whether it
+// changed the job.
+func (s *Store) update(id string, fn func(*Job) bool) (Job, bool, error) {
+	var updated Job
+	changed := false
+	err := s.file.Modify(func(jobs []Job) ([]Job, error) {
+		for i := range jobs {
+			if jobs[i].ID == id && fn(&jobs[i]) {
+				jobs[i].Updated = time.Now()
+				updated, changed = jobs[i], true
+			}
+		}
+		return jobs, nil
+	})
+	return updated, changed, err
+}
+
+// find matches ref against job IDs by prefix
+func find(jobs []Job, ref string) (*Job, error) {
+	i, err := jsonl.Find(jobs, func(j Job) string { return j.ID }, ref)
+	if err != nil {
+		return nil, err
+	}
+	return &jobs[i], nil
+}
@@ -0,0 +1,277 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"just-icon/internal/generator"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// testWorker returns a worker on a fresh queue whose generations run
+// generate
+func testWorker(t *testing.T, generate func(ctx context.Context, options *types.IconGenerationOptions) error) (*Store, *Worker) {
+	t.Helper()
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	worker := NewWorker(store, 2)
+	worker.generate = func(ctx context.Context, options *types.IconGenerationOptions, preset string, reporter *progress.Reporter) (*generator.Outcome, error) {
+		if err := generate(ctx, options); err != nil {
+			return nil, err
+		}
+		file := filepath.Join(options.Output, options.Prompt+".png")
+		return &generator.Outcome{Result: &types.GenerationResult{Cost: 0.01}, Files: []string{file}, HistoryID: "h-" + options.Prompt}, nil
+	}
+	return store, worker
+}
+
+// add queues one job per prompt
+func add(t *testing.T, store *Store, prompts ...string) []Job {
+	t.Helper()
+	var options []types.IconGenerationOptions
+	for _, prompt := range prompts {
+		options = append(options, types.IconGenerationOptions{Prompt: prompt, Output: "/icons"})
+	}
+	jobs, err := store.Add("", options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jobs
+}
+
+// statuses returns the status of every job by prompt
+func statuses(t *testing.T, store *Store) map[string]Status {
+	t.Helper()
+	jobs, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[string]Status)
+	for _, job := range jobs {
+		result[job.Options.Prompt] = job.Status
+	}
+	return result
+}
+
+func TestWorkerRunsQueue(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	store, worker := testWorker(t, func(ctx context.Context, options *types.IconGenerationOptions) error {
+		mu.Lock()
+		active++
+		peak = max(peak, active)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		if options.Prompt == "bad" {
+			return errors.New("content policy")
+		}
+		return nil
+	})
+	add(t, store, "rocket", "plane", "bad", "boat")
+
+	if err := worker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Status{"rocket": Succeeded, "plane": Succeeded, "bad": Failed, "boat": Succeeded}
+	if got := statuses(t, store); !maps.Equal(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if peak != 2 {
+		t.Errorf("ran %d jobs at once, want 2", peak)
+	}
+	jobs, _ := store.List()
+	if job := jobs[0]; job.Attempts != 1 || len(job.Files) != 1 || job.HistoryID != "h-rocket" || job.CostUSD != 0.01 {
+		t.Errorf("finished job = %+v", job)
+	}
+	if job := jobs[2]; job.Error != "content policy" || len(job.Files) != 0 {
+		t.Errorf("failed job = %+v", job)
+	}
+
+	// A retried job runs again
+	if _, err := store.Retry([]string{jobs[2].ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Retry([]string{jobs[0].ID}); err == nil {
+		t.Error("retrying a succeeded job should fail")
+	}
+	worker.Run(context.Background())
+	if job, _ := store.Find(jobs[2].ID); job.Attempts != 2 || job.Status != Failed {
+		t.Errorf("retried job = %+v", job)
+	}
+}
+
+func TestWorkerResumesInterruptedRun(t *testing.T) {
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	stopped := 0
+	store, worker := testWorker(t, func(ctx context.Context, options *types.IconGenerationOptions) error {
+		started <- options.Prompt
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			mu.Lock()
+			stopped++
+			mu.Unlock()
+			return ctx.Err()
+		}
+	})
+	jobs := add(t, store, "rocket", "plane", "boat")
+
+	// Interrupt the run while the first two jobs are generating
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+	<-started
+	<-started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted run returned %v", err)
+	}
+	// Run returned only after both generations stopped
+	mu.Lock()
+	if stopped != 2 {
+		t.Errorf("%d generations stopped before Run returned, want 2", stopped)
+	}
+	mu.Unlock()
+	if got := statuses(t, store); got["rocket"] != Queued || got["plane"] != Queued || got["boat"] != Queued {
+		t.Errorf("after interruption statuses = %v, want all queued", got)
+	}
+
+	// A job a crashed worker left running is picked up as well
+	store.update(jobs[2].ID, func(job *Job) bool {
+		job.Status = Running
+		return true
+	})
+	if _, err := store.Cancel([]string{jobs[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+	if err := worker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := statuses(t, store); got["rocket"] != Succeeded || got["plane"] != Canceled || got["boat"] != Succeeded {
+		t.Errorf("after resuming statuses = %v", got)
+	}
+	if job, _ := store.Find(jobs[0].ID); job.Attempts != 2 {
+		t.Errorf("resumed job made %d attempts, want 2", job.Attempts)
+	}
+}
+
+func TestWorkerIsExclusive(t *testing.T) {
+	release := make(chan struct{})
+	store, worker := testWorker(t, func(context.Context, *types.IconGenerationOptions) error {
+		<-release
+		return nil
+	})
+	add(t, store, "rocket")
+	done := make(chan error)
+	go func() { done <- worker.Run(context.Background()) }()
+	for deadline := time.Now().Add(5 * time.Second); statuses(t, store)["rocket"] != Running && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := NewWorker(store, 1).Run(context.Background()); !errors.Is(err, ErrBusy) {
+		t.Errorf("second worker returned %v, want ErrBusy", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkerStopsCanceledJob(t *testing.T) {
+	cancelPollInterval = 5 * time.Millisecond
+	defer func() { cancelPollInterval = time.Second }()
+
+	stopped := make(chan error, 1)
+	store, worker := testWorker(t, func(ctx context.Context, options *types.IconGenerationOptions) error {
+		if options.Prompt != "rocket" {
+			return nil
+		}
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return ctx.Err()
+	})
+	jobs := add(t, store, "rocket", "plane")
+	worker.OnUpdate = func(job Job) {
+		if job.Status == Running && job.ID == jobs[0].ID {
+			go store.Cancel([]string{job.ID})
+		}
+	}
+
+	if err := worker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled generation stopped with %v", err)
+	}
+	job, _ := store.Find(jobs[0].ID)
+	if job.Status != Canceled || job.Error != "" || len(job.Files) != 0 {
+		t.Errorf("canceled job = %+v", job)
+	}
+	if got := statuses(t, store); got["plane"] != Succeeded {
+		t.Errorf("statuses = %v", got)
+	}
+}
+
+func TestWorkerKeepsFilesOfCanceledJob(t *testing.T) {
+	store, worker := testWorker(t, func(ctx context.Context, options *types.IconGenerationOptions) error {
+		// The images are saved before the cancel is noticed
+		return nil
+	})
+	jobs := add(t, store, "rocket")
+	var reported []Status
+	worker.OnUpdate = func(job Job) {
+		reported = append(reported, job.Status)
+		if job.Status == Running {
+			if _, err := store.Cancel([]string{job.ID}); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+
+	if err := worker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	job, _ := store.Find(jobs[0].ID)
+	if job.Status != Canceled || len(job.Files) != 1 || job.HistoryID != "h-rocket" {
+		t.Errorf("canceled job = %+v", job)
+	}
+	if len(reported) != 1 {
+		t.Errorf("reported statuses = %v", reported)
+	}
+}
+
+func TestStoreFindAndRemove(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), FileName))
+	jobs := add(t, store, "rocket", "plane")
+	brandJobs, _ := store.Add("flat", types.IconGenerationOptions{Prompt: "boat", Brand: &types.BrandKit{Mood: []string{"calm"}}})
+	if job := brandJobs[0]; !job.Branded || job.Options.Brand != nil || job.Preset != "flat" {
+		t.Errorf("branded job = %+v, want the kit replaced by the flag", job)
+	}
+
+	if job, err := store.Find(jobs[1].ID[:6]); err != nil || job.ID != jobs[1].ID {
+		t.Errorf("Find(prefix) = %v, %v", job, err)
+	}
+	if _, err := store.Find("zzz"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Find(unknown) error = %v", err)
+	}
+
+	store.Cancel([]string{jobs[0].ID})
+	if removed, err := store.Remove(); err != nil || removed != 1 {
+		t.Errorf("Remove() = %d, %v, want 1", removed, err)
+	}
+	if remaining, _ := store.List(); len(remaining) != 2 {
+		t.Errorf("%d jobs remain, want 2", len(remaining))
+	}
+}
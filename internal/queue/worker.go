@@ -0,0 +1,255 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"just-icon/internal/brand"
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// DefaultConcurrency is how many jobs a worker runs at once
+	DefaultConcurrency = 2
+	// workerLockSuffix names the lock a worker holds while it runs
+	workerLockSuffix = ".worker"
+)
+
+// cancelPollInterval is how often a worker looks for running jobs that were
+// canceled; tests shorten it
+var cancelPollInterval = time.Second
+
+// ErrBusy is returned when another worker is already processing the queue
+var ErrBusy = errors.New("another worker is already processing the job queue")
+
+// Worker processes queued jobs. Only one worker runs per queue, so jobs it
+// finds running when it starts were interrupted and are queued again.
+type Worker struct {
+	store       *Store
+	concurrency int
+
+	// OnUpdate, when set, is called when a job is queued again, starts or
+	// finishes. Calls never overlap.
+	OnUpdate func(Job)
+	notify   sync.Mutex
+
+	// generate does the work; tests replace it
+	generate func(context.Context, *types.IconGenerationOptions, string, *progress.Reporter) (*generator.Outcome, error)
+}
+
+// NewWorker creates a worker running up to concurrency jobs at once; zero
+// or less uses DefaultConcurrency
+func NewWorker(store *Store, concurrency int) *Worker {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Worker{store: store, concurrency: concurrency, generate: generator.Run}
+}
+
+// Run processes queued jobs, including ones added while it runs, until none
+// is left. Canceling a running job stops its generation. When ctx is done
+// the running generations are stopped, and once they have returned the jobs
+// are queued again so the next run picks them up.
+func (w *Worker) Run(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(w.store.Path()), types.ConfigDirPerm); err != nil {
+		return fmt.Errorf("failed to create job queue directory: %w", err)
+	}
+	lock, ok, err := utils.TryLockFile(w.store.Path() + workerLockSuffix)
+	if err != nil {
+		return fmt.Errorf("failed to lock job queue: %w", err)
+	}
+	if !ok {
+		return ErrBusy
+	}
+	defer lock.Unlock()
+
+	// No other worker is alive, so running jobs were interrupted
+	if err := w.requeue(); err != nil {
+		return err
+	}
+
+	// Every running job can be stopped on its own
+	cancels := make(map[string]context.CancelFunc)
+	finished := make(chan string, w.concurrency)
+	poll := time.NewTicker(cancelPollInterval)
+	defer poll.Stop()
+	for {
+		// Fill the free slots with the oldest queued jobs
+		for ctx.Err() == nil && len(cancels) < w.concurrency {
+			job, ok, err := w.claim()
+			if err != nil {
+				w.stop(cancels, finished)
+				return err
+			}
+			if !ok {
+				break
+			}
+			jobCtx, cancel := context.WithCancel(ctx)
+			cancels[job.ID] = cancel
+			w.report(job)
+			go func() {
+				w.process(jobCtx, job)
+				finished <- job.ID
+			}()
+		}
+		if len(cancels) == 0 {
+			return ctx.Err()
+		}
+
+		select {
+		case id := <-finished:
+			cancels[id]()
+			delete(cancels, id)
+		case <-poll.C:
+			w.stopCanceled(cancels)
+		case <-ctx.Done():
+			// Nothing may still be generating once the lock is released
+			w.stop(cancels, finished)
+			if err := w.requeue(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// stop cancels the running jobs and waits for them to return
+func (w *Worker) stop(cancels map[string]context.CancelFunc, finished <-chan string) {
+	for _, cancel := range cancels {
+		cancel()
+	}
+	for range len(cancels) {
+		<-finished
+	}
+	clear(cancels)
+}
+
+// stopCanceled cancels the running jobs that are no longer marked running,
+// so they stop generating
+func (w *Worker) stopCanceled(cancels map[string]context.CancelFunc) {
+	jobs, err := w.store.List()
+	if err != nil {
+		return
+	}
+	for _, job := range jobs {
+		if cancel, ok := cancels[job.ID]; ok && job.Status != Running {
+			cancel()
+		}
+	}
+}
+
+// requeue puts running jobs back in the queue
+func (w *Worker) requeue() error {
+	jobs, err := w.store.transition(nil, func(job Job) bool {
+		return job.Status == Running
+	}, func(job *Job) {
+		job.Status = Queued
+	})
+	for _, job := range jobs {
+		w.report(job)
+	}
+	return err
+}
+
+// claim marks the oldest queued job running and returns it
+func (w *Worker) claim() (Job, bool, error) {
+	var claimed Job
+	ok := false
+	err := w.store.file.Modify(func(jobs []Job) ([]Job, error) {
+		for i := range jobs {
+			if jobs[i].Status != Queued {
+				continue
+			}
+			jobs[i].Status = Running
+			jobs[i].Attempts++
+			jobs[i].Error = ""
+			claimed, ok = jobs[i], true
+			break
+		}
+		return jobs, nil
+	})
+	return claimed, ok, err
+}
+
+// process runs a job and records its outcome, unless the job was queued
+// again in the meantime. A job that failed because ctx ended is left as it
+// is: Run queues interrupted jobs again and canceled ones stay canceled. A
+// job canceled after its images were saved stays canceled but keeps its
+// files, so they are listed and retrying it warns about duplicates.
+func (w *Worker) process(ctx context.Context, job Job) {
+	outcome, err := w.run(ctx, job)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	finished, changed, updateErr := w.store.update(job.ID, func(current *Job) bool {
+		if current.Attempts != job.Attempts {
+			return false
+		}
+		switch current.Status {
+		case Running:
+			current.Status = Succeeded
+			if err != nil {
+				current.Status = Failed
+				current.Error = err.Error()
+			}
+		case Canceled:
+			if outcome == nil || len(outcome.Files) == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+		if outcome != nil {
+			current.Files = outcome.Files
+			current.HistoryID = outcome.HistoryID
+			current.Warnings = outcome.Warnings
+			if outcome.Result != nil {
+				current.CostUSD = outcome.Result.Cost
+			}
+		}
+		return true
+	})
+	switch {
+	case updateErr != nil:
+		job.Status, job.Error = Failed, updateErr.Error()
+		w.report(job)
+	case changed && finished.Status != Canceled:
+		w.report(finished)
+	}
+}
+
+// run generates a job's images
+func (w *Worker) run(ctx context.Context, job Job) (*generator.Outcome, error) {
+	options := job.Options
+	// The brand kit is not stored in the queue, so apply the current one
+	if job.Branded {
+		cfg, err := config.DefaultService.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		if brand.IsEmpty(cfg.Brand) {
+			return nil, errors.New("no brand kit is configured")
+		}
+		options.Brand = cfg.Brand
+	}
+	return w.generate(ctx, &options, job.Preset, nil)
+}
+
+// report passes a job to OnUpdate
+func (w *Worker) report(job Job) {
+	if w.OnUpdate == nil {
+		return
+	}
+	w.notify.Lock()
+	defer w.notify.Unlock()
+	w.OnUpdate(job)
+}
@@ -0,0 +1,250 @@
+package report
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/image/draw"
+
+	"just-icon/internal/i18n"
+	"just-icon/internal/metadata"
+	"just-icon/pkg/utils"
+)
+
+const (
+	// ContactSheetName is the contact sheet written into the output directory
+	ContactSheetName = "index.html"
+	// thumbnailSize is the longest edge of the embedded previews in pixels
+	thumbnailSize = 256
+	// appLabelWidth truncates the app name shown under home screen previews
+	appLabelWidth = 10
+	// timeFormat formats generation times on the sheet
+	timeFormat = "2006-01-02 15:04"
+	// generatorMeta marks contact sheets written by this tool, which may be
+	// replaced; it must match the tag in contact_sheet.html
+	generatorMeta = `<meta name="generator" content="just-icon">`
+	// generatorScan is how much of an existing file is searched for the mark
+	generatorScan = 4096
+)
+
+//go:embed contact_sheet.html
+var contactSheetHTML string
+
+var contactSheetTemplate = template.Must(template.New("contact_sheet").Parse(contactSheetHTML))
+
+// smallSizes are the pixel sizes the icon is previewed at
+var smallSizes = []int{16, 24, 32, 48, 64}
+
+// Item is one image on the contact sheet
+type Item struct {
+	Path     string
+	Metadata metadata.Metadata
+}
+
+// option is a labeled value in an item's options table
+type option struct {
+	Label string
+	Value string
+}
+
+// itemView is an item prepared for the template
+type itemView struct {
+	Name      string
+	Href      string
+	Thumbnail template.URL
+	Error     string
+	AppName   string
+	Prompt    string
+	Original  string
+	Revised   string
+	Options   []option
+}
+
+// sheetView is the data passed to the template
+type sheetView struct {
+	Lang       string
+	Title      string
+	Summary    string
+	Items      []itemView
+	SmallSizes []int
+	Labels     map[string]string
+	// Before and After number the placeholder apps around the icon on the
+	// simulated home screens
+	Before []int
+	After  []int
+}
+
+// WriteContactSheet writes a self-contained index.html into dir showing the
+// items, and returns its path. A sheet written earlier by this tool is
+// replaced; any other index.html is kept and the sheet goes to index-2.html,
+// index-3.html and so on.
+func WriteContactSheet(dir string, items []Item) (string, error) {
+	var buf bytes.Buffer
+	if err := ContactSheet(&buf, dir, items); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, ContactSheetName)
+	if ownSheet(path) {
+		if err := utils.WriteFileAtomic(path, buf.Bytes(), 0644); err != nil {
+			return "", fmt.Errorf("failed to write contact sheet: %w", err)
+		}
+		return path, nil
+	}
+	path, err := utils.WriteFileExclusive(path, buf.Bytes(), 0644, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to write contact sheet: %w", err)
+	}
+	return path, nil
+}
+
+// ownSheet reports whether path is a contact sheet written by this tool
+func ownSheet(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	head := make([]byte, generatorScan)
+	n, _ := io.ReadFull(file, head)
+	return bytes.Contains(head[:n], []byte(generatorMeta))
+}
+
+// ContactSheet renders the contact sheet HTML. Images are embedded as
+// thumbnails and linked relative to dir; no external assets are referenced.
+func ContactSheet(w io.Writer, dir string, items []Item) error {
+	view := sheetView{
+		Lang:       string(i18n.GetLocalizer().GetCurrentLanguage()),
+		Title:      i18n.T("report_title"),
+		SmallSizes: smallSizes,
+		Labels: map[string]string{
+			"prompt":      i18n.T("report_prompt"),
+			"original":    i18n.T("report_original"),
+			"revised":     i18n.T("report_revised"),
+			"small_sizes": i18n.T("report_small_sizes"),
+			"ios":         i18n.T("report_ios"),
+			"android":     i18n.T("report_android"),
+			"open":        i18n.T("report_open"),
+		},
+		Before: []int{1, 2, 3, 4, 5},
+		After:  []int{6, 7, 8, 9, 10, 11},
+	}
+
+	var latest time.Time
+	for _, item := range items {
+		view.Items = append(view.Items, newItemView(dir, item))
+		if item.Metadata.Timestamp.After(latest) {
+			latest = item.Metadata.Timestamp
+		}
+	}
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+	view.Summary = i18n.Tf("report_summary", len(items), latest.Local().Format(timeFormat))
+
+	return contactSheetTemplate.Execute(w, view)
+}
+
+// newItemView prepares an item for the template
+func newItemView(dir string, item Item) itemView {
+	md := item.Metadata
+	view := itemView{
+		Name:     filepath.Base(item.Path),
+		Href:     relativeHref(dir, item.Path),
+		AppName:  runewidth.Truncate(appName(md), appLabelWidth, "…"),
+		Prompt:   md.Prompt,
+		Original: md.OriginalPrompt,
+		Revised:  md.RevisedPrompt,
+	}
+
+	thumbnail, err := thumbnailURL(item.Path)
+	if err != nil {
+		view.Error = i18n.Tf("report_thumbnail_failed", err.Error())
+	}
+	view.Thumbnail = thumbnail
+
+	for _, opt := range []option{
+		{i18n.T("report_model"), md.Model},
+		{i18n.T("report_template"), md.Template},
+		{i18n.T("report_preset"), md.Preset},
+		{i18n.T("report_size"), md.Size},
+		{i18n.T("report_quality"), md.Quality},
+		{i18n.T("report_background"), md.Background},
+		{i18n.T("report_format"), md.OutputFormat},
+	} {
+		if opt.Value != "" {
+			view.Options = append(view.Options, opt)
+		}
+	}
+	if md.CostUSD > 0 {
+		view.Options = append(view.Options, option{i18n.T("report_cost"), fmt.Sprintf("~$%.4f", md.CostUSD)})
+	}
+	return view
+}
+
+// thumbnailURL returns the image scaled to fit thumbnailSize as a PNG data URL
+func thumbnailURL(path string) (template.URL, error) {
+	img, err := utils.LoadImage(path)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			width, height = thumbnailSize, max(1, height*thumbnailSize/width)
+		} else {
+			width, height = max(1, width*thumbnailSize/height), thumbnailSize
+		}
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+		img = scaled
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// relativeHref links to the image relative to the sheet's directory
+func relativeHref(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// appName labels the home screen previews with the prompt's first word
+func appName(md metadata.Metadata) string {
+	prompt := md.OriginalPrompt
+	if prompt == "" {
+		prompt = md.Prompt
+	}
+	words := strings.Fields(prompt)
+	if len(words) == 0 {
+		return "App"
+	}
+	name := []rune(words[0])
+	return strings.ToUpper(string(name[:1])) + string(name[1:])
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"just-icon/internal/metadata"
+)
+
+func writePNG(t *testing.T, path string, width, height int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteContactSheet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "red square", "icon 1.png")
+	writePNG(t, path, 600, 300)
+
+	items := []Item{
+		{
+			Path: path,
+			Metadata: metadata.Metadata{
+				Prompt:    "a <b>bold</b> rocket",
+				Model:     "gpt-image-1",
+				Quality:   "high",
+				CostUSD:   0.167,
+				Timestamp: time.Now(),
+			},
+		},
+		{Path: filepath.Join(dir, "missing.png")},
+	}
+
+	sheet, err := WriteContactSheet(dir, items)
+	if err != nil {
+		t.Fatalf("WriteContactSheet() error = %v", err)
+	}
+	if sheet != filepath.Join(dir, ContactSheetName) {
+		t.Errorf("WriteContactSheet() = %q", sheet)
+	}
+	data, err := os.ReadFile(sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(data)
+
+	for _, want := range []string{
+		`href="red%20square/icon%201.png"`,
+		"a &lt;b&gt;bold&lt;/b&gt; rocket",
+		"~$0.1670",
+		"missing.png",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("contact sheet is missing %q", want)
+		}
+	}
+	if regexp.MustCompile(`(src|href)="(https?:)?//`).MatchString(html) {
+		t.Error("contact sheet references external assets")
+	}
+
+	match := regexp.MustCompile(`data:image/png;base64,([A-Za-z0-9+/=]+)`).FindStringSubmatch(html)
+	if match == nil {
+		t.Fatal("contact sheet has no embedded thumbnail")
+	}
+	raw, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := png.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || config.Width != thumbnailSize || config.Height != thumbnailSize/2 {
+		t.Errorf("thumbnail = %+v, %v; want %dx%d", config, err, thumbnailSize, thumbnailSize/2)
+	}
+}
+
+func TestWriteContactSheetKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	index := filepath.Join(dir, ContactSheetName)
+	if err := os.WriteFile(index, []byte("<h1>my site</h1>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sheet, err := WriteContactSheet(dir, nil)
+	if err != nil || sheet != filepath.Join(dir, "index-2.html") {
+		t.Fatalf("WriteContactSheet() = %q, %v; want index-2.html", sheet, err)
+	}
+	if data, _ := os.ReadFile(index); string(data) != "<h1>my site</h1>" {
+		t.Errorf("WriteContactSheet() replaced an index.html it did not write: %q", data)
+	}
+
+	// A sheet this tool wrote is refreshed in place
+	os.Remove(index)
+	for i := 0; i < 2; i++ {
+		sheet, err = WriteContactSheet(dir, nil)
+		if err != nil || sheet != index {
+			t.Fatalf("WriteContactSheet() = %q, %v; want %q", sheet, err, index)
+		}
+	}
+	if data, _ := os.ReadFile(sheet); !strings.Contains(string(data), generatorMeta) {
+		t.Errorf("contact sheet is missing %s", generatorMeta)
+	}
+}
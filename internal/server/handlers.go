@@ -0,0 +1,339 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/history"
+	"just-icon/internal/iconset"
+	"just-icon/internal/logging"
+	"just-icon/internal/types"
+)
+
+// defaultHistoryLimit is how many entries GET /v1/history returns by default
+const defaultHistoryLimit = 20
+
+// GenerateRequest is the body of POST /v1/generate. Unset fields come from
+// the preset, then from the configured defaults.
+type GenerateRequest struct {
+	Prompt       string `json:"prompt"`
+	Preset       string `json:"preset,omitempty"`
+	Template     string `json:"template,omitempty"`
+	Size         string `json:"size,omitempty"`
+	Quality      string `json:"quality,omitempty"`
+	Background   string `json:"background,omitempty"`
+	OutputFormat string `json:"output_format,omitempty"`
+	N            int    `json:"n,omitempty"`
+	// Raw sends the prompt without a template
+	Raw bool `json:"raw,omitempty"`
+	// Brand applies the configured brand kit
+	Brand   bool `json:"brand,omitempty"`
+	Enhance bool `json:"enhance,omitempty"`
+	// ResponseFormat is "url" (the default) or "b64_json"
+	ResponseFormat string `json:"response_format,omitempty"`
+	// Wait answers once the job has finished instead of right away
+	Wait bool `json:"wait,omitempty"`
+}
+
+// EditRequest is the body of POST /v1/edit. The prompt is the instruction;
+// the image is a URL the server returned or a base64 upload.
+type EditRequest struct {
+	GenerateRequest
+	Image       string `json:"image,omitempty"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+}
+
+// ExportRequest is the body of POST /v1/export. No targets export every
+// platform.
+type ExportRequest struct {
+	Image          string   `json:"image,omitempty"`
+	ImageBase64    string   `json:"image_base64,omitempty"`
+	Targets        []string `json:"targets,omitempty"`
+	ResponseFormat string   `json:"response_format,omitempty"`
+}
+
+// handleGenerate starts a generation job
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	options, ok := s.options(w, req)
+	if !ok {
+		return
+	}
+	job, err := s.start("generate", options, "", req.Preset, req.ResponseFormat, req.Enhance)
+	if err != nil {
+		writeUnavailable(w, err)
+		return
+	}
+	s.respond(w, r, job, req.Wait)
+}
+
+// handleEdit starts a job that changes an image as the prompt says
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	var req EditRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	// Edits send the instruction as is and never enhance it
+	req.Raw, req.Enhance = true, false
+	base, ok := s.options(w, req.GenerateRequest)
+	if !ok {
+		return
+	}
+	source, uploaded, err := s.source(req.Image, req.ImageBase64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	upload := ""
+	if uploaded {
+		upload = source
+	}
+	options := generator.EditOptions(*base, source, base.Prompt)
+	job, err := s.start("edit", options, upload, req.Preset, req.ResponseFormat, false)
+	if err != nil {
+		if uploaded {
+			os.Remove(upload)
+		}
+		writeUnavailable(w, err)
+		return
+	}
+	s.respond(w, r, job, req.Wait)
+}
+
+// handleExport writes the platform icon sets of an image
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	var req ExportRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if !validFormat(req.ResponseFormat) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("response_format must be %q or %q", FormatURL, FormatBase64))
+		return
+	}
+	targets, err := iconset.ParseTargets(req.Targets)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	source, uploaded, err := s.source(req.Image, req.ImageBase64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if uploaded {
+		defer os.Remove(source)
+	}
+
+	dir := filepath.Join(s.opts.Root, "exports", logging.NewRequestID())
+	if _, err := iconset.Export(source, dir, targets); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	var files []Image
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		file, err := s.image(path, req.ResponseFormat)
+		files = append(files, file)
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"files": files})
+}
+
+// handleJobs lists the jobs, newest first. Images are listed by URL only;
+// base64 comes with the single job.
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ids := append([]string(nil), s.order...)
+	s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		// Jobs may have been pruned since the IDs were copied
+		if job, ok := s.find(ids[i]); ok {
+			jobs = append(jobs, s.snapshot(job))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+// handleJob shows a job
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.find(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.detail(job))
+}
+
+// handleHistory lists recent history entries, newest first
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	limit := defaultHistoryLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "limit must be a non-negative number")
+			return
+		}
+		limit = n
+	}
+	entries, err := history.DefaultStore().Recent(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+}
+
+// handleHistoryEntry shows one history entry by ID, ID prefix or position
+func (s *Server) handleHistoryEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := history.DefaultStore().Find(r.PathValue("ref"))
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		writeError(w, http.StatusNotFound, "history entry not found")
+	case errors.Is(err, history.ErrAmbiguous):
+		writeError(w, http.StatusConflict, "history reference matches more than one entry")
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, entry)
+	}
+}
+
+// handleFile serves a file below the server's root
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	path, ok := s.resolve(r.PathValue("path"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	http.ServeFile(w, r, path)
+}
+
+// respond answers with the job: at once with 202 Accepted, or with 200 once
+// it has finished when the client waits
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, job *Job, wait bool) {
+	if !wait {
+		w.Header().Set("Location", "/v1/jobs/"+job.ID)
+		writeJSON(w, http.StatusAccepted, s.snapshot(job))
+		return
+	}
+	select {
+	case <-job.done:
+		writeJSON(w, http.StatusOK, s.detail(job))
+	case <-r.Context().Done():
+		// The client went away; the job keeps running
+	}
+}
+
+// options builds the generation options of a request and validates them
+// the way the client will, answering errors itself
+func (s *Server) options(w http.ResponseWriter, req GenerateRequest) (*types.IconGenerationOptions, bool) {
+	if !validFormat(req.ResponseFormat) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("response_format must be %q or %q", FormatURL, FormatBase64))
+		return nil, false
+	}
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	options, err := generator.OptionsFor(cfg, generator.Settings{
+		Prompt:       req.Prompt,
+		Preset:       req.Preset,
+		Template:     req.Template,
+		Size:         req.Size,
+		Quality:      req.Quality,
+		Background:   req.Background,
+		OutputFormat: req.OutputFormat,
+		NumImages:    req.N,
+		Raw:          req.Raw,
+		Brand:        req.Brand,
+	})
+	if err == nil {
+		err = s.validate(options)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return options, true
+}
+
+// source returns the image a request refers to: a file the server returned,
+// by its URL, or a base64 upload saved below the root. Uploads are reported
+// so the caller can remove them once they are no longer needed.
+func (s *Server) source(ref, encoded string) (string, bool, error) {
+	switch {
+	case ref != "" && encoded != "":
+		return "", false, errors.New("give either image or image_base64, not both")
+	case ref != "":
+		if i := strings.Index(ref, filesPrefix); i >= 0 {
+			ref = ref[i+len(filesPrefix):]
+		}
+		path, ok := s.resolve(ref)
+		if !ok {
+			return "", false, fmt.Errorf("image %q not found on this server", ref)
+		}
+		return path, false, nil
+	case encoded != "":
+		// Accept data URLs as well as plain base64
+		if _, data, ok := strings.Cut(encoded, ";base64,"); ok {
+			encoded = data
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid image_base64: %w", err)
+		}
+		ext := map[string]string{"image/png": ".png", "image/jpeg": ".jpeg", "image/webp": ".webp"}[http.DetectContentType(data)]
+		if ext == "" {
+			return "", false, errors.New("image_base64 must be a PNG, JPEG or WebP image")
+		}
+		path := filepath.Join(s.opts.Root, "uploads", logging.NewRequestID()+ext)
+		if err := os.MkdirAll(filepath.Dir(path), types.ConfigDirPerm); err != nil {
+			return "", false, err
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			os.Remove(path)
+			return "", false, err
+		}
+		return path, true, nil
+	}
+	return "", false, errors.New("image or image_base64 is required")
+}
+
+// resolve maps a path relative to the root to a regular file inside it
+func (s *Server) resolve(rel string) (string, bool) {
+	path := filepath.Join(s.opts.Root, filepath.FromSlash(rel))
+	inside, err := filepath.Rel(s.opts.Root, path)
+	if err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return path, true
+}
+
+// validFormat reports whether a response format is known; empty means URL
+func validFormat(format string) bool {
+	return format == "" || format == FormatURL || format == FormatBase64
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/logging"
+	"just-icon/internal/openai"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// Job states
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// errQueueFull rejects jobs while maxPendingJobs are queued or running
+var errQueueFull = errors.New("too many jobs are queued; try again later")
+
+// Response formats for images
+const (
+	FormatURL    = "url"
+	FormatBase64 = "b64_json"
+)
+
+// Job is a generation or edit running in the background
+type Job struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	// Progress describes the running request, e.g. "running 12s · about 20s left"
+	Progress  string     `json:"progress,omitempty"`
+	Created   time.Time  `json:"created_at"`
+	Finished  *time.Time `json:"finished_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Images    []Image    `json:"images,omitempty"`
+	HistoryID string     `json:"history_id,omitempty"`
+	CostUSD   float64    `json:"cost_usd,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+
+	options *types.IconGenerationOptions
+	// upload is an image sent with the request, removed once the job ends
+	upload  string
+	preset  string
+	format  string
+	enhance bool
+	tracker *progress.Tracker
+	done    chan struct{}
+}
+
+// Image is a saved image of a job. URL is served by the server; B64JSON is
+// only set when the request asked for base64, and is read from disk for
+// every response rather than kept with the job.
+type Image struct {
+	URL     string `json:"url"`
+	B64JSON string `json:"b64_json,omitempty"`
+
+	path string
+}
+
+// start queues a job and runs it once a slot is free. It fails with
+// errQueueFull when maxPendingJobs are already waiting or running.
+func (s *Server) start(kind string, options *types.IconGenerationOptions, upload, preset, format string, enhance bool) (*Job, error) {
+	job := &Job{
+		ID:      logging.NewRequestID(),
+		Kind:    kind,
+		Status:  JobQueued,
+		Created: time.Now(),
+		options: options,
+		upload:  upload,
+		preset:  preset,
+		format:  format,
+		enhance: enhance,
+		tracker: progress.NewTracker([]string{""}, s.estimate(options)),
+		done:    make(chan struct{}),
+	}
+	options.Output = filepath.Join(s.opts.Root, job.ID)
+
+	s.mu.Lock()
+	pending := 0
+	for _, id := range s.order {
+		if s.jobs[id].Finished == nil {
+			pending++
+		}
+	}
+	if pending >= maxPendingJobs {
+		s.mu.Unlock()
+		return nil, errQueueFull
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.prune()
+	s.mu.Unlock()
+
+	go s.run(job)
+	return job, nil
+}
+
+// run waits for a slot, generates and records the outcome in the job
+func (s *Server) run(job *Job) {
+	defer close(job.done)
+	if job.upload != "" {
+		defer os.Remove(job.upload)
+	}
+	select {
+	case s.slots <- struct{}{}:
+	case <-s.ctx.Done():
+		s.fail(job, s.ctx.Err())
+		return
+	}
+	defer func() { <-s.slots }()
+	s.update(job, func(job *Job) { job.Status = JobRunning })
+
+	log := logging.Logger().With("job", job.ID, "kind", job.Kind)
+	log.Info("job started")
+
+	if job.enhance {
+		enhanced, err := s.enhance(s.ctx, job.options.Prompt)
+		if err != nil {
+			s.fail(job, fmt.Errorf("prompt enhancement failed: %w", err))
+			return
+		}
+		job.options.OriginalPrompt, job.options.Prompt = job.options.Prompt, enhanced
+	}
+
+	outcome, err := s.generate(s.ctx, job.options, job.preset, job.tracker.Reporter(0))
+	if err != nil {
+		s.fail(job, err)
+		return
+	}
+
+	images := make([]Image, 0, len(outcome.Files))
+	for _, file := range outcome.Files {
+		image, err := s.image(file, FormatURL)
+		if err != nil {
+			s.fail(job, err)
+			return
+		}
+		images = append(images, image)
+	}
+	s.update(job, func(job *Job) {
+		job.Status = JobSucceeded
+		job.Images = images
+		job.HistoryID = outcome.HistoryID
+		job.Warnings = outcome.Warnings
+		if outcome.Result != nil {
+			job.CostUSD = outcome.Result.Cost
+		}
+		now := time.Now()
+		job.Finished = &now
+	})
+	log.Info("job succeeded", "images", len(images))
+}
+
+// fail marks a job as failed. Image URLs in the error are often pre-signed,
+// so their queries are removed.
+func (s *Server) fail(job *Job, err error) {
+	s.update(job, func(job *Job) {
+		job.Status = JobFailed
+		job.Error = logging.StripURLQueries(err.Error())
+		now := time.Now()
+		job.Finished = &now
+	})
+	logging.Logger().Warn("job failed", "job", job.ID, "error", err.Error())
+}
+
+// update changes a job under the lock
+func (s *Server) update(job *Job, change func(*Job)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(job)
+}
+
+// snapshot returns a copy of the job with its current progress
+func (s *Server) snapshot(job *Job) Job {
+	s.mu.Lock()
+	view := *job
+	s.mu.Unlock()
+	if view.Status == JobRunning {
+		view.Progress = job.tracker.Line(0, time.Now())
+	}
+	return view
+}
+
+// detail returns a snapshot of the job with its images in base64 when the
+// request asked for it. Images that are gone from disk keep only their URL.
+func (s *Server) detail(job *Job) Job {
+	view := s.snapshot(job)
+	if view.format != FormatBase64 {
+		return view
+	}
+	view.Images = append([]Image(nil), view.Images...)
+	for i := range view.Images {
+		if image, err := s.image(view.Images[i].path, FormatBase64); err == nil {
+			view.Images[i] = image
+		}
+	}
+	return view
+}
+
+// prune forgets the oldest finished jobs beyond maxFinishedJobs; their
+// images stay on disk. Callers hold the lock.
+func (s *Server) prune() {
+	finished := 0
+	for _, id := range s.order {
+		if s.jobs[id].Finished != nil {
+			finished++
+		}
+	}
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if finished > maxFinishedJobs && s.jobs[id].Finished != nil {
+			delete(s.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+// find returns the job with the ID
+func (s *Server) find(id string) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	return job, ok
+}
+
+// image describes a saved file for the response
+func (s *Server) image(path, format string) (Image, error) {
+	rel, err := filepath.Rel(s.opts.Root, path)
+	if err != nil {
+		return Image{}, err
+	}
+	image := Image{URL: filesPrefix + filepath.ToSlash(rel), path: path}
+	if format == FormatBase64 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Image{}, err
+		}
+		image.B64JSON = base64.StdEncoding.EncodeToString(data)
+	}
+	return image, nil
+}
+
+// enhancePrompt rewrites a prompt with the configured chat model
+func enhancePrompt(ctx context.Context, prompt string) (string, error) {
+	cfg, err := config.DefaultService.GetConfig()
+	if err != nil {
+		return "", err
+	}
+	client, err := openai.NewClientFromConfig()
+	if err != nil {
+		return "", err
+	}
+	return client.EnhancePrompt(ctx, prompt, cfg.Enhance)
+}
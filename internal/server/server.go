@@ -0,0 +1,193 @@
+// Package server exposes generation, editing, export and history over a
+// small REST API for other tools to call just-icon as a service.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"just-icon/internal/generator"
+	"just-icon/internal/logging"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+const (
+	// DefaultAddr listens on the loopback interface only
+	DefaultAddr = "127.0.0.1:8080"
+	// DefaultMaxBodyMB bounds request bodies, which carry base64 images
+	DefaultMaxBodyMB = 8
+	// DefaultConcurrency is how many generations run at once
+	DefaultConcurrency = 2
+	// TokenEnv holds the bearer token when --token is not given
+	TokenEnv = "JUST_ICON_TOKEN"
+	// filesPrefix is where saved images are served
+	filesPrefix = "/v1/files/"
+)
+
+// Options configure a server
+type Options struct {
+	// Token is the bearer token every API request must carry
+	Token string
+	// Root holds the images of every job, one directory per job
+	Root string
+	// MaxBodyBytes bounds request bodies; zero uses DefaultMaxBodyMB
+	MaxBodyBytes int64
+	// Concurrency bounds the generations running at once; zero uses
+	// DefaultConcurrency
+	Concurrency int
+}
+
+// Job limits; tests lower them
+var (
+	// maxFinishedJobs bounds the finished jobs kept in memory
+	maxFinishedJobs = 200
+	// maxPendingJobs bounds the queued and running jobs; more are rejected
+	// until some finish
+	maxPendingJobs = 50
+)
+
+// Server handles the API. Jobs are kept in memory, up to maxPendingJobs
+// unfinished and maxFinishedJobs finished ones.
+type Server struct {
+	opts  Options
+	slots chan struct{}
+	// ctx is canceled by Close to stop the running jobs
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	// order lists job IDs oldest first
+	order []string
+
+	// generate, estimate, enhance and validate do the work; tests replace
+	// them
+	generate func(context.Context, *types.IconGenerationOptions, string, *progress.Reporter) (*generator.Outcome, error)
+	estimate func(*types.IconGenerationOptions) time.Duration
+	enhance  func(ctx context.Context, prompt string) (string, error)
+	validate func(*types.IconGenerationOptions) error
+}
+
+// New creates a server
+func New(opts Options) *Server {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyMB << 20
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		opts:     opts,
+		slots:    make(chan struct{}, opts.Concurrency),
+		ctx:      ctx,
+		cancel:   cancel,
+		jobs:     make(map[string]*Job),
+		generate: generator.Run,
+		estimate: generator.EstimateDuration,
+		enhance:  enhancePrompt,
+		validate: generator.Validate,
+	}
+}
+
+// Close cancels the queued and running jobs; they fail with the
+// cancellation error
+func (s *Server) Close() {
+	s.cancel()
+}
+
+// Handler returns the API routes behind the token guard and body limit.
+// Only /healthz answers without a token.
+func (s *Server) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("POST /v1/generate", s.handleGenerate)
+	api.HandleFunc("POST /v1/edit", s.handleEdit)
+	api.HandleFunc("POST /v1/export", s.handleExport)
+	api.HandleFunc("GET /v1/jobs", s.handleJobs)
+	api.HandleFunc("GET /v1/jobs/{id}", s.handleJob)
+	api.HandleFunc("GET /v1/history", s.handleHistory)
+	api.HandleFunc("GET /v1/history/{ref}", s.handleHistoryEntry)
+	api.HandleFunc("GET "+filesPrefix+"{path...}", s.handleFile)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.Handle("/", s.guard(api))
+	return mux
+}
+
+// guard rejects requests without the bearer token and bounds their bodies
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="just-icon"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		if r.ContentLength > s.opts.MaxBodyBytes {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+		logging.Logger().Info("api request", "method", r.Method, "path", r.URL.Path)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewToken returns a random bearer token
+func NewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// decode reads a JSON request body, answering the error itself. It reports
+// whether the body was read.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return false
+		}
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON answers with v as JSON
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// writeUnavailable answers that a job was not accepted because the queue
+// is full
+func writeUnavailable(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", "30")
+	writeError(w, http.StatusServiceUnavailable, err.Error())
+}
+
+// writeError answers with {"error": {"message": ...}}
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]string{"message": message}})
+}
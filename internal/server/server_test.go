@@ -0,0 +1,276 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"just-icon/internal/config"
+	"just-icon/internal/generator"
+	"just-icon/internal/progress"
+	"just-icon/internal/types"
+)
+
+// testServer starts a server whose generations write a small PNG and
+// records the options they got
+func testServer(t *testing.T, opts Options) (*httptest.Server, *[]types.IconGenerationOptions) {
+	t.Helper()
+	dir := t.TempDir()
+	service := config.DefaultService
+	config.DefaultService = config.NewServiceWithPath(filepath.Join(dir, config.ConfigFileName))
+	t.Cleanup(func() { config.DefaultService = service })
+	// Options are checked by a client, which needs a key
+	if err := config.DefaultService.SetAPIKey("sk-test-1234567890"); err != nil {
+		t.Fatal(err)
+	}
+
+	icon := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for i := range icon.Pix {
+		icon.Pix[i] = 0xff
+	}
+	icon.Set(4, 4, color.NRGBA{R: 0xff, A: 0xff})
+	var encoded bytes.Buffer
+	png.Encode(&encoded, icon)
+
+	opts.Token = "secret-token"
+	opts.Root = filepath.Join(dir, "serve")
+	s := New(opts)
+	t.Cleanup(s.Close)
+	var mu sync.Mutex
+	var calls []types.IconGenerationOptions
+	s.estimate = func(*types.IconGenerationOptions) time.Duration { return 0 }
+	s.generate = func(ctx context.Context, options *types.IconGenerationOptions, preset string, reporter *progress.Reporter) (*generator.Outcome, error) {
+		mu.Lock()
+		calls = append(calls, *options)
+		mu.Unlock()
+		switch options.Prompt {
+		case "expired":
+			return nil, errors.New("failed to download image from URL https://cdn.example.com/icon.png?sig=s3cr3t: HTTP 403")
+		case "slow":
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		if err := os.MkdirAll(options.Output, 0755); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(options.Output, "icon-1.png")
+		if err := os.WriteFile(path, encoded.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+		return &generator.Outcome{Result: &types.GenerationResult{Cost: 0.01}, Files: []string{path}, HistoryID: "abc12345"}, nil
+	}
+
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// call sends a request with the token and decodes the JSON answer
+func call(t *testing.T, server *httptest.Server, method, path, body string, out any) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp
+}
+
+func TestServerAuth(t *testing.T) {
+	server, _ := testServer(t, Options{})
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("healthz = %v, %v", resp, err)
+	}
+	for _, header := range []string{"", "Bearer wrong", "secret-token"} {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/jobs", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q answered %d, want 401", header, resp.StatusCode)
+		}
+	}
+}
+
+func TestServerGenerate(t *testing.T) {
+	server, calls := testServer(t, Options{})
+
+	var job Job
+	resp := call(t, server, "POST", "/v1/generate", `{"prompt":"rocket","quality":"high","n":2,"wait":true}`, &job)
+	if resp.StatusCode != http.StatusOK || job.Status != JobSucceeded || len(job.Images) != 1 || job.HistoryID != "abc12345" {
+		t.Fatalf("generate answered %d with %+v", resp.StatusCode, job)
+	}
+	got := (*calls)[0]
+	if got.Prompt != "rocket" || got.Quality != "high" || got.NumImages != 2 || got.Template == "" {
+		t.Errorf("generation options = %+v", got)
+	}
+
+	// The image URL serves the saved file
+	req, _ := http.NewRequest("GET", server.URL+job.Images[0].URL, nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	fileResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(fileResp.Body)
+	fileResp.Body.Close()
+	if fileResp.StatusCode != http.StatusOK || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("image URL answered %d with %d bytes", fileResp.StatusCode, len(data))
+	}
+
+	// Without waiting the job is returned at once and can be polled
+	resp = call(t, server, "POST", "/v1/generate", `{"prompt":"plane","response_format":"b64_json"}`, &job)
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("Location") != "/v1/jobs/"+job.ID {
+		t.Fatalf("async generate answered %d, Location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	for deadline := time.Now().Add(5 * time.Second); job.Status != JobSucceeded && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		call(t, server, "GET", "/v1/jobs/"+job.ID, "", &job)
+	}
+	if job.Status != JobSucceeded || job.Images[0].B64JSON == "" {
+		t.Errorf("polled job = %+v, want succeeded with base64", job)
+	}
+
+	var list struct{ Jobs []Job }
+	call(t, server, "GET", "/v1/jobs", "", &list)
+	if len(list.Jobs) != 2 || list.Jobs[0].ID != job.ID {
+		t.Errorf("jobs = %+v, want both, newest first", list.Jobs)
+	}
+}
+
+func TestServerForgetsOldJobs(t *testing.T) {
+	maxFinishedJobs = 2
+	defer func() { maxFinishedJobs = 200 }()
+	server, _ := testServer(t, Options{})
+
+	var first Job
+	call(t, server, "POST", "/v1/generate", `{"prompt":"expired","wait":true}`, &first)
+	if first.Status != JobFailed || strings.Contains(first.Error, "s3cr3t") || !strings.Contains(first.Error, "https://cdn.example.com/icon.png") {
+		t.Errorf("failed job = %+v, want the error without the URL query", first)
+	}
+	for _, prompt := range []string{"rocket", "plane", "boat"} {
+		call(t, server, "POST", "/v1/generate", `{"prompt":"`+prompt+`","wait":true}`, nil)
+	}
+
+	var list struct{ Jobs []Job }
+	call(t, server, "GET", "/v1/jobs", "", &list)
+	if len(list.Jobs) != 3 {
+		t.Errorf("kept %d jobs, want 3", len(list.Jobs))
+	}
+	if resp := call(t, server, "GET", "/v1/jobs/"+first.ID, "", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("oldest job answered %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestServerRejectsJobsWhenFull(t *testing.T) {
+	maxPendingJobs = 1
+	defer func() { maxPendingJobs = 50 }()
+	server, calls := testServer(t, Options{})
+
+	if resp := call(t, server, "POST", "/v1/generate", `{"prompt":"slow"}`, nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("first job answered %d, want 202", resp.StatusCode)
+	}
+	resp := call(t, server, "POST", "/v1/generate", `{"prompt":"rocket"}`, nil)
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("job beyond the limit answered %d, want 503 with Retry-After", resp.StatusCode)
+	}
+	for _, call := range *calls {
+		if call.Prompt == "rocket" {
+			t.Error("rejected job was generated")
+		}
+	}
+}
+
+func TestServerBadRequests(t *testing.T) {
+	server, _ := testServer(t, Options{MaxBodyBytes: 256})
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"missing prompt", "POST", "/v1/generate", `{"prompt":" "}`, http.StatusBadRequest},
+		{"unknown field", "POST", "/v1/generate", `{"prompt":"a","colour":"red"}`, http.StatusBadRequest},
+		{"unknown preset", "POST", "/v1/generate", `{"prompt":"a","preset":"nope"}`, http.StatusBadRequest},
+		{"bad format", "POST", "/v1/generate", `{"prompt":"a","response_format":"png"}`, http.StatusBadRequest},
+		{"brand without kit", "POST", "/v1/generate", `{"prompt":"a","brand":true}`, http.StatusBadRequest},
+		{"bad size", "POST", "/v1/generate", `{"prompt":"a","size":"huge"}`, http.StatusBadRequest},
+		{"bad quality", "POST", "/v1/generate", `{"prompt":"a","quality":"best"}`, http.StatusBadRequest},
+		{"bad background", "POST", "/v1/generate", `{"prompt":"a","background":"plaid"}`, http.StatusBadRequest},
+		{"bad output format", "POST", "/v1/generate", `{"prompt":"a","output_format":"gif"}`, http.StatusBadRequest},
+		{"too many images", "POST", "/v1/generate", `{"prompt":"a","n":11}`, http.StatusBadRequest},
+		{"too large", "POST", "/v1/generate", `{"prompt":"` + strings.Repeat("a", 300) + `"}`, http.StatusRequestEntityTooLarge},
+		{"edit without image", "POST", "/v1/edit", `{"prompt":"make it red"}`, http.StatusBadRequest},
+		{"file outside root", "GET", "/v1/files/../just-icon.json", "", http.StatusNotFound},
+		{"unknown job", "GET", "/v1/jobs/nope", "", http.StatusNotFound},
+		{"unknown history entry", "GET", "/v1/history/nope", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := call(t, server, tt.method, tt.path, tt.body, nil); resp.StatusCode != tt.want {
+				t.Errorf("answered %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerEditAndExport(t *testing.T) {
+	server, calls := testServer(t, Options{})
+
+	var job Job
+	call(t, server, "POST", "/v1/generate", `{"prompt":"rocket","wait":true}`, &job)
+	call(t, server, "POST", "/v1/edit", `{"prompt":"make it red","image":"`+job.Images[0].URL+`","wait":true}`, &job)
+	edit := (*calls)[1]
+	if job.Status != JobSucceeded || edit.Prompt != "make it red" || !edit.RawPrompt || !strings.HasSuffix(edit.Reference, "icon-1.png") {
+		t.Errorf("edit job %+v ran with %+v", job, edit)
+	}
+
+	// Export an uploaded image for one platform
+	icon := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	var encoded bytes.Buffer
+	png.Encode(&encoded, icon)
+	var exported struct{ Files []Image }
+	body := `{"image_base64":"data:image/png;base64,` + base64.StdEncoding.EncodeToString(encoded.Bytes()) + `","targets":["ios"]}`
+	if resp := call(t, server, "POST", "/v1/export", body, &exported); resp.StatusCode != http.StatusOK {
+		t.Fatalf("export answered %d", resp.StatusCode)
+	}
+	if len(exported.Files) != 2 || !strings.HasSuffix(exported.Files[0].URL, "/ios/AppIcon.appiconset/AppIcon-1024.png") {
+		t.Errorf("exported files = %+v", exported.Files)
+	}
+
+	// Uploads are removed once the job is done with them
+	upload := base64.StdEncoding.EncodeToString(encoded.Bytes())
+	call(t, server, "POST", "/v1/edit", `{"prompt":"make it blue","image_base64":"`+upload+`","wait":true}`, &job)
+	uploaded := (*calls)[len(*calls)-1].Reference
+	if job.Status != JobSucceeded || !strings.Contains(uploaded, "uploads") {
+		t.Fatalf("edit of an upload = %+v with reference %q", job, uploaded)
+	}
+	entries, err := os.ReadDir(filepath.Dir(uploaded))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("uploads left behind: %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,262 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"just-icon/internal/i18n"
+	"just-icon/internal/types"
+)
+
+// Built-in template names
+const (
+	IOS             = "ios"
+	AndroidMaterial = "android-material"
+	MacOS           = "macos"
+	FlatWeb         = "flat-web"
+	FaviconGlyph    = "favicon-glyph"
+	LineIcon        = "line-icon"
+	Raw             = "raw"
+
+	// Default is used when neither the caller nor the config selects a template
+	Default = IOS
+)
+
+// Platform values exposed to templates as {{.Platform}}
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformMacOS   = "macos"
+	PlatformWeb     = "web"
+)
+
+// brandClause appends the brand kit constraints that are set
+const brandClause = `{{if .Reference}} Match the style, shapes and colors of the attached brand logo without copying it.{{end}}` +
+	`{{if .Palette}} Use only this color palette: {{.Palette}}.{{end}}` +
+	`{{if .Avoid}} Never use these colors: {{.Avoid}}.{{end}}` +
+	`{{if .Mood}} Mood: {{.Mood}}.{{end}}` +
+	`{{if .Typography}} Any lettering must use {{.Typography}}.{{end}}`
+
+// Template is a named prompt template
+type Template struct {
+	Name string
+	types.PromptTemplate
+	BuiltIn bool
+}
+
+// Vars are the values available to a template
+type Vars struct {
+	// Prompt is the user's icon description
+	Prompt string
+	// Size is the requested image size, e.g. 1024x1024
+	Size string
+	// Platform is the target platform declared by the template
+	Platform string
+	// Palette lists the brand colors, e.g. "primary #1E88E5, accent #FFC107"
+	Palette string
+	// Avoid lists the forbidden brand colors
+	Avoid string
+	// Mood lists the brand mood keywords
+	Mood string
+	// Typography describes the brand lettering
+	Typography string
+	// Reference is true when a brand logo is attached to the request
+	Reference bool
+}
+
+var builtIns = []Template{
+	{
+		Name: IOS,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformIOS,
+			Text:     "Create a full-bleed {{.Size}} px iOS app icon: {{.Prompt}}. Use crisp, minimal design with vibrant colors. Add a subtle inner bevel for gentle depth; no hard shadows or outlines. Center the design with comfortable breathing room from the edges. Solid, light-neutral background. IMPORTANT: Fill the entire canvas edge-to-edge with the design, no padding, no margins. Design elements should be centered with appropriate spacing from edges but the background must cover 100% of the canvas. Add subtle depth with inner highlights, avoid hard shadows. Clean, minimal, Apple-style design. No borders, frames, or rounded corners." + brandClause,
+		},
+	},
+	{
+		Name: AndroidMaterial,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformAndroid,
+			Text:     "Create a {{.Size}} px Android adaptive app icon in Material Design 3 style: {{.Prompt}}. Bold, simple foreground symbol centered inside the inner two-thirds safe zone so it survives circle, squircle and rounded-square masks. Flat shapes with soft material shadows and harmonious tonal colors. The background layer must fill the entire canvas edge-to-edge with a solid color or gentle gradient. No text, borders, frames, or rounded corners." + brandClause,
+		},
+	},
+	{
+		Name: MacOS,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformMacOS,
+			Text:     "Create a {{.Size}} px macOS app icon: {{.Prompt}}. Modern macOS style: a rounded-square base with realistic lighting, rich material detail and a soft drop shadow beneath it. Slight depth and dimensionality, polished and tactile. Keep the shape centered with an even margin on a transparent or plain background. No text." + brandClause,
+		},
+	},
+	{
+		Name: FlatWeb,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformWeb,
+			Text:     "Create a {{.Size}} px flat web icon: {{.Prompt}}. Flat design with two to four solid colors; no gradients, shadows, bevels or textures. Simple geometric shapes, centered with generous padding on a plain background. Suitable for websites, dashboards and documentation. No text." + brandClause,
+		},
+	},
+	{
+		Name: FaviconGlyph,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformWeb,
+			Text:     "Create a {{.Size}} px favicon glyph: {{.Prompt}}. A single bold, instantly recognizable glyph that stays legible when scaled down to 16x16 px. Thick shapes, high contrast, at most two colors and no fine detail. Centered and filling most of the canvas on a plain background. No text." + brandClause,
+		},
+	},
+	{
+		Name: LineIcon,
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformWeb,
+			Text:     "Create a {{.Size}} px line icon: {{.Prompt}}. Monoline outline style with a uniform stroke weight, rounded caps and joins, and no fills. Single color on a plain background, centered with even padding, consistent with a UI icon set." + brandClause,
+		},
+	},
+	{
+		Name: Raw,
+		PromptTemplate: types.PromptTemplate{
+			Text: "{{.Prompt}}",
+		},
+	},
+}
+
+// nameRegexp matches names of templates and other saved settings
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// BuiltIns returns the templates shipped with the application
+func BuiltIns() []Template {
+	result := make([]Template, len(builtIns))
+	for i, t := range builtIns {
+		t.BuiltIn = true
+		result[i] = t
+	}
+	return result
+}
+
+// IsBuiltIn reports whether name is a built-in template
+func IsBuiltIn(name string) bool {
+	for _, t := range builtIns {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup finds a template by name. Custom templates take precedence over
+// built-ins with the same name.
+func Lookup(name string, custom map[string]*types.PromptTemplate) (Template, bool) {
+	if t, ok := custom[name]; ok && t != nil {
+		return Template{Name: name, PromptTemplate: *t}, true
+	}
+	for _, t := range BuiltIns() {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// List returns the built-in templates in their shipped order, replaced by any
+// custom override, followed by the remaining custom templates sorted by name
+func List(custom map[string]*types.PromptTemplate) []Template {
+	var result []Template
+	for _, t := range BuiltIns() {
+		if override, ok := Lookup(t.Name, custom); ok {
+			t = override
+		}
+		result = append(result, t)
+	}
+
+	var names []string
+	for name, t := range custom {
+		if t != nil && !IsBuiltIn(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, Template{Name: name, PromptTemplate: *custom[name]})
+	}
+	return result
+}
+
+// Names returns the names of all available templates
+func Names(custom map[string]*types.PromptTemplate) []string {
+	var names []string
+	for _, t := range List(custom) {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Describe returns a human readable description of the template
+func (t Template) Describe() string {
+	if t.BuiltIn {
+		return i18n.T("template_desc_" + strings.ReplaceAll(t.Name, "-", "_"))
+	}
+	return t.Description
+}
+
+// ValidateName checks that a template name is usable on the command line
+func ValidateName(name string) error {
+	return CheckName("template", name)
+}
+
+// CheckName checks that the name of a saved setting is usable on the command
+// line; kind, such as "template" or "preset", describes it in the error
+func CheckName(kind, name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q: use lowercase letters, digits, '-' and '_'", kind, name)
+	}
+	return nil
+}
+
+// Validate checks that text parses, only uses known variables and includes
+// the user's prompt
+func Validate(text string) error {
+	const marker = "\x00prompt\x00"
+	rendered, err := render(text, Vars{
+		Prompt:     marker,
+		Size:       types.DefaultValues.Size,
+		Platform:   PlatformIOS,
+		Palette:    "primary #000000",
+		Avoid:      "#FF0000",
+		Mood:       "calm",
+		Typography: "sans-serif",
+		Reference:  true,
+	})
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(rendered, marker) {
+		return fmt.Errorf("template must include {{.Prompt}}")
+	}
+	return nil
+}
+
+// Render executes the template with vars. The template's platform is used
+// when vars does not set one.
+func Render(t Template, vars Vars) (string, error) {
+	if vars.Platform == "" {
+		vars.Platform = t.Platform
+	}
+	rendered, err := render(t.Text, vars)
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", t.Name, err)
+	}
+	return strings.TrimSpace(rendered), nil
+}
+
+// render parses and executes text, failing on unknown variables
+func render(text string, vars Vars) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
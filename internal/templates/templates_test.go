@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	"just-icon/internal/types"
+)
+
+func TestRenderIOSMatchesLegacyWrapper(t *testing.T) {
+	template, ok := Lookup(IOS, nil)
+	if !ok {
+		t.Fatal("built-in ios template not found")
+	}
+
+	rendered, err := Render(template, Vars{Prompt: "weather app", Size: "1024x1024"})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+
+	expected := "Create a full-bleed 1024x1024 px iOS app icon: weather app. Use crisp, minimal design with vibrant colors. Add a subtle inner bevel for gentle depth; no hard shadows or outlines. Center the design with comfortable breathing room from the edges. Solid, light-neutral background. IMPORTANT: Fill the entire canvas edge-to-edge with the design, no padding, no margins. Design elements should be centered with appropriate spacing from edges but the background must cover 100% of the canvas. Add subtle depth with inner highlights, avoid hard shadows. Clean, minimal, Apple-style design. No borders, frames, or rounded corners."
+	if rendered != expected {
+		t.Errorf("unexpected ios prompt:\n%s", rendered)
+	}
+}
+
+func TestRenderVariables(t *testing.T) {
+	template := Template{
+		Name: "custom",
+		PromptTemplate: types.PromptTemplate{
+			Platform: PlatformAndroid,
+			Text:     "{{.Platform}} {{.Size}} {{.Prompt}}{{if .Palette}} [{{.Palette}}]{{end}}",
+		},
+	}
+
+	rendered, err := Render(template, Vars{Prompt: "cat", Size: "1024x1024", Palette: "#fff, #000"})
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if rendered != "android 1024x1024 cat [#fff, #000]" {
+		t.Errorf("unexpected rendering: %q", rendered)
+	}
+}
+
+func TestBuiltInsRender(t *testing.T) {
+	for _, template := range BuiltIns() {
+		if err := Validate(template.Text); err != nil {
+			t.Errorf("%s: %v", template.Name, err)
+		}
+	}
+
+	raw, _ := Lookup(Raw, nil)
+	rendered, err := Render(raw, Vars{Prompt: "exactly this", Palette: "#fff"})
+	if err != nil || rendered != "exactly this" {
+		t.Errorf("raw template changed the prompt: %q, %v", rendered, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{"valid", "Icon of {{.Prompt}} at {{.Size}}", false},
+		{"missing prompt", "Icon at {{.Size}}", true},
+		{"unknown variable", "{{.Prompt}} {{.Color}}", true},
+		{"parse error", "{{.Prompt}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.text); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	for _, name := range []string{"team", "app-icon_2", "9lives"} {
+		if err := CheckName("preset", name); err != nil {
+			t.Errorf("CheckName(%q) error = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-team", "Team", "my team", "team/icons"} {
+		err := CheckName("preset", name)
+		if err == nil || !strings.Contains(err.Error(), "invalid preset name") {
+			t.Errorf("CheckName(%q) error = %v, want an invalid preset name", name, err)
+		}
+	}
+}
+
+func TestLookupAndList(t *testing.T) {
+	custom := map[string]*types.PromptTemplate{
+		IOS:      {Text: "my ios {{.Prompt}}"},
+		"zebra":  {Text: "{{.Prompt}} z"},
+		"banner": {Text: "{{.Prompt}} b"},
+	}
+
+	template, ok := Lookup(IOS, custom)
+	if !ok || template.BuiltIn || !strings.HasPrefix(template.Text, "my ios") {
+		t.Errorf("expected custom override for ios, got %+v", template)
+	}
+
+	if _, ok := Lookup("missing", custom); ok {
+		t.Error("expected missing template lookup to fail")
+	}
+
+	names := Names(custom)
+	expected := []string{IOS, AndroidMaterial, MacOS, FlatWeb, FaviconGlyph, LineIcon, Raw, "banner", "zebra"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected template order: %v", names)
+	}
+}
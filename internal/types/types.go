@@ -1,5 +1,10 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Constants for the application
 const (
 	// Model constants
@@ -19,6 +24,24 @@ const (
 	// API constants
 	DefaultBaseURL = "https://api.katonai.dev"
 	APIVersion     = "/v1"
+
+	// Provider constants
+	ProviderOpenAI = "openai"
+	ProviderAzure  = "azure"
+
+	// Azure OpenAI constants
+	AzureAuthKey           = "key"
+	AzureAuthAAD           = "aad"
+	DefaultAzureAPIVersion = "2025-04-01-preview"
+	AzureAPIKeyEnv         = "AZURE_OPENAI_API_KEY"
+	AzureADTokenEnv        = "AZURE_OPENAI_AD_TOKEN"
+
+	// Prompt enhancement constants
+	DefaultEnhanceModel = "gpt-4o-mini"
+
+	// Request log constants
+	DefaultLogMaxSizeMB = 5
+	DefaultLogMaxFiles  = 3
 	
 	// File constants
 	ConfigDirPerm  = 0755
@@ -43,11 +66,146 @@ const (
 
 // Config represents the application configuration
 type Config struct {
-	OpenAIAPIKey       string `json:"openai_api_key,omitempty"`
-	BaseURL            string `json:"base_url,omitempty"`
-	DefaultOutputPath  string `json:"default_output_path,omitempty"`
-	Language           string `json:"language,omitempty"`
-	Initialized        bool   `json:"initialized"`
+	OpenAIAPIKey      string       `json:"openai_api_key,omitempty"`
+	BaseURL           string       `json:"base_url,omitempty"`
+	DefaultOutputPath string       `json:"default_output_path,omitempty"`
+	Language          string       `json:"language,omitempty"`
+	Provider          string       `json:"provider,omitempty"`
+	Azure             *AzureConfig `json:"azure,omitempty"`
+	HTTP              *HTTPConfig  `json:"http,omitempty"`
+	// Templates holds user-defined prompt templates keyed by name
+	Templates       map[string]*PromptTemplate `json:"templates,omitempty"`
+	DefaultTemplate string                     `json:"default_template,omitempty"`
+	// Presets holds named style presets keyed by name
+	Presets     map[string]*Preset `json:"presets,omitempty"`
+	Brand       *BrandKit          `json:"brand,omitempty"`
+	Enhance     *EnhanceConfig     `json:"enhance,omitempty"`
+	Output      *OutputConfig      `json:"output,omitempty"`
+	Log         *LogConfig         `json:"log,omitempty"`
+	Initialized bool               `json:"initialized"`
+}
+
+// OutputConfig controls how saved images are named and grouped
+type OutputConfig struct {
+	// FilenameTemplate names saved images, e.g. "{slug}-{date}-{n}.{ext}";
+	// empty uses the default timestamp name
+	FilenameTemplate string `json:"filename_template,omitempty"`
+	// PromptSubdirs saves each prompt's images in a subdirectory named
+	// after the prompt
+	PromptSubdirs bool `json:"prompt_subdirs,omitempty"`
+	// ContactSheet writes an index.html contact sheet of each run into the
+	// output directory
+	ContactSheet bool `json:"contact_sheet,omitempty"`
+	// Preview selects how images are drawn in the terminal: auto, kitty,
+	// iterm2, sixel, blocks or off; empty means auto
+	Preview string `json:"preview,omitempty"`
+}
+
+// LogConfig controls the request log in the state directory
+type LogConfig struct {
+	// Disabled turns the request log off
+	Disabled bool `json:"disabled,omitempty"`
+	// Level is the lowest level written: debug, info, warn or error; empty
+	// means info
+	Level string `json:"level,omitempty"`
+	// MaxSizeMB rotates the log once it grows past this size; zero uses
+	// DefaultLogMaxSizeMB
+	MaxSizeMB int `json:"max_size_mb,omitempty"`
+	// MaxFiles is how many rotated logs are kept; zero uses
+	// DefaultLogMaxFiles
+	MaxFiles int `json:"max_files,omitempty"`
+	// HashPrompts writes a hash of each prompt instead of its text, which
+	// still tells requests with the same prompt apart
+	HashPrompts bool `json:"hash_prompts,omitempty"`
+	// RetentionDays drops records older than this many days when the
+	// program starts; zero keeps them until the log rotates
+	RetentionDays int `json:"retention_days,omitempty"`
+}
+
+// EnhanceConfig configures prompt enhancement through a chat model before
+// image generation
+type EnhanceConfig struct {
+	Enabled bool `json:"enabled"`
+	// Model is the chat model; empty uses DefaultEnhanceModel
+	Model string `json:"model,omitempty"`
+	// SystemPrompt instructs the chat model; empty uses the built-in brief
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// BrandKit describes brand constraints that are injected into prompts and
+// checked against generated images
+type BrandKit struct {
+	// Colors is the brand palette in order of importance
+	Colors          []BrandColor `json:"colors,omitempty"`
+	ForbiddenColors []string     `json:"forbidden_colors,omitempty"`
+	Mood            []string     `json:"mood,omitempty"`
+	// Typography describes lettering, e.g. "rounded geometric sans-serif"
+	Typography string `json:"typography,omitempty"`
+	// ReferenceLogo is an image sent along with the prompt as a style reference
+	ReferenceLogo string `json:"reference_logo,omitempty"`
+	// Tolerance is the largest color difference (CIE76) from the palette
+	// before the palette check warns; 0 uses the default
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// BrandColor is a named palette color
+type BrandColor struct {
+	Name string `json:"name"`
+	Hex  string `json:"hex"`
+}
+
+// Preset bundles a prompt template with generation option overrides. Empty
+// fields leave the corresponding option unchanged.
+type Preset struct {
+	Description  string `json:"description,omitempty"`
+	Template     string `json:"template,omitempty"`
+	Size         string `json:"size,omitempty"`
+	Quality      string `json:"quality,omitempty"`
+	Background   string `json:"background,omitempty"`
+	OutputFormat string `json:"output_format,omitempty"`
+	NumImages    int    `json:"num_images,omitempty"`
+}
+
+// PromptTemplate is a text/template that turns the user's description into
+// the prompt sent to the image API. Available variables are {{.Prompt}},
+// {{.Size}}, {{.Platform}}, {{.Palette}}, {{.Avoid}}, {{.Mood}},
+// {{.Typography}} and {{.Reference}}.
+type PromptTemplate struct {
+	Description string `json:"description,omitempty"`
+	Platform    string `json:"platform,omitempty"`
+	Text        string `json:"text"`
+}
+
+// AzureConfig configures the Azure OpenAI provider
+type AzureConfig struct {
+	// Endpoint is the resource URL, e.g. https://my-resource.openai.azure.com
+	Endpoint   string `json:"endpoint,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	// Auth is AzureAuthKey (api-key header) or AzureAuthAAD (Entra ID bearer token)
+	Auth   string `json:"auth,omitempty"`
+	APIKey string `json:"api_key,omitempty"`
+	// TokenCommand prints an Entra ID access token when AZURE_OPENAI_AD_TOKEN is unset
+	TokenCommand string `json:"token_command,omitempty"`
+	// Deployments maps deployment names to the model each one serves
+	Deployments map[string]string `json:"deployments,omitempty"`
+}
+
+// HTTPConfig configures the HTTP client used for API calls and image downloads
+type HTTPConfig struct {
+	ProxyURL       string            `json:"proxy_url,omitempty"`
+	CAFile         string            `json:"ca_file,omitempty"`
+	ClientCertFile string            `json:"client_cert_file,omitempty"`
+	ClientKeyFile  string            `json:"client_key_file,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	UserAgent      string            `json:"user_agent,omitempty"`
+}
+
+// SharedConfig is the portable form of Config used to share defaults with a
+// team. It never contains secrets.
+type SharedConfig struct {
+	Version    int                        `json:"version"`
+	ExportedAt string                     `json:"exported_at,omitempty"`
+	Settings   map[string]json.RawMessage `json:"settings"`
 }
 
 // IconGenerationOptions represents options for icon generation
@@ -62,6 +220,49 @@ type IconGenerationOptions struct {
 	NumImages    int    `json:"num_images,omitempty"`
 	Moderation   string `json:"moderation,omitempty"`
 	RawPrompt    bool   `json:"raw_prompt,omitempty"`
+	// Template names the prompt template; empty uses the configured default
+	Template string `json:"template,omitempty"`
+	// Brand injects the brand kit into the prompt; nil disables it
+	Brand *BrandKit `json:"brand,omitempty"`
+	// OriginalPrompt is the user's prompt before enhancement, empty when the
+	// prompt was not enhanced
+	OriginalPrompt string `json:"original_prompt,omitempty"`
+	// Reference attaches an image the result should build on; it takes
+	// precedence over the brand logo
+	Reference string `json:"reference,omitempty"`
+}
+
+// GenerationResult is the outcome of an image generation request
+type GenerationResult struct {
+	Images []GeneratedImage
+	// Prompt is the final prompt sent to the API after templating
+	Prompt string
+	// Template names the template that wrapped the prompt, empty for raw prompts
+	Template     string
+	Model        string
+	Size         string
+	Quality      string
+	Background   string
+	OutputFormat string
+	Provider     string
+	Usage        ImageUsage
+	// Cost is the estimated price of the whole request in US dollars
+	Cost    float64
+	Created time.Time
+}
+
+// GeneratedImage is one image returned by the API
+type GeneratedImage struct {
+	Base64 string
+	// RevisedPrompt is the prompt the model actually used, when it reports one
+	RevisedPrompt string
+}
+
+// ImageUsage is the token usage reported by the image API
+type ImageUsage struct {
+	InputTokens      int `json:"input_tokens,omitempty"`
+	InputImageTokens int `json:"input_image_tokens,omitempty"`
+	OutputTokens     int `json:"output_tokens,omitempty"`
 }
 
 // OpenAIImageResponse represents the response from OpenAI image generation API
@@ -112,6 +313,11 @@ var SupportedOutputFormats = map[string][]string{
 	ModelGPTImage1: {"png", "jpeg", "webp"},
 }
 
+// SupportedModerations contains the mapping of models to their supported moderation levels
+var SupportedModerations = map[string][]string{
+	ModelGPTImage1: {"auto", "low"},
+}
+
 // DefaultValues contains default configuration values
 var DefaultValues = struct {
 	Model        string
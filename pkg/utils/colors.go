@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/pterm/pterm"
 	"just-icon/internal/types"
@@ -28,47 +29,70 @@ var (
 	Dim  = pterm.FgGray.Sprint
 )
 
-// PrintSuccess prints a success message with green checkmark
+// PrintSuccess prints a success message with green checkmark to stderr,
+// unless output is quiet
 func PrintSuccess(message string) {
-	pterm.Printf("%s %s\n", Green("✅"), message)
+	if quiet {
+		return
+	}
+	printMessage(Green("✅"), "", message)
 }
 
-// PrintError prints an error message with red X
+// PrintError prints an error message with red X to stderr
 func PrintError(message string) {
-	pterm.Printf("%s %s\n", Red("❌"), message)
+	printMessage(Red("❌"), "error", message)
 }
 
-// PrintInfo prints an info message with blue info icon
+// PrintInfo prints an info message with blue info icon to stderr, unless
+// output is quiet
 func PrintInfo(message string) {
-	pterm.Printf("%s %s\n", Blue("ℹ️"), message)
+	if quiet {
+		return
+	}
+	printMessage(Blue("ℹ️"), "", message)
 }
 
-// PrintWarning prints a warning message with yellow warning icon
+// PrintWarning prints a warning message with yellow warning icon to stderr
 func PrintWarning(message string) {
-	pterm.Printf("%s %s\n", Yellow("⚠️"), message)
+	printMessage(Yellow("⚠️"), "warning", message)
+}
+
+// printMessage writes a message to stderr behind its icon, or behind a
+// plain label when stdout is not a terminal
+func printMessage(icon, label, message string) {
+	switch {
+	case decorated:
+		fmt.Fprintf(os.Stderr, "%s %s\n", icon, message)
+	case label != "":
+		fmt.Fprintf(os.Stderr, "%s: %s\n", label, message)
+	default:
+		fmt.Fprintln(os.Stderr, message)
+	}
 }
 
-// PrintHeader prints a header with emoji using pterm's enhanced styling
+// PrintHeader prints a header with emoji using pterm's enhanced styling to
+// stderr, unless output is quiet
 func PrintHeader(message string) {
 	// Create a styled header with pterm
 	headerStyle := pterm.NewStyle(pterm.FgLightCyan, pterm.Bold)
-	pterm.Printf("%s %s\n", "🎨", headerStyle.Sprint(message))
+	pterm.Fprintln(Diagnostics(), fmt.Sprintf("%s %s", "🎨", headerStyle.Sprint(message)))
 }
 
-// PrintSubHeader prints a sub-header with enhanced styling
+// PrintSubHeader prints a sub-header with enhanced styling to stderr, unless
+// output is quiet
 func PrintSubHeader(message string) {
 	subHeaderStyle := pterm.NewStyle(pterm.FgLightGreen, pterm.Bold)
-	pterm.Printf("\n%s\n", subHeaderStyle.Sprint(message))
+	pterm.Fprintln(Diagnostics(), "\n"+subHeaderStyle.Sprint(message))
 }
 
-// PrintKeyValue prints a key-value pair with consistent formatting
+// PrintKeyValue prints a key-value pair with consistent formatting to stderr
 func PrintKeyValue(key, value string) {
-	pterm.Printf("%s %s\n", Bold(key+":"), value)
+	pterm.Fprintln(os.Stderr, fmt.Sprintf("%s %s", Bold(key+":"), value))
 }
 
-// PrintDim prints dimmed text
+// PrintDim prints dimmed text to stderr, unless output is quiet
 func PrintDim(message string) {
-	pterm.Println(Dim(message))
+	pterm.Fprintln(Diagnostics(), Dim(message))
 }
 
 // MaskAPIKey masks an API key for display, showing only the last 4 characters
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"io"
+	"os"
 	"strings"
 	"testing"
 )
@@ -85,3 +87,57 @@ func TestColorFunctions(t *testing.T) {
 		})
 	}
 }
+
+// captureOutput runs fn and returns what it wrote to stdout and stderr
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	errR, errW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outW, errW
+	fn()
+	outW.Close()
+	errW.Close()
+
+	out, _ := io.ReadAll(outR)
+	errOut, _ := io.ReadAll(errR)
+	return string(out), string(errOut)
+}
+
+func TestPrintHelpersWriteToStderr(t *testing.T) {
+	defer ConfigureOutput(false, false)
+
+	stdout, stderr := captureOutput(t, func() {
+		PrintHeader("header")
+		PrintSubHeader("section")
+		PrintKeyValue("key", "value")
+		PrintDim("hint")
+	})
+	if stdout != "" {
+		t.Errorf("print helpers wrote to stdout: %q", stdout)
+	}
+	for _, want := range []string{"header", "section", "key:", "value", "hint"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr is missing %q: %q", want, stderr)
+		}
+	}
+
+	// Quiet output keeps the values but drops headers and hints
+	ConfigureOutput(true, false)
+	_, stderr = captureOutput(t, func() {
+		PrintSubHeader("section")
+		PrintKeyValue("key", "value")
+		PrintDim("hint")
+	})
+	if !strings.Contains(stderr, "value") || strings.Contains(stderr, "section") || strings.Contains(stderr, "hint") {
+		t.Errorf("quiet output = %q, want only the key-value pair", stderr)
+	}
+}
@@ -12,38 +12,42 @@ import (
 	"just-icon/internal/types"
 )
 
+// httpClient downloads files for DownloadFile; SetHTTPClient replaces it
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// SetHTTPClient sets the client DownloadFile uses. The CLI passes the one
+// built from the configured proxy, CA bundle and headers.
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
 // DownloadFile downloads a file from URL and saves it to the specified path
+// with the client set by SetHTTPClient
 func DownloadFile(url, filepath string) error {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-	}
-	
-	// Make request
+	return DownloadFileWithClient(httpClient, url, filepath)
+}
+
+// DownloadFileWithClient downloads a file using the given HTTP client
+func DownloadFileWithClient(client *http.Client, url, filepath string) error {
 	resp, err := client.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
 	}
 	defer resp.Body.Close()
-	
-	// Check response status
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("bad status: %s", resp.Status)
 	}
-	
-	// Create the file
+
 	out, err := os.Create(filepath)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer out.Close()
-	
-	// Copy data
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
 		return fmt.Errorf("failed to save file: %w", err)
 	}
-	
 	return nil
 }
 
@@ -77,12 +81,14 @@ func SaveBase64Image(base64Data, filepath string) error {
 		return fmt.Errorf("failed to decode base64 data: %w", err)
 	}
 
-	// Write image to file
-	err = os.WriteFile(filepath, imgBytes, 0644)
-	if err != nil {
+	return SaveImage(imgBytes, filepath)
+}
+
+// SaveImage writes image data to the specified path
+func SaveImage(data []byte, filepath string) error {
+	if err := os.WriteFile(filepath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write image file: %w", err)
 	}
-
 	return nil
 }
 
@@ -98,8 +104,8 @@ func GenerateTimestampFileName(format string) string {
 	now := time.Now()
 	timestamp := now.Format("20060102150405") // YYYYMMDDHHMMSS
 
-	// Generate 3-digit random number (000-999)
-	rand.Seed(now.UnixNano())
+	// Generate 3-digit random number (000-999); the global source is
+	// seeded automatically
 	randomNum := rand.Intn(1000)
 
 	return fmt.Sprintf("icon_%s%03d.%s", timestamp, randomNum, format)
@@ -1,11 +1,38 @@
 package utils
 
 import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
 )
 
+func TestDownloadFileUsesConfiguredClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "icon")
+	}))
+	defer server.Close()
+	defer SetHTTPClient(httpClient)
+
+	// The default client does not trust the test server's certificate
+	path := filepath.Join(t.TempDir(), "icon.png")
+	if err := DownloadFile(server.URL, path); err == nil {
+		t.Fatal("DownloadFile() trusted an unknown certificate")
+	}
+
+	SetHTTPClient(server.Client())
+	if err := DownloadFile(server.URL, path); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "icon" {
+		t.Errorf("downloaded %q", data)
+	}
+}
+
 func TestGenerateTimestampFileName(t *testing.T) {
 	tests := []struct {
 		name   string
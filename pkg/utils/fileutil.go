@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// WriteFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never observe a partially written file
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temp file on any failure before the rename
+	committed := false
+	defer func() {
+		if !committed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	committed = true
+
+	// Persist the rename itself
+	return syncDir(dir)
+}
+
+// maxExclusiveAttempts bounds the numbered names WriteFileExclusive tries
+const maxExclusiveAttempts = 10000
+
+// WriteFileExclusive creates a new file at path and writes data to it, never
+// replacing an existing file. When path is taken it tries name-2.ext,
+// name-3.ext and so on; reserved, if set, rejects candidates for other
+// reasons. It returns the path that was written.
+func WriteFileExclusive(path string, data []byte, perm os.FileMode, reserved func(string) bool) (string, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	for i := 1; i <= maxExclusiveAttempts; i++ {
+		candidate := path
+		if i > 1 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		if reserved != nil && reserved(candidate) {
+			continue
+		}
+
+		file, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to create file: %w", err)
+		}
+
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(candidate)
+			return "", fmt.Errorf("failed to write file: %w", err)
+		}
+		return candidate, nil
+	}
+	return "", fmt.Errorf("no free file name for %s", path)
+}
+
+// FileLock is an advisory, cross-process lock backed by a lock file
+type FileLock struct {
+	file *os.File
+	mu   *sync.Mutex
+}
+
+// lockMutexes serializes lockers inside a single process, since some
+// platforms only arbitrate file locks between processes
+var lockMutexes sync.Map
+
+// LockFile acquires an exclusive advisory lock on path, creating the lock
+// file if needed. It blocks until the lock is available.
+func LockFile(path string) (*FileLock, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	value, _ := lockMutexes.LoadOrStore(absPath, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		mu.Unlock()
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		mu.Unlock()
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return &FileLock{file: file, mu: mu}, nil
+}
+
+// TryLockFile acquires the lock on path like LockFile, but returns at once
+// with ok false when another locker holds it
+func TryLockFile(path string) (lock *FileLock, ok bool, err error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	value, _ := lockMutexes.LoadOrStore(absPath, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, false, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		mu.Unlock()
+		return nil, false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	locked, err := tryLockFile(file)
+	if err != nil || !locked {
+		file.Close()
+		mu.Unlock()
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	return &FileLock{file: file, mu: mu}, true, nil
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	defer l.mu.Unlock()
+
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ExpandHome expands a leading ~ to the user's home directory
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, filepath.FromSlash(strings.TrimPrefix(path, "~")))
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileExclusive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "icon.png")
+
+	first, err := WriteFileExclusive(path, []byte("one"), 0644, nil)
+	if err != nil || first != path {
+		t.Fatalf("WriteFileExclusive() = %q, %v; want %q", first, err, path)
+	}
+	second, err := WriteFileExclusive(path, []byte("two"), 0644, nil)
+	if err != nil || second != filepath.Join(dir, "icon-2.png") {
+		t.Fatalf("WriteFileExclusive() = %q, %v; want icon-2.png", second, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "one" {
+		t.Errorf("existing file was overwritten with %q", data)
+	}
+
+	reserved := func(candidate string) bool { return candidate == filepath.Join(dir, "icon-3.png") }
+	third, err := WriteFileExclusive(path, []byte("three"), 0644, reserved)
+	if err != nil || third != filepath.Join(dir, "icon-4.png") {
+		t.Errorf("WriteFileExclusive() = %q, %v; want icon-4.png", third, err)
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"io"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/pterm/pterm"
+	"golang.org/x/term"
+)
+
+// Output settings shared by the print helpers
+var (
+	quiet   bool
+	colored = true
+	// decorated enables emoji, the banner, previews and live progress. It is
+	// off when stdout is piped or the terminal cannot move the cursor.
+	decorated = term.IsTerminal(int(os.Stdout.Fd())) && os.Getenv("TERM") != "dumb"
+)
+
+// ConfigureOutput applies the global output flags. Quiet hides the banner,
+// progress and informational messages; errors, warnings and results are
+// still printed. Colors are off with noColor, the NO_COLOR environment
+// variable, TERM=dumb or when stdout is not a terminal.
+func ConfigureOutput(quietMode, noColor bool) {
+	quiet = quietMode
+	colored = decorated && !noColor && os.Getenv("NO_COLOR") == ""
+	if !colored {
+		pterm.DisableColor()
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
+
+// Quiet reports whether informational output is hidden
+func Quiet() bool {
+	return quiet
+}
+
+// ColorEnabled reports whether output may use colors
+func ColorEnabled() bool {
+	return colored
+}
+
+// Decorated reports whether stdout is a terminal that gets emoji, the
+// banner, previews and live progress
+func Decorated() bool {
+	return decorated
+}
+
+// CanPrompt reports whether the user can answer questions: stdin and stdout
+// are both terminals
+func CanPrompt() bool {
+	return decorated && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Diagnostics returns where messages about the work go: stderr, or nowhere
+// in quiet mode. Stdout is kept for results so it can be piped.
+func Diagnostics() io.Writer {
+	if quiet {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// Report returns where human-readable reports such as lists, settings and
+// check results go: stderr, the stream of the headers and key-value pairs,
+// so a report is never split across two streams. Machine-readable results,
+// such as JSON and the paths of saved files, go to stdout.
+func Report() io.Writer {
+	return os.Stderr
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"io"
+	"testing"
+)
+
+func TestConfigureOutput(t *testing.T) {
+	defer ConfigureOutput(false, false)
+
+	ConfigureOutput(true, true)
+	if !Quiet() || Diagnostics() != io.Discard {
+		t.Error("quiet output still writes diagnostics")
+	}
+	if ColorEnabled() || Green("ok") != "ok" {
+		t.Errorf("colors not disabled: %q", Green("ok"))
+	}
+
+	ConfigureOutput(false, false)
+	if Quiet() || Diagnostics() == io.Discard {
+		t.Error("diagnostics hidden without quiet")
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// paletteSampleSize bounds the number of pixels sampled along each axis
+const paletteSampleSize = 256
+
+// ColorShare is a color and the fraction of opaque pixels close to it
+type ColorShare struct {
+	Color color.RGBA
+	Share float64
+}
+
+// LoadImage decodes a PNG, JPEG or WebP image from disk
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// DominantColors returns up to n of the most common colors in img, ignoring
+// transparent pixels. Colors are grouped into coarse buckets and each bucket
+// is reported as the average of its pixels.
+func DominantColors(img image.Image, n int) []ColorShare {
+	type bucket struct {
+		r, g, b, count int
+	}
+
+	bounds := img.Bounds()
+	stepX := max(1, bounds.Dx()/paletteSampleSize)
+	stepY := max(1, bounds.Dy()/paletteSampleSize)
+
+	buckets := make(map[int]*bucket)
+	total := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+
+			// 3 bits per channel gives 512 buckets
+			key := int(c.R>>5)<<6 | int(c.G>>5)<<3 | int(c.B>>5)
+			b := buckets[key]
+			if b == nil {
+				b = &bucket{}
+				buckets[key] = b
+			}
+			b.r += int(c.R)
+			b.g += int(c.G)
+			b.b += int(c.B)
+			b.count++
+			total++
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	var shares []ColorShare
+	for _, b := range buckets {
+		shares = append(shares, ColorShare{
+			Color: color.RGBA{
+				R: uint8(b.r / b.count),
+				G: uint8(b.g / b.count),
+				B: uint8(b.b / b.count),
+				A: 255,
+			},
+			Share: float64(b.count) / float64(total),
+		})
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].Share != shares[j].Share {
+			return shares[i].Share > shares[j].Share
+		}
+		return HexColor(shares[i].Color) < HexColor(shares[j].Color)
+	})
+
+	if len(shares) > n {
+		shares = shares[:n]
+	}
+	return shares
+}
+
+// ParseHexColor parses #RGB or #RRGGBB, with or without the leading #
+func ParseHexColor(hex string) (color.RGBA, error) {
+	value := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(value) == 3 {
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	}
+	if len(value) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q: use #RRGGBB", hex)
+	}
+
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid hex color %q: use #RRGGBB", hex)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
+
+// HexColor formats a color as #RRGGBB
+func HexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// ColorDistance returns the CIE76 difference between two colors. A value
+// around 2 is barely noticeable; above 30 the colors read as different.
+func ColorDistance(a, b color.RGBA) float64 {
+	l1, a1, b1 := toLab(a)
+	l2, a2, b2 := toLab(b)
+	return math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+// ColorChroma returns the colorfulness of c in CIELAB; greys, black and
+// white are close to 0
+func ColorChroma(c color.RGBA) float64 {
+	_, a, b := toLab(c)
+	return math.Sqrt(a*a + b*b)
+}
+
+// toLab converts an sRGB color to CIELAB under the D65 white point
+func toLab(c color.RGBA) (float64, float64, float64) {
+	r := linearize(c.R)
+	g := linearize(c.G)
+	b := linearize(c.B)
+
+	x := (0.4124*r + 0.3576*g + 0.1805*b) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*b
+	z := (0.0193*r + 0.1192*g + 0.9505*b) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// linearize converts an sRGB channel to linear light
+func linearize(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// labF is the CIELAB companding function
+func labF(t float64) float64 {
+	if t > 216.0/24389.0 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27.0*t + 16) / 116
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected color.RGBA
+		wantErr  bool
+	}{
+		{"#1E88E5", color.RGBA{0x1E, 0x88, 0xE5, 255}, false},
+		{"ffc107", color.RGBA{0xFF, 0xC1, 0x07, 255}, false},
+		{"#fff", color.RGBA{255, 255, 255, 255}, false},
+		{"#12345", color.RGBA{}, true},
+		{"#GGGGGG", color.RGBA{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseHexColor(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHexColor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseHexColor() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDominantColors(t *testing.T) {
+	blue := color.RGBA{0x1E, 0x88, 0xE5, 255}
+	red := color.RGBA{0xE5, 0x39, 0x35, 255}
+
+	// 75% blue, 25% red, with a transparent row that must be ignored
+	img := image.NewRGBA(image.Rect(0, 0, 100, 101))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if x < 75 {
+				img.Set(x, y, blue)
+			} else {
+				img.Set(x, y, red)
+			}
+		}
+	}
+
+	colors := DominantColors(img, 4)
+	if len(colors) != 2 {
+		t.Fatalf("expected 2 colors, got %v", colors)
+	}
+	if colors[0].Color != blue || colors[1].Color != red {
+		t.Errorf("unexpected colors %v", colors)
+	}
+	if colors[0].Share < 0.74 || colors[0].Share > 0.76 {
+		t.Errorf("unexpected share %f", colors[0].Share)
+	}
+}
+
+func TestColorDistance(t *testing.T) {
+	blue, _ := ParseHexColor("#1E88E5")
+	nearBlue, _ := ParseHexColor("#2090E0")
+	orange, _ := ParseHexColor("#FF9800")
+
+	if d := ColorDistance(blue, blue); d != 0 {
+		t.Errorf("distance to itself = %f", d)
+	}
+	if d := ColorDistance(blue, nearBlue); d > 10 {
+		t.Errorf("similar blues too far apart: %f", d)
+	}
+	if d := ColorDistance(blue, orange); d < 50 {
+		t.Errorf("blue and orange too close: %f", d)
+	}
+
+	grey, _ := ParseHexColor("#808080")
+	if c := ColorChroma(grey); c > 1 {
+		t.Errorf("grey chroma = %f", c)
+	}
+}